@@ -14,6 +14,7 @@ import (
 	"embyforge/internal/handler"
 	"embyforge/internal/middleware"
 	"embyforge/internal/model"
+	"embyforge/internal/util"
 
 	"github.com/gin-gonic/gin"
 )
@@ -108,6 +109,18 @@ func main() {
 	// 设置 Gin 为 release 模式
 	gin.SetMode(gin.ReleaseMode)
 
+	// 未设置加密密钥时敏感数据使用公开的内置默认密钥加密，等同于明文存储
+	if util.UsingDefaultEncryptionKey() {
+		log.Println("⚠️  ============================================================")
+		log.Println("⚠️  未设置 ENCRYPTION_KEY，敏感配置正在使用内置默认密钥加密！")
+		log.Println("⚠️  任何拿到数据库文件的人都可以解密 Emby/TMDB 密钥等敏感数据，")
+		log.Println("⚠️  请设置 ENCRYPTION_KEY（建议 32 位以上随机字符串）后重启，")
+		log.Println("⚠️  已有数据会在启动时自动使用新密钥重新加密。")
+		log.Println("⚠️  ============================================================")
+	} else if util.WeakEncryptionKey() {
+		log.Println("⚠️  ENCRYPTION_KEY 过短，建议使用 32 位以上的随机字符串")
+	}
+
 	// 初始化数据库
 	db, err := model.InitDB(cfg.DBPath)
 	if err != nil {
@@ -125,7 +138,7 @@ func main() {
 			db.Create(&model.SystemConfig{
 				Key:         "jwt_secret",
 				Value:       jwtSecret,
-				Description: "JWT 签名密钥（自动生成，修改密码时会更新）",
+				Description: "JWT 签名密钥（自动生成）",
 			})
 			log.Println("🔑 已生成并持久化 JWT 密钥")
 		} else {
@@ -150,7 +163,7 @@ func main() {
 	authHandler := handler.NewAuthHandler(db, cfg.JWTSecret)
 	embyConfigHandler := handler.NewEmbyConfigHandler(db)
 	scanHandler := handler.NewScanHandler(db)
-	cacheHandler := handler.NewCacheHandler(db, cfg.JWTSecret)
+	cacheHandler := handler.NewCacheHandler(db)
 	dashboardHandler := handler.NewDashboardHandler(db)
 	profileHandler := handler.NewProfileHandler(db, filepath.Dir(cfg.DBPath))
 	systemConfigHandler := handler.NewSystemConfigHandler(db)
@@ -161,6 +174,12 @@ func main() {
 	renderingWordsHandler := handler.NewRenderingWordsHandler(db)
 	embyCacheHandler := handler.NewEmbyCacheHandler(db)
 	quickDeleteHandler := handler.NewQuickDeleteHandler(db)
+	seriesPreferenceHandler := handler.NewSeriesPreferenceHandler(db)
+	userHandler := handler.NewUserHandler(db)
+	apiTokenHandler := handler.NewAPITokenHandler(db)
+	auditHandler := handler.NewAuditHandler(db)
+	streamTickets := middleware.NewStreamTickets(middleware.StreamTicketTTL)
+	streamTicketHandler := handler.NewStreamTicketHandler(streamTickets)
 
 	// 初始化 Gin 引擎
 	r := gin.New()
@@ -173,13 +192,19 @@ func main() {
 
 	// 创建Webhook速率限制器：每分钟最多10个请求
 	webhookRateLimiter := middleware.NewRateLimiter(10, time.Minute)
+	// 登录接口速率限制：每个 IP 每分钟最多 30 次（失败次数的递增延迟和锁定由 AuthHandler 处理）
+	loginRateLimiter := middleware.NewRateLimiter(30, time.Minute)
 
 	// 公开路由（无需认证）
 	public := r.Group("/api")
 	{
-		public.POST("/auth/login", authHandler.Login)
-		// GitHub Webhook 公开端点（带速率限制）
-		// 支持动态路径参数，但实际不使用（为了兼容生成的 URL）
+		public.POST("/auth/login", middleware.RateLimitMiddleware(loginRateLimiter), authHandler.Login)
+		public.POST("/auth/refresh", authHandler.Refresh)
+		public.GET("/auth/oidc/status", authHandler.GetOIDCStatus)
+		public.GET("/auth/oidc/login", authHandler.OIDCLogin)
+		public.GET("/auth/oidc/callback", authHandler.OIDCCallback)
+		// Git 仓库 Webhook 公开端点（带速率限制）
+		// 路径参数匹配 Webhook 配置生成的 URL，不带参数的旧地址仅在只有一个配置时可用
 		public.POST("/webhook/github", 
 			middleware.RateLimitMiddleware(webhookRateLimiter),
 			webhookHandler.HandleGitHubWebhook)
@@ -188,16 +213,37 @@ func main() {
 			webhookHandler.HandleGitHubWebhook)
 	}
 
-	// 受保护路由（需要 JWT 认证）
+	// 必须修改初始密码的用户只能访问以下接口
+	passwordChangeAllowed := map[string]bool{
+		"GET /api/profile":          true,
+		"PUT /api/profile/password": true,
+		"POST /api/auth/logout":     true,
+	}
+
+	// 受保护路由（需要 JWT 或个人 API 令牌认证，登录会话未被撤销），按角色分组授权：
+	// viewer 可查看仪表盘和异常列表，operator 可执行同步、分析和规则维护，
+	// admin 可删除媒体、修改 Emby/TMDB/Symedia/Webhook 配置和管理用户
 	protected := r.Group("/api")
-	protected.Use(middleware.JWTAuth(cfg.JWTSecret))
+	protected.Use(middleware.JWTAuth(cfg.JWTSecret, db), middleware.ActiveSession(db), middleware.UserRole(db),
+		middleware.PasswordChanged(passwordChangeAllowed), middleware.TokenScope(handler.APITokenScopes))
+	operator := protected.Group("", middleware.RequireRole(model.RoleOperator))
+	admin := protected.Group("", middleware.RequireRole(model.RoleAdmin))
 
-	// SSE 路由（handler 内部通过 query parameter 验证 JWT，不使用中间件）
-	r.GET("/api/cache/sync/stream", cacheHandler.SyncCacheStream)
+	// SSE 路由：EventSource 不能携带请求头，只接受 POST /api/stream-tickets 签发的一次性票据
+	r.GET("/api/cache/sync/stream", middleware.StreamTicketAuth(streamTickets, middleware.StreamCacheSync),
+		middleware.UserRole(db), middleware.PasswordChanged(nil), middleware.RequireRole(model.RoleOperator),
+		cacheHandler.SyncCacheStream)
 
 	// 启动 Emby WebSocket 实时监听（后台自动重连）
 	cacheHandler.StartWSListener()
 
+	// 启动 Symedia 刷新重试队列
+	webhookHandler.Deliveries.Start()
+
+	// 定期清理过期的 Webhook 日志
+	webhookHandler.StartLogRetention()
+
+	// 所有角色：查看
 	{
 		protected.GET("/dashboard", dashboardHandler.GetDashboard)
 
@@ -206,30 +252,30 @@ func main() {
 		protected.PUT("/profile/password", profileHandler.ChangePassword)
 		protected.POST("/profile/avatar", profileHandler.UploadAvatar)
 
-		protected.GET("/system-config", systemConfigHandler.GetAllConfigs)
-		protected.PUT("/system-config/:key", systemConfigHandler.UpdateConfig)
+		protected.POST("/auth/logout", authHandler.Logout)
+		protected.GET("/profile/sessions", authHandler.ListSessions)
+		protected.DELETE("/profile/sessions/:id", authHandler.RevokeSession)
+		protected.POST("/profile/sessions/revoke-others", authHandler.RevokeOtherSessions)
+
+		protected.GET("/profile/2fa", profileHandler.GetTwoFactorStatus)
+		protected.POST("/profile/2fa/setup", profileHandler.SetupTwoFactor)
+		protected.POST("/profile/2fa/enable", profileHandler.EnableTwoFactor)
+		protected.POST("/profile/2fa/disable", profileHandler.DisableTwoFactor)
+		protected.POST("/profile/2fa/recovery-codes", profileHandler.RegenerateRecoveryCodes)
+
+		protected.GET("/profile/api-tokens", apiTokenHandler.ListAPITokens)
+		protected.POST("/profile/api-tokens", apiTokenHandler.CreateAPIToken)
+		protected.DELETE("/profile/api-tokens/:id", apiTokenHandler.RevokeAPIToken)
 
-		protected.GET("/logs/recent", logsHandler.GetRecentLogs)
+		protected.POST("/stream-tickets", streamTicketHandler.IssueStreamTicket)
 
-		protected.GET("/emby-config", embyConfigHandler.GetConfig)
-		protected.POST("/emby-config", embyConfigHandler.SaveConfig)
-		protected.POST("/emby-config/test", embyConfigHandler.TestConnection)
 		protected.GET("/emby-config/server-info", embyConfigHandler.GetServerInfo)
 
-		protected.POST("/cache/sync", cacheHandler.SyncCache)
 		protected.GET("/cache/status", cacheHandler.GetCacheStatus)
 		protected.GET("/cache/sync/status", cacheHandler.GetSyncStatus)
 
-		protected.POST("/analyze/scrape-anomaly", scanHandler.AnalyzeScrapeAnomalies)
-		protected.POST("/analyze/duplicate-media", scanHandler.AnalyzeDuplicateMedia)
-		protected.POST("/analyze/episode-mapping", scanHandler.AnalyzeEpisodeMapping)
-
-		protected.POST("/cleanup/duplicate-media", scanHandler.CleanupDuplicateMedia)
 		protected.GET("/cleanup/duplicate-media/preview", scanHandler.PreviewDuplicateCleanup)
-		protected.POST("/cleanup/scrape-anomaly", scanHandler.CleanupScrapeAnomalies)
 		protected.GET("/cleanup/missing-poster-items", scanHandler.GetMissingPosterItems)
-		protected.POST("/cleanup/batch-find-posters", scanHandler.BatchFindPosters)
-		protected.POST("/cleanup/find-single-poster", scanHandler.FindSinglePoster)
 
 		protected.GET("/scan/scrape-anomaly", scanHandler.GetScrapeAnomalies)
 		protected.GET("/scan/duplicate-media", scanHandler.GetDuplicateMedia)
@@ -238,39 +284,123 @@ func main() {
 
 		protected.GET("/tmdb-cache", tmdbCacheHandler.GetTmdbCacheList)
 		protected.GET("/tmdb-cache/status", tmdbCacheHandler.GetTmdbCacheStatus)
-		protected.PUT("/tmdb-cache/:id", tmdbCacheHandler.UpdateTmdbCache)
-		protected.DELETE("/tmdb-cache/:id", tmdbCacheHandler.DeleteTmdbCache)
-		protected.DELETE("/tmdb-cache/show/:tmdbId", tmdbCacheHandler.DeleteTmdbCacheByShow)
-		protected.POST("/tmdb-cache/clear", tmdbCacheHandler.ClearTmdbCache)
-
-		// Symedia 配置管理
-		protected.GET("/symedia/config", symediaHandler.GetConfigs)
-		protected.POST("/symedia/save-config", symediaHandler.SaveConfig)
-		protected.POST("/symedia/refresh", symediaHandler.ManualRefresh)
-		protected.POST("/symedia/github-config-save", symediaHandler.SaveGithubConfigOnly)
-		protected.POST("/symedia/github-config", symediaHandler.SaveGithubConfig)
-
-		// 渲染词生成器
+
+		protected.GET("/tmdb/tv/:tmdbId/episode-groups", seriesPreferenceHandler.ListEpisodeGroups)
+		protected.GET("/tmdb/episode-groups/:groupId", seriesPreferenceHandler.GetEpisodeGroup)
+		protected.GET("/series-preferences", seriesPreferenceHandler.ListSeriesPreferences)
+
+		protected.GET("/webhook/logs", webhookHandler.ListWebhookLogs)
+		protected.GET("/webhook/logs/:id", webhookHandler.GetWebhookLog)
+
+		// 渲染词生成器（只读与预览）
 		protected.GET("/rendering-words/import-candidates", renderingWordsHandler.GetImportCandidates)
 		protected.GET("/rendering-words/validate-tmdb/:tmdbId", renderingWordsHandler.ValidateTmdbID)
+		protected.POST("/rendering-words/generate", renderingWordsHandler.GenerateRenderingWords)
+		protected.POST("/rendering-words/export", renderingWordsHandler.ExportRenderingWords)
+		protected.POST("/rendering-words/preview", renderingWordsHandler.PreviewRenderingWords)
+		protected.GET("/rendering-words/rule-sets", renderingWordsHandler.ListRuleSets)
+		protected.GET("/rendering-words/rule-sets/export", renderingWordsHandler.ExportRuleSets)
+		protected.GET("/rendering-words/rule-sets/:id", renderingWordsHandler.GetRuleSet)
+		protected.GET("/rendering-words/rule-sets/:id/versions", renderingWordsHandler.ListRuleSetVersions)
+		protected.GET("/rendering-words/rule-sets/:id/versions/:version/diff", renderingWordsHandler.DiffRuleSetVersion)
 
-		// Emby 缓存管理
 		protected.GET("/emby-cache", embyCacheHandler.GetEmbyCacheList)
 		protected.GET("/emby-cache/status", embyCacheHandler.GetEmbyCacheStatus)
-		protected.PUT("/emby-cache/:id", embyCacheHandler.UpdateEmbyCache)
-		protected.DELETE("/emby-cache/:id", embyCacheHandler.DeleteEmbyCache)
-		protected.POST("/emby-cache/:id/refresh", embyCacheHandler.RefreshEmbyCache)
 
-		// 快速删除
 		protected.GET("/quick-delete/search", quickDeleteHandler.SearchEmbyMedia)
 		protected.GET("/quick-delete/seasons/:seriesId", quickDeleteHandler.GetSeriesSeasons)
-		protected.POST("/quick-delete/delete", quickDeleteHandler.DeleteMedia)
+	}
+
+	// 操作员：同步、分析、缓存与规则维护
+	{
+		operator.GET("/logs/recent", logsHandler.GetRecentLogs)
+
+		operator.POST("/cache/sync", cacheHandler.SyncCache)
+
+		operator.POST("/analyze/scrape-anomaly", scanHandler.AnalyzeScrapeAnomalies)
+		operator.POST("/analyze/duplicate-media", scanHandler.AnalyzeDuplicateMedia)
+		operator.POST("/analyze/episode-mapping", scanHandler.AnalyzeEpisodeMapping)
+
+		operator.POST("/cleanup/batch-find-posters", scanHandler.BatchFindPosters)
+		operator.POST("/cleanup/find-single-poster", scanHandler.FindSinglePoster)
+
+		operator.PUT("/tmdb-cache/:id", tmdbCacheHandler.UpdateTmdbCache)
+		operator.DELETE("/tmdb-cache/:id", tmdbCacheHandler.DeleteTmdbCache)
+		operator.DELETE("/tmdb-cache/show/:tmdbId", tmdbCacheHandler.DeleteTmdbCacheByShow)
+		operator.POST("/tmdb-cache/clear", tmdbCacheHandler.ClearTmdbCache)
+
+		operator.PUT("/series-preferences/:embyItemId/episode-group", seriesPreferenceHandler.PinEpisodeGroup)
+		operator.DELETE("/series-preferences/:embyItemId/episode-group", seriesPreferenceHandler.UnpinEpisodeGroup)
+		operator.PUT("/series-preferences/:embyItemId/reference-source", seriesPreferenceHandler.SetReferenceSource)
+
+		// Symedia 刷新重试队列
+		operator.GET("/symedia/deliveries", webhookHandler.ListDeliveries)
+		operator.POST("/symedia/deliveries/:id/replay", webhookHandler.ReplayDelivery)
+		operator.DELETE("/symedia/deliveries/:id", webhookHandler.DeleteDelivery)
+
+		// 渲染词规则集维护与发布
+		operator.POST("/rendering-words/publish", renderingWordsHandler.PublishRenderingWords)
+		operator.POST("/rendering-words/rule-sets", renderingWordsHandler.CreateRuleSet)
+		operator.POST("/rendering-words/rule-sets/import", renderingWordsHandler.ImportRuleSets)
+		operator.PUT("/rendering-words/rule-sets/:id", renderingWordsHandler.UpdateRuleSet)
+		operator.DELETE("/rendering-words/rule-sets/:id", renderingWordsHandler.DeleteRuleSet)
+		operator.POST("/rendering-words/rule-sets/:id/versions/:version/rollback", renderingWordsHandler.RollbackRuleSet)
+
+		// Emby 缓存管理（只影响本地缓存）
+		operator.PUT("/emby-cache/:id", embyCacheHandler.UpdateEmbyCache)
+		operator.DELETE("/emby-cache/:id", embyCacheHandler.DeleteEmbyCache)
+		operator.POST("/emby-cache/:id/refresh", embyCacheHandler.RefreshEmbyCache)
+	}
+
+	// 管理员：删除媒体、修改配置、用户管理
+	{
+		admin.GET("/system-config", systemConfigHandler.GetAllConfigs)
+		admin.PUT("/system-config/:key", systemConfigHandler.UpdateConfig)
+
+		admin.GET("/emby-config", embyConfigHandler.GetConfig)
+		admin.POST("/emby-config", embyConfigHandler.SaveConfig)
+		admin.POST("/emby-config/test", embyConfigHandler.TestConnection)
+
+		admin.POST("/cleanup/duplicate-media", scanHandler.CleanupDuplicateMedia)
+		admin.POST("/cleanup/scrape-anomaly", scanHandler.CleanupScrapeAnomalies)
+		admin.POST("/quick-delete/delete", quickDeleteHandler.DeleteMedia)
+
+		// Symedia 配置管理（手动刷新会保存 Symedia 地址和令牌）
+		admin.GET("/symedia/config", symediaHandler.GetConfigs)
+		admin.POST("/symedia/save-config", symediaHandler.SaveConfig)
+		admin.POST("/symedia/refresh", symediaHandler.ManualRefresh)
+		admin.POST("/symedia/github-config-save", symediaHandler.SaveGithubConfigOnly)
+		admin.POST("/symedia/github-config", symediaHandler.SaveGithubConfig)
+
+		// Webhook 配置（多仓库 / 多 Symedia）
+		admin.GET("/webhook-configs", webhookHandler.ListWebhookConfigs)
+		admin.POST("/webhook-configs", webhookHandler.CreateWebhookConfig)
+		admin.PUT("/webhook-configs/:id", webhookHandler.UpdateWebhookConfig)
+		admin.DELETE("/webhook-configs/:id", webhookHandler.DeleteWebhookConfig)
+		admin.POST("/webhook-configs/:id/regenerate-url", webhookHandler.RegenerateWebhookUrl)
+
+		// 用户管理
+		admin.GET("/users", userHandler.ListUsers)
+		admin.POST("/users", userHandler.CreateUser)
+		admin.PUT("/users/:id", userHandler.UpdateUser)
+		admin.DELETE("/users/:id", userHandler.DeleteUser)
+		admin.POST("/users/:id/reset-2fa", userHandler.ResetTwoFactor)
+
+		admin.GET("/login-failures", authHandler.ListLoginFailures)
+		admin.POST("/login-failures/clear", authHandler.ClearLoginFailures)
+
+		// 审计日志
+		admin.GET("/audit-logs", auditHandler.ListAuditLogs)
 	}
 
 	// 启动服务
 	addr := fmt.Sprintf(":%d", cfg.Port)
 	log.Printf("✅ EmbyForge 已启动，监听端口 %s", addr)
-	log.Println("📝 默认账户: admin / admin")
+	var pendingPasswordChange int64
+	db.Model(&model.User{}).Where("must_change_password = ?", true).Count(&pendingPasswordChange)
+	if pendingPasswordChange > 0 {
+		log.Printf("⚠️  %d 个账户仍在使用初始密码，登录后需先修改密码", pendingPasswordChange)
+	}
 	if err := r.Run(addr); err != nil {
 		log.Fatalf("❌ 服务启动失败: %v", err)
 	}
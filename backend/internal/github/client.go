@@ -0,0 +1,250 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL GitHub REST API 官方地址
+const DefaultBaseURL = "https://api.github.com"
+
+// APIError GitHub API 返回的非 2xx 响应
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API 错误 (状态码 %d): %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound 判断错误是否为 404（文件或仓库不存在）
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsConflict 判断错误是否为 409（文件 SHA 已过期，期间有其他提交）
+func IsConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// Client GitHub contents API 客户端，使用个人访问令牌（PAT）认证
+type Client struct {
+	Token      string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient 创建 GitHub API 客户端，baseURL 为空时使用官方地址（可指向本地替身服务或 GitHub Enterprise）
+func NewClient(token, baseURL string) (*Client, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.New("未配置 GitHub 访问令牌")
+	}
+
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if u, err := url.Parse(baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("GitHub API 地址格式无效: %s", baseURL)
+	}
+
+	return &Client{
+		Token:   token,
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// FileContent 仓库中的文件内容
+type FileContent struct {
+	Path    string
+	SHA     string // 文件 blob SHA，更新文件时需要回传
+	Content string // 已解码的文件内容
+}
+
+// CommitResult 写入文件后生成的提交
+type CommitResult struct {
+	ContentSHA string // 新文件 blob SHA
+	CommitSHA  string
+	HTMLURL    string
+}
+
+// PutFileRequest 创建或更新文件请求
+type PutFileRequest struct {
+	Message string
+	Content string
+	SHA     string // 更新已有文件时必填，创建新文件时为空
+	Branch  string
+}
+
+// ParseRepoURL 从仓库地址解析 owner 和 repo
+// 支持 https://github.com/owner/repo(.git)、git@github.com:owner/repo.git 和 owner/repo
+func ParseRepoURL(repoURL string) (string, string, error) {
+	s := strings.TrimSpace(repoURL)
+	switch {
+	case strings.HasPrefix(s, "git@"):
+		if i := strings.Index(s, ":"); i >= 0 {
+			s = s[i+1:]
+		}
+	case strings.Contains(s, "://"):
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", "", fmt.Errorf("仓库地址格式无效: %s", repoURL)
+		}
+		s = u.Path
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(strings.Trim(s, "/"), ".git"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("仓库地址格式无效，应为 https://github.com/owner/repo: %s", repoURL)
+	}
+	return parts[0], parts[1], nil
+}
+
+// contentsPath 构建 contents API 路径，文件路径逐段转义
+func contentsPath(owner, repo, filePath string) string {
+	segments := strings.Split(strings.Trim(filePath, "/"), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return fmt.Sprintf("/repos/%s/%s/contents/%s", url.PathEscape(owner), url.PathEscape(repo), strings.Join(segments, "/"))
+}
+
+// GetFile 获取文件内容，文件不存在时返回 nil, nil
+// 调用 GET /repos/{owner}/{repo}/contents/{path}?ref={branch}
+func (c *Client) GetFile(ctx context.Context, owner, repo, filePath, ref string) (*FileContent, error) {
+	path := contentsPath(owner, repo, filePath)
+	if ref != "" {
+		path += "?ref=" + url.QueryEscape(ref)
+	}
+
+	var resp struct {
+		Type     string `json:"type"`
+		Path     string `json:"path"`
+		SHA      string `json:"sha"`
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取文件失败 (%s/%s:%s): %w", owner, repo, filePath, err)
+	}
+	if resp.Type != "" && resp.Type != "file" {
+		return nil, fmt.Errorf("路径 %s 不是文件", filePath)
+	}
+
+	content := resp.Content
+	if resp.Encoding == "base64" {
+		// GitHub 返回的 base64 每 60 个字符换行
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(resp.Content, "\n", ""))
+		if err != nil {
+			return nil, fmt.Errorf("解码文件内容失败: %w", err)
+		}
+		content = string(decoded)
+	}
+
+	return &FileContent{Path: resp.Path, SHA: resp.SHA, Content: content}, nil
+}
+
+// PutFile 创建或更新文件，生成一个提交
+// 调用 PUT /repos/{owner}/{repo}/contents/{path}
+func (c *Client) PutFile(ctx context.Context, owner, repo, filePath string, req PutFileRequest) (*CommitResult, error) {
+	body := map[string]string{
+		"message": req.Message,
+		"content": base64.StdEncoding.EncodeToString([]byte(req.Content)),
+	}
+	if req.SHA != "" {
+		body["sha"] = req.SHA
+	}
+	if req.Branch != "" {
+		body["branch"] = req.Branch
+	}
+
+	var resp struct {
+		Content struct {
+			SHA string `json:"sha"`
+		} `json:"content"`
+		Commit struct {
+			SHA     string `json:"sha"`
+			HTMLURL string `json:"html_url"`
+		} `json:"commit"`
+	}
+	if err := c.do(ctx, http.MethodPut, contentsPath(owner, repo, filePath), body, &resp); err != nil {
+		return nil, fmt.Errorf("提交文件失败 (%s/%s:%s): %w", owner, repo, filePath, err)
+	}
+
+	return &CommitResult{
+		ContentSHA: resp.Content.SHA,
+		CommitSHA:  resp.Commit.SHA,
+		HTMLURL:    resp.Commit.HTMLURL,
+	}, nil
+}
+
+// do 发送请求并解析 JSON 响应
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &errResp) == nil && errResp.Message != "" {
+			message = errResp.Message
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析响应失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestParseRepoURL 解析常见的仓库地址格式
+func TestParseRepoURL(t *testing.T) {
+	for _, in := range []string{
+		"https://github.com/owner/repo",
+		"https://github.com/owner/repo.git",
+		"https://github.com/owner/repo/",
+		"git@github.com:owner/repo.git",
+		"owner/repo",
+	} {
+		owner, repo, err := ParseRepoURL(in)
+		if err != nil || owner != "owner" || repo != "repo" {
+			t.Errorf("ParseRepoURL(%q) = %q, %q, %v", in, owner, repo, err)
+		}
+	}
+	for _, in := range []string{"", "https://github.com/owner", "https://github.com/a/b/c"} {
+		if _, _, err := ParseRepoURL(in); err == nil {
+			t.Errorf("ParseRepoURL(%q) 应返回错误", in)
+		}
+	}
+}
+
+// TestGetAndPutFile 读取文件（含不存在的情况）并提交更新
+func TestGetAndPutFile(t *testing.T) {
+	var putBody map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/contents/dir/words.txt":
+			if r.URL.Query().Get("ref") != "main" {
+				t.Errorf("ref = %q", r.URL.Query().Get("ref"))
+			}
+			encoded := base64.StdEncoding.EncodeToString([]byte("旧内容\n"))
+			json.NewEncoder(w).Encode(map[string]string{
+				"type": "file", "path": "dir/words.txt", "sha": "blob1",
+				"encoding": "base64", "content": encoded[:4] + "\n" + encoded[4:],
+			})
+		case r.Method == http.MethodPut && r.URL.Path == "/repos/o/r/contents/dir/words.txt":
+			json.NewDecoder(r.Body).Decode(&putBody)
+			w.Write([]byte(`{"content":{"sha":"blob2"},"commit":{"sha":"c0ffee","html_url":"https://example/commit"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient("pat", server.URL+"/")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+
+	file, err := client.GetFile(ctx, "o", "r", "dir/words.txt", "main")
+	if err != nil || file == nil || file.SHA != "blob1" || file.Content != "旧内容\n" {
+		t.Fatalf("GetFile = %+v, %v", file, err)
+	}
+
+	missing, err := client.GetFile(ctx, "o", "r", "missing.txt", "main")
+	if err != nil || missing != nil {
+		t.Errorf("文件不存在时应返回 nil, nil: %+v, %v", missing, err)
+	}
+
+	result, err := client.PutFile(ctx, "o", "r", "dir/words.txt", PutFileRequest{Message: "msg", Content: "新内容\n", SHA: "blob1", Branch: "main"})
+	if err != nil || result.CommitSHA != "c0ffee" {
+		t.Fatalf("PutFile = %+v, %v", result, err)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(putBody["content"])
+	if string(decoded) != "新内容\n" || putBody["sha"] != "blob1" || putBody["branch"] != "main" {
+		t.Errorf("PUT 请求体不正确: %+v", putBody)
+	}
+
+	if _, err := NewClient("", ""); err == nil {
+		t.Error("未配置令牌时应返回错误")
+	}
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAPITokensPerUser 每个用户最多保留的有效 API 令牌数
+const maxAPITokensPerUser = 20
+
+// APITokenScopes 个人 API 令牌可访问的接口及所需授权范围，未列出的接口只能通过登录会话访问
+// （令牌和会话管理、管理员配置、用户、审计等）
+var APITokenScopes = map[string]string{
+	"GET /api/dashboard":                                            model.ScopeRead,
+	"GET /api/emby-config/server-info":                              model.ScopeRead,
+	"GET /api/cache/status":                                         model.ScopeRead,
+	"GET /api/cache/sync/status":                                    model.ScopeRead,
+	"GET /api/cleanup/duplicate-media/preview":                      model.ScopeRead,
+	"GET /api/cleanup/missing-poster-items":                         model.ScopeRead,
+	"GET /api/scan/scrape-anomaly":                                  model.ScopeRead,
+	"GET /api/scan/duplicate-media":                                 model.ScopeRead,
+	"GET /api/scan/episode-mapping":                                 model.ScopeRead,
+	"GET /api/scan/analysis-status":                                 model.ScopeRead,
+	"GET /api/tmdb-cache":                                           model.ScopeRead,
+	"GET /api/tmdb-cache/status":                                    model.ScopeRead,
+	"GET /api/tmdb/tv/:tmdbId/episode-groups":                       model.ScopeRead,
+	"GET /api/tmdb/episode-groups/:groupId":                         model.ScopeRead,
+	"GET /api/series-preferences":                                   model.ScopeRead,
+	"GET /api/webhook/logs":                                         model.ScopeRead,
+	"GET /api/webhook/logs/:id":                                     model.ScopeRead,
+	"GET /api/emby-cache":                                           model.ScopeRead,
+	"GET /api/emby-cache/status":                                    model.ScopeRead,
+	"GET /api/quick-delete/search":                                  model.ScopeRead,
+	"GET /api/quick-delete/seasons/:seriesId":                       model.ScopeRead,
+	"GET /api/rendering-words/import-candidates":                    model.ScopeRead,
+	"GET /api/rendering-words/validate-tmdb/:tmdbId":                model.ScopeRead,
+	"GET /api/rendering-words/rule-sets":                            model.ScopeRead,
+	"GET /api/rendering-words/rule-sets/export":                     model.ScopeRead,
+	"GET /api/rendering-words/rule-sets/:id":                        model.ScopeRead,
+	"GET /api/rendering-words/rule-sets/:id/versions":               model.ScopeRead,
+	"GET /api/rendering-words/rule-sets/:id/versions/:version/diff": model.ScopeRead,
+	"POST /api/rendering-words/generate":                            model.ScopeRead,
+	"POST /api/rendering-words/export":                              model.ScopeRead,
+	"POST /api/rendering-words/preview":                             model.ScopeRead,
+
+	"POST /api/cache/sync":             model.ScopeSync,
+	"POST /api/emby-cache/:id/refresh": model.ScopeSync,
+	"POST /api/symedia/refresh":        model.ScopeSync,
+	"POST /api/stream-tickets":         model.ScopeSync,
+
+	"POST /api/analyze/scrape-anomaly":  model.ScopeAnalyze,
+	"POST /api/analyze/duplicate-media": model.ScopeAnalyze,
+	"POST /api/analyze/episode-mapping": model.ScopeAnalyze,
+
+	"POST /api/cleanup/duplicate-media": model.ScopeDelete,
+	"POST /api/cleanup/scrape-anomaly":  model.ScopeDelete,
+	"POST /api/quick-delete/delete":     model.ScopeDelete,
+}
+
+// APITokenHandler 个人 API 令牌处理器
+type APITokenHandler struct {
+	DB *gorm.DB
+}
+
+// NewAPITokenHandler 创建个人 API 令牌处理器
+func NewAPITokenHandler(db *gorm.DB) *APITokenHandler {
+	return &APITokenHandler{DB: db}
+}
+
+// CreateAPITokenRequest 创建 API 令牌请求
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=3650"` // 0 表示永不过期
+}
+
+// APITokenResponse API 令牌响应，明文令牌只在创建时返回一次
+type APITokenResponse struct {
+	model.APIToken
+	Scopes []string `json:"scopes"`
+	Token  string   `json:"token,omitempty"`
+}
+
+func toAPITokenResponse(token model.APIToken) APITokenResponse {
+	return APITokenResponse{APIToken: token, Scopes: token.ScopeList()}
+}
+
+// ListAPITokens 获取当前用户未撤销的 API 令牌
+// GET /api/profile/api-tokens
+func (h *APITokenHandler) ListAPITokens(c *gin.Context) {
+	var tokens []model.APIToken
+	if err := h.DB.Where("user_id = ? AND revoked_at IS NULL", c.GetUint("userID")).
+		Order("id DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 API 令牌失败"})
+		return
+	}
+	data := make([]APITokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		data = append(data, toAPITokenResponse(token))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// CreateAPIToken 为当前用户创建 API 令牌
+// 令牌可访问的接口同时受授权范围和用户角色限制
+// POST /api/profile/api-tokens
+func (h *APITokenHandler) CreateAPIToken(c *gin.Context) {
+	var req CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "令牌名称不能为空"})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !model.IsValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的授权范围: " + scope})
+			return
+		}
+	}
+
+	userID := c.GetUint("userID")
+	var count int64
+	h.DB.Model(&model.APIToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count)
+	if count >= maxAPITokensPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "API 令牌数量已达上限，请先撤销不用的令牌"})
+		return
+	}
+
+	secret, err := util.RandomToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "令牌生成失败"})
+		return
+	}
+	plaintext := model.APITokenPrefix + secret
+	token := model.APIToken{
+		UserID:      userID,
+		Name:        name,
+		TokenPrefix: plaintext[:len(model.APITokenPrefix)+6],
+		TokenHash:   util.HashToken(plaintext),
+	}
+	token.SetScopes(req.Scopes)
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := h.DB.Create(&token).Error; err != nil {
+		log.Printf("❌ 创建 API 令牌失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建 API 令牌失败"})
+		return
+	}
+
+	log.Printf("🔑 用户 %s 创建了 API 令牌 %s（%s）", changedBy(c), token.Name, token.Scopes)
+	resp := toAPITokenResponse(token)
+	resp.Token = plaintext
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": resp})
+}
+
+// RevokeAPIToken 撤销当前用户的 API 令牌
+// DELETE /api/profile/api-tokens/:id
+func (h *APITokenHandler) RevokeAPIToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的令牌 ID"})
+		return
+	}
+	now := time.Now()
+	result := h.DB.Model(&model.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, c.GetUint("userID")).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "撤销 API 令牌失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "API 令牌不存在"})
+		return
+	}
+	log.Printf("🔑 用户 %s 撤销了 API 令牌 %d", changedBy(c), id)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "API 令牌已撤销"})
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"embyforge/internal/middleware"
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupAPITokenTest 注册令牌管理路由和两个按授权范围保护的测试接口
+func setupAPITokenTest(t *testing.T) (*gin.Engine, *AuthHandler) {
+	t.Helper()
+	r, h := setupAuthTest(t)
+	tokens := NewAPITokenHandler(h.DB)
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"user": c.GetString("username")}) }
+
+	protected := r.Group("/api", middleware.JWTAuth(h.JWTSecret, h.DB), middleware.ActiveSession(h.DB), middleware.UserRole(h.DB),
+		middleware.TokenScope(APITokenScopes))
+	protected.GET("/profile/api-tokens", tokens.ListAPITokens)
+	protected.POST("/profile/api-tokens", tokens.CreateAPIToken)
+	protected.DELETE("/profile/api-tokens/:id", tokens.RevokeAPIToken)
+	protected.GET("/dashboard", ok)
+	protected.POST("/cache/sync", ok)
+	protected.POST("/system-config", ok)
+	for _, path := range []string{"/system-config", "/emby-config", "/symedia/config", "/webhook-configs", "/users", "/login-failures", "/audit-logs", "/unlisted-report"} {
+		protected.GET(path, ok)
+	}
+	return r, h
+}
+
+func createAPIToken(t *testing.T, r *gin.Engine, session string, req CreateAPITokenRequest) APITokenResponse {
+	t.Helper()
+	w := doJSONWithToken(r, http.MethodPost, "/api/profile/api-tokens", session, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("创建 API 令牌失败: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data APITokenResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Data
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	r, h := setupAPITokenTest(t)
+	session := sessionLogin(t, r).Token
+
+	if w := doJSONWithToken(r, http.MethodPost, "/api/profile/api-tokens", session,
+		CreateAPITokenRequest{Name: "bad", Scopes: []string{"admin"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("无效授权范围期望 400，实际 %d", w.Code)
+	}
+
+	created := createAPIToken(t, r, session, CreateAPITokenRequest{Name: "nightly", Scopes: []string{"read", "read"}, ExpiresInDays: 30})
+	if created.Token == "" || created.ExpiresAt == nil || len(created.Scopes) != 1 {
+		t.Fatalf("创建结果不正确: %+v", created)
+	}
+	var stored model.APIToken
+	h.DB.First(&stored, created.ID)
+	if stored.TokenHash == created.Token {
+		t.Fatal("API 令牌不应明文存储")
+	}
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/dashboard", http.StatusOK},
+		{http.MethodPost, "/api/cache/sync", http.StatusForbidden},         // 缺少 sync
+		{http.MethodPost, "/api/system-config", http.StatusForbidden},      // 未列出的写接口
+		{http.MethodGet, "/api/profile/api-tokens", http.StatusForbidden},  // 令牌管理只能通过登录会话
+		{http.MethodPost, "/api/profile/api-tokens", http.StatusForbidden}, // 不能用令牌创建令牌
+		{http.MethodGet, "/api/system-config", http.StatusForbidden},       // 管理员配置只能通过登录会话查看
+		{http.MethodGet, "/api/emby-config", http.StatusForbidden},
+		{http.MethodGet, "/api/symedia/config", http.StatusForbidden},
+		{http.MethodGet, "/api/webhook-configs", http.StatusForbidden},
+		{http.MethodGet, "/api/users", http.StatusForbidden},
+		{http.MethodGet, "/api/login-failures", http.StatusForbidden},
+		{http.MethodGet, "/api/audit-logs", http.StatusForbidden},
+		{http.MethodGet, "/api/unlisted-report", http.StatusForbidden}, // 未列出的 GET 接口默认不可访问
+	}
+	for _, tc := range cases {
+		if w := doJSONWithToken(r, tc.method, tc.path, created.Token, CreateAPITokenRequest{}); w.Code != tc.want {
+			t.Errorf("%s %s 期望 %d，实际 %d", tc.method, tc.path, tc.want, w.Code)
+		}
+	}
+	h.DB.First(&stored, created.ID)
+	if stored.LastUsedAt == nil {
+		t.Error("使用后应记录最近使用时间")
+	}
+
+	syncToken := createAPIToken(t, r, session, CreateAPITokenRequest{Name: "sync", Scopes: []string{"sync"}})
+	if w := doJSONWithToken(r, http.MethodPost, "/api/cache/sync", syncToken.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("sync 令牌调用同步期望 200，实际 %d", w.Code)
+	}
+	if w := doJSONWithToken(r, http.MethodGet, "/api/dashboard", syncToken.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("缺少 read 的令牌查看数据期望 403，实际 %d", w.Code)
+	}
+
+	// 撤销后立即失效
+	if w := doJSONWithToken(r, http.MethodDelete, fmt.Sprintf("/api/profile/api-tokens/%d", syncToken.ID), session, nil); w.Code != http.StatusOK {
+		t.Fatalf("撤销失败: %d", w.Code)
+	}
+	if w := doJSONWithToken(r, http.MethodPost, "/api/cache/sync", syncToken.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("撤销后期望 401，实际 %d", w.Code)
+	}
+
+	// 过期后失效
+	h.DB.Model(&model.APIToken{}).Where("id = ?", created.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	if w := doJSONWithToken(r, http.MethodGet, "/api/dashboard", created.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("过期后期望 401，实际 %d", w.Code)
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 审计目标类型
+const (
+	auditTargetEmbyConfig    = "emby_config"
+	auditTargetSystemConfig  = "system_config"
+	auditTargetSymedia       = "symedia_config"
+	auditTargetGithubConfig  = "github_config"
+	auditTargetWebhookConfig = "webhook_config"
+	auditTargetTmdbCache     = "tmdb_cache"
+	auditTargetEmbyCache     = "emby_cache"
+	auditTargetUser          = "user"
+	auditTargetLoginFailure  = "login_failure"
+)
+
+// auditSecretFields 审计快照中需要脱敏的字段（JSON 字段名）
+var auditSecretFields = map[string]bool{
+	"api_key":      true,
+	"auth_token":   true,
+	"secret":       true,
+	"password":     true,
+	"token":        true,
+	"access_token": true,
+}
+
+// maskAuditSecrets 递归脱敏 JSON 解码结果中的敏感字段
+func maskAuditSecrets(v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if s, ok := child.(string); ok && auditSecretFields[k] {
+				val[k] = util.MaskToken(s)
+				continue
+			}
+			maskAuditSecrets(child)
+		}
+	case []interface{}:
+		for _, child := range val {
+			maskAuditSecrets(child)
+		}
+	}
+}
+
+// auditSnapshot 将操作目标序列化为 JSON 快照并脱敏，v 为 nil 时返回空字符串
+func auditSnapshot(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return ""
+	}
+	maskAuditSecrets(decoded)
+	masked, _ := json.Marshal(decoded)
+	return string(masked)
+}
+
+// auditConfigValue 系统配置值的审计快照，加密存储的配置项脱敏
+func auditConfigValue(key, value string) gin.H {
+	if isSecretConfigKey(key) {
+		value = util.MaskToken(value)
+	}
+	return gin.H{"key": key, "value": value}
+}
+
+// recordAudit 记录一条管理操作审计日志，操作人和来源 IP 取自请求上下文
+// before/after 为操作前后目标的值（创建时 before 为 nil，删除时 after 为 nil），前后一致的修改不记录。
+// 写入失败只输出日志，不影响操作本身
+func recordAudit(db *gorm.DB, c *gin.Context, action, targetType, targetID string, before, after interface{}) {
+	entry := model.AuditLog{
+		UserID:      c.GetUint("userID"),
+		Username:    changedBy(c),
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		BeforeValue: auditSnapshot(before),
+		AfterValue:  auditSnapshot(after),
+		IP:          c.ClientIP(),
+	}
+	if entry.BeforeValue != "" && entry.BeforeValue == entry.AfterValue {
+		return
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("⚠️  记录审计日志失败: action=%s, target=%s:%s, error=%v", action, targetType, targetID, err)
+	}
+}
+
+// AuditHandler 审计日志处理器（仅管理员）
+type AuditHandler struct {
+	DB *gorm.DB
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler(db *gorm.DB) *AuditHandler {
+	return &AuditHandler{DB: db}
+}
+
+// AuditLogResponse 审计日志响应，修改前后的值以 JSON 返回
+type AuditLogResponse struct {
+	model.AuditLog
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+func toAuditLogResponse(entry model.AuditLog) AuditLogResponse {
+	resp := AuditLogResponse{AuditLog: entry, Before: json.RawMessage("null"), After: json.RawMessage("null")}
+	if entry.BeforeValue != "" {
+		resp.Before = json.RawMessage(entry.BeforeValue)
+	}
+	if entry.AfterValue != "" {
+		resp.After = json.RawMessage(entry.AfterValue)
+	}
+	return resp
+}
+
+// ListAuditLogs 分页查询审计日志
+// 查询参数: page, pageSize, username, action, target_type, target_id, q（在目标和修改前后的值中模糊匹配）,
+// since/until（RFC3339 时间）
+// GET /api/audit-logs
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := h.DB.Model(&model.AuditLog{})
+	for param, column := range map[string]string{
+		"username": "username", "action": "action", "target_type": "target_type", "target_id": "target_id",
+	} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if q := c.Query("q"); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("target_id LIKE ? OR before_value LIKE ? OR after_value LIKE ?", like, like, like)
+	}
+	for param, cond := range map[string]string{"since": "created_at >= ?", "until": "created_at < ?"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": param + " 必须为 RFC3339 时间"})
+			return
+		}
+		query = query.Where(cond, t)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询审计日志失败"})
+		return
+	}
+	var entries []model.AuditLog
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询审计日志失败"})
+		return
+	}
+
+	data := make([]AuditLogResponse, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, toAuditLogResponse(entry))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": data, "total": total, "page": page, "page_size": pageSize,
+	})
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupAuditTest(t *testing.T) (*gin.Engine, *AuditHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	h := NewAuditHandler(db)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		c.Set("username", "admin")
+		c.Next()
+	})
+	r.POST("/api/emby-config", NewEmbyConfigHandler(db).SaveConfig)
+	r.PUT("/api/system-config/:key", NewSystemConfigHandler(db).UpdateConfig)
+	r.GET("/api/audit-logs", h.ListAuditLogs)
+	return r, h
+}
+
+type auditListResponse struct {
+	Data  []AuditLogResponse `json:"data"`
+	Total int64              `json:"total"`
+}
+
+func listAuditLogs(t *testing.T, r *gin.Engine, query string) auditListResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/audit-logs?"+query, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("查询审计日志失败: %d %s", w.Code, w.Body.String())
+	}
+	var resp auditListResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestAuditLog(t *testing.T) {
+	r, h := setupAuditTest(t)
+	const apiKey = "0123456789abcdef-emby-key"
+
+	for _, req := range []EmbyConfigRequest{
+		{Host: "http://emby.local", Port: 8096, APIKey: apiKey},
+		{Host: "http://emby.lan", Port: 8096, APIKey: apiKey},
+		{Host: "http://emby.lan", Port: 8096, APIKey: apiKey}, // 未修改，不记录
+	} {
+		if w := doRuleSetRequest(r, http.MethodPost, "/api/emby-config", req); w.Code != http.StatusOK {
+			t.Fatalf("保存 Emby 配置失败: %d %s", w.Code, w.Body.String())
+		}
+	}
+	const token = "tmdb-access-token-secret-value"
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/system-config/tmdb_access_token", UpdateConfigRequest{Value: token}); w.Code != http.StatusOK {
+		t.Fatalf("更新系统配置失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 敏感值不以明文出现在审计日志中
+	var entries []model.AuditLog
+	h.DB.Order("id").Find(&entries)
+	if len(entries) != 3 {
+		t.Fatalf("期望 3 条审计日志，实际 %d", len(entries))
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.BeforeValue+entry.AfterValue, apiKey) || strings.Contains(entry.BeforeValue+entry.AfterValue, token) {
+			t.Errorf("审计日志包含明文密钥: %+v", entry)
+		}
+		if entry.Username != "admin" || entry.UserID != 1 {
+			t.Errorf("操作人记录错误: %+v", entry)
+		}
+	}
+	if entries[0].Action != "create" || entries[0].BeforeValue != "" {
+		t.Errorf("创建操作应无修改前的值: %+v", entries[0])
+	}
+
+	resp := listAuditLogs(t, r, "target_type="+auditTargetEmbyConfig+"&action=update")
+	if resp.Total != 1 {
+		t.Fatalf("按目标和操作筛选期望 1 条，实际 %d", resp.Total)
+	}
+	var before, after map[string]interface{}
+	json.Unmarshal(resp.Data[0].Before, &before)
+	json.Unmarshal(resp.Data[0].After, &after)
+	if before["host"] != "http://emby.local" || after["host"] != "http://emby.lan" {
+		t.Errorf("修改前后的值错误: before=%v after=%v", before, after)
+	}
+	if after["api_key"] == "" || after["api_key"] == apiKey {
+		t.Errorf("API Key 应脱敏记录: %v", after["api_key"])
+	}
+
+	if resp := listAuditLogs(t, r, "q=emby.lan"); resp.Total != 1 {
+		t.Errorf("关键字搜索期望 1 条，实际 %d", resp.Total)
+	}
+	if resp := listAuditLogs(t, r, "q=emby.lan&action=create"); resp.Total != 0 {
+		t.Errorf("关键字搜索应与其他条件同时生效，实际 %d", resp.Total)
+	}
+	if resp := listAuditLogs(t, r, "target_id=tmdb_access_token"); resp.Total != 1 {
+		t.Errorf("按目标 ID 筛选期望 1 条，实际 %d", resp.Total)
+	}
+	if resp := listAuditLogs(t, r, "username=someone-else"); resp.Total != 0 {
+		t.Errorf("按操作人筛选期望 0 条，实际 %d", resp.Total)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/audit-logs?since=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("无效的时间参数期望 400，实际 %d", w.Code)
+	}
+}
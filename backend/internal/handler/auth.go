@@ -3,8 +3,8 @@ package handler
 import (
 	"log"
 	"net/http"
+	"time"
 
-	"embyforge/internal/middleware"
 	"embyforge/internal/model"
 
 	"github.com/gin-gonic/gin"
@@ -16,23 +16,30 @@ import (
 type AuthHandler struct {
 	DB        *gorm.DB
 	JWTSecret string
+
+	oidc *oidcState
 }
 
 // NewAuthHandler 创建认证处理器
 func NewAuthHandler(db *gorm.DB, jwtSecret string) *AuthHandler {
-	return &AuthHandler{DB: db, JWTSecret: jwtSecret}
+	return &AuthHandler{DB: db, JWTSecret: jwtSecret, oidc: &oidcState{pending: make(map[string]oidcPending)}}
 }
 
 // LoginRequest 登录请求体
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	TOTPCode string `json:"totp_code"` // 启用两步验证时必填，也可填写恢复码
 }
 
 // LoginResponse 登录响应体
 type LoginResponse struct {
-	Token    string `json:"token"`
-	Username string `json:"username"`
+	Token              string `json:"token"`         // 短期访问令牌
+	RefreshToken       string `json:"refresh_token"` // 刷新令牌，每次使用后轮换
+	ExpiresIn          int64  `json:"expires_in"`    // 访问令牌有效期（秒）
+	Username           string `json:"username"`
+	Role               string `json:"role"`
+	MustChangePassword bool   `json:"must_change_password"` // 使用初始密码，需先修改密码才能访问其他接口
 }
 
 // Login 处理用户登录
@@ -43,10 +50,17 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// 失败次数过多时在校验密码前拒绝，避免继续猜测
+	if wait := h.loginRetryAfter(req.Username, c.ClientIP(), time.Now()); wait > 0 {
+		rejectThrottledLogin(c, req.Username, wait)
+		return
+	}
+
 	// 查询用户
 	var user model.User
 	if err := h.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
 		log.Printf("🔐 用户 %s 登录失败: 用户不存在", req.Username)
+		h.recordLoginFailure(c, req.Username, model.LoginFailureUnknownUser)
 		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户名或密码错误"})
 		return
 	}
@@ -54,20 +68,34 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
 		log.Printf("🔐 用户 %s 登录失败: 密码错误", req.Username)
+		h.recordLoginFailure(c, req.Username, model.LoginFailureBadPassword)
 		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户名或密码错误"})
 		return
 	}
 
-	// 生成 JWT 令牌
-	token, err := middleware.GenerateToken(user.ID, user.Username, h.JWTSecret)
+	// 启用两步验证时，第二因素通过后才签发令牌
+	if user.TOTPEnabled {
+		if req.TOTPCode == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "请输入两步验证码", "two_factor_required": true})
+			return
+		}
+		if !verifySecondFactor(h.DB, &user, req.TOTPCode, time.Now()) {
+			log.Printf("🔐 用户 %s 登录失败: 两步验证码错误", req.Username)
+			h.recordLoginFailure(c, req.Username, model.LoginFailureBadTOTP)
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "两步验证码错误", "two_factor_required": true})
+			return
+		}
+	}
+
+	h.clearLoginFailures(user.Username)
+
+	// 创建登录会话并签发令牌
+	resp, err := h.issueSession(c, &user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "令牌生成失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, LoginResponse{
-		Token:    token,
-		Username: user.Username,
-	})
+	c.JSON(http.StatusOK, resp)
 	log.Printf("🔐 用户 %s 登录成功", user.Username)
 }
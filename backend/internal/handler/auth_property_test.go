@@ -84,6 +84,9 @@ func TestProperty_LoginCredentialConsistency(t *testing.T) {
 			rapid.StringMatching(`[a-zA-Z0-9!@#]{3,20}`),
 		).Draw(t, "password")
 
+		// 每次迭代前清空登录失败记录，避免防爆破限制影响凭据校验结果
+		h.DB.Where("1 = 1").Delete(&model.LoginFailure{})
+
 		// 判断预期结果：凭据是否有效
 		isValidCredential := (username == "admin" && password == "admin") ||
 			(username == "testuser" && password == "testpass123")
@@ -10,12 +10,10 @@ import (
 	"time"
 
 	"embyforge/internal/emby"
-	"embyforge/internal/middleware"
 	"embyforge/internal/model"
 	"embyforge/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
@@ -91,7 +89,6 @@ func (a *activeSync) closeAllListeners() {
 // CacheHandler 缓存处理器
 type CacheHandler struct {
 	DB           *gorm.DB
-	JWTSecret    string
 	CacheService *service.CacheService
 
 	syncMu     sync.Mutex
@@ -101,10 +98,9 @@ type CacheHandler struct {
 }
 
 // NewCacheHandler 创建缓存处理器
-func NewCacheHandler(db *gorm.DB, jwtSecret string) *CacheHandler {
+func NewCacheHandler(db *gorm.DB) *CacheHandler {
 	return &CacheHandler{
 		DB:           db,
-		JWTSecret:    jwtSecret,
 		CacheService: service.NewCacheService(db),
 	}
 }
@@ -317,23 +313,9 @@ func (h *CacheHandler) GetSyncStatus(c *gin.Context) {
 }
 
 // SyncCacheStream GET /api/cache/sync/stream - SSE 实时推送同步进度
-// 使用 URL query parameter 传递 JWT token（因为 EventSource 不支持自定义 header）
+// EventSource 不支持自定义 header，使用 POST /api/stream-tickets 签发的一次性票据认证（见 middleware.StreamTicketAuth）
 func (h *CacheHandler) SyncCacheStream(c *gin.Context) {
-	// 从 query parameter 获取 token 并手动验证 JWT
-	tokenStr := c.Query("token")
-	if tokenStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "缺少认证令牌"})
-		return
-	}
-
-	claims := &middleware.Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(h.JWTSecret), nil
-	})
-	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "认证令牌无效或已过期"})
-		return
-	}
+	username := c.GetString("username")
 
 	// 获取 Emby 客户端
 	client, err := h.getEmbyClient()
@@ -350,9 +332,9 @@ func (h *CacheHandler) SyncCacheStream(c *gin.Context) {
 		if fullSync {
 			mode = "全量"
 		}
-		log.Printf("🔄 SSE 触发新%s同步任务 (用户: %s)", mode, claims.Username)
+		log.Printf("🔄 SSE 触发新%s同步任务 (用户: %s)", mode, username)
 	} else {
-		log.Printf("🔄 SSE 连接到已有同步任务 (用户: %s)", claims.Username)
+		log.Printf("🔄 SSE 连接到已有同步任务 (用户: %s)", username)
 	}
 
 	// 设置 SSE 响应头
@@ -372,14 +354,14 @@ func (h *CacheHandler) SyncCacheStream(c *gin.Context) {
 	listenerCh := as.addListener()
 	defer as.removeListener(listenerCh)
 
-	log.Printf("🔄 SSE 同步流已建立 (用户: %s)", claims.Username)
+	log.Printf("🔄 SSE 同步流已建立 (用户: %s)", username)
 
 	// 从订阅通道读取事件并推送 SSE
 	for {
 		select {
 		case <-c.Request.Context().Done():
 			// 客户端断开 SSE 连接（不影响后台同步）
-			log.Printf("⚠️ SSE 连接已断开 (用户: %s)", claims.Username)
+			log.Printf("⚠️ SSE 连接已断开 (用户: %s)", username)
 			return
 
 		case progress, ok := <-listenerCh:
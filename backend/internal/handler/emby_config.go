@@ -3,6 +3,7 @@ package handler
 import (
 	"log"
 	"net/http"
+	"strconv"
 
 	"embyforge/internal/emby"
 	"embyforge/internal/model"
@@ -44,6 +45,11 @@ func (h *EmbyConfigHandler) GetConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": config})
 }
 
+// embyConfigAudit Emby 配置的审计快照
+func embyConfigAudit(config model.EmbyConfig) gin.H {
+	return gin.H{"host": config.Host, "port": config.Port, "api_key": config.APIKey}
+}
+
 // SaveConfig 保存 Emby 配置（upsert，只保留一条记录）
 func (h *EmbyConfigHandler) SaveConfig(c *gin.Context) {
 	var req EmbyConfigRequest
@@ -66,6 +72,7 @@ func (h *EmbyConfigHandler) SaveConfig(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存配置失败"})
 			return
 		}
+		recordAudit(h.DB, c, "create", auditTargetEmbyConfig, strconv.Itoa(int(config.ID)), nil, embyConfigAudit(config))
 		log.Printf("⚙️ Emby 配置已保存: %s:%d", req.Host, req.Port)
 		c.JSON(http.StatusOK, gin.H{"data": config, "message": "配置保存成功"})
 		return
@@ -77,6 +84,7 @@ func (h *EmbyConfigHandler) SaveConfig(c *gin.Context) {
 	}
 
 	// 更新已有记录
+	before := embyConfigAudit(existing)
 	existing.Host = req.Host
 	existing.Port = req.Port
 	existing.APIKey = req.APIKey
@@ -85,6 +93,7 @@ func (h *EmbyConfigHandler) SaveConfig(c *gin.Context) {
 		return
 	}
 
+	recordAudit(h.DB, c, "update", auditTargetEmbyConfig, strconv.Itoa(int(existing.ID)), before, embyConfigAudit(existing))
 	log.Printf("⚙️ Emby 配置已保存: %s:%d", req.Host, req.Port)
 	c.JSON(http.StatusOK, gin.H{"data": existing, "message": "配置更新成功"})
 }
@@ -97,6 +97,11 @@ func (h *EmbyCacheHandler) GetEmbyCacheStatus(c *gin.Context) {
 	})
 }
 
+// embyCacheAudit Emby 缓存条目的审计快照
+func embyCacheAudit(cache model.MediaCache) gin.H {
+	return gin.H{"emby_item_id": cache.EmbyItemID, "name": cache.Name, "type": cache.Type}
+}
+
 // UpdateEmbyCache PUT /api/emby-cache/:id - 编辑缓存条目
 func (h *EmbyCacheHandler) UpdateEmbyCache(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -125,11 +130,13 @@ func (h *EmbyCacheHandler) UpdateEmbyCache(c *gin.Context) {
 		return
 	}
 
+	before := embyCacheAudit(cache)
 	cache.Name = req.Name
 	if err := h.DB.Save(&cache).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新失败"})
 		return
 	}
+	recordAudit(h.DB, c, "update", auditTargetEmbyCache, strconv.Itoa(int(cache.ID)), before, embyCacheAudit(cache))
 
 	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": cache})
 }
@@ -161,6 +168,7 @@ func (h *EmbyCacheHandler) DeleteEmbyCache(c *gin.Context) {
 	}
 
 	h.DB.Delete(&cache)
+	recordAudit(h.DB, c, "delete", auditTargetEmbyCache, strconv.Itoa(int(cache.ID)), embyCacheAudit(cache), nil)
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
 }
 
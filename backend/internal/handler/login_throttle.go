@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLoginMaxFailuresPerUser = 5
+	defaultLoginMaxFailuresPerIP   = 20
+	defaultLoginFailureWindow      = 15 * time.Minute
+	defaultLoginLockout            = 15 * time.Minute
+	// loginDelayFreeFailures 不触发延迟的失败次数
+	loginDelayFreeFailures = 2
+	// loginMaxDelay 递增延迟上限
+	loginMaxDelay = 30 * time.Second
+	// loginFailureRetention 登录失败记录保留时长
+	loginFailureRetention = 30 * 24 * time.Hour
+)
+
+// loginLimits 登录防爆破配置
+type loginLimits struct {
+	maxPerUser int
+	maxPerIP   int
+	window     time.Duration
+	lockout    time.Duration
+}
+
+// systemConfigInt 读取非负整数系统配置，无效时返回默认值
+func systemConfigInt(h *AuthHandler, key string, def int) int {
+	n, err := strconv.Atoi(getSystemConfigValue(h.DB, key))
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
+
+// loadLoginLimits 读取登录防爆破配置
+func (h *AuthHandler) loadLoginLimits() loginLimits {
+	limits := loginLimits{
+		maxPerUser: systemConfigInt(h, "login_max_failures_per_user", defaultLoginMaxFailuresPerUser),
+		maxPerIP:   systemConfigInt(h, "login_max_failures_per_ip", defaultLoginMaxFailuresPerIP),
+		window:     time.Duration(systemConfigInt(h, "login_failure_window_minutes", 15)) * time.Minute,
+		lockout:    time.Duration(systemConfigInt(h, "login_lockout_minutes", 15)) * time.Minute,
+	}
+	if limits.window == 0 {
+		limits.window = defaultLoginFailureWindow
+	}
+	if limits.lockout == 0 {
+		limits.lockout = defaultLoginLockout
+	}
+	return limits
+}
+
+// loginDelay 连续失败 failures 次后到允许下次尝试的等待时间：前几次不延迟，之后每次翻倍
+func loginDelay(failures int) time.Duration {
+	if failures <= loginDelayFreeFailures {
+		return 0
+	}
+	delay := time.Second << (failures - loginDelayFreeFailures - 1)
+	if delay <= 0 || delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+// failureWait 计算某个用户名或 IP 还需等待多长时间才能再次尝试登录
+// 以最近一次失败为基准：统计窗口内失败次数达到上限时锁定，否则按失败次数递增延迟
+func (h *AuthHandler) failureWait(column, value string, max int, limits loginLimits, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	var last model.LoginFailure
+	if err := h.DB.Where(column+" = ? AND cleared = ?", value, false).Order("created_at DESC").
+		First(&last).Error; err != nil {
+		return 0
+	}
+	var count int64
+	h.DB.Model(&model.LoginFailure{}).
+		Where(column+" = ? AND cleared = ? AND created_at > ?", value, false, last.CreatedAt.Add(-limits.window)).
+		Count(&count)
+
+	if max > 0 && int(count) >= max {
+		return last.CreatedAt.Add(limits.lockout).Sub(now)
+	}
+	return last.CreatedAt.Add(loginDelay(int(count))).Sub(now)
+}
+
+// loginRetryAfter 返回本次登录需要等待的时间，0 表示允许尝试
+func (h *AuthHandler) loginRetryAfter(username, ip string, now time.Time) time.Duration {
+	limits := h.loadLoginLimits()
+	wait := h.failureWait("username", username, limits.maxPerUser, limits, now)
+	if ipWait := h.failureWait("ip", ip, limits.maxPerIP, limits, now); ipWait > wait {
+		wait = ipWait
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// rejectThrottledLogin 登录尝试过于频繁时返回 429
+func rejectThrottledLogin(c *gin.Context, username string, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	message := fmt.Sprintf("登录尝试过于频繁，请 %d 秒后再试", seconds)
+	if seconds > 60 {
+		message = fmt.Sprintf("登录失败次数过多，账户已临时锁定，请 %d 分钟后再试", int(math.Ceil(wait.Minutes())))
+	}
+	log.Printf("🔐 用户 %s 登录被限制（IP: %s），需等待 %d 秒", username, c.ClientIP(), seconds)
+	c.JSON(http.StatusTooManyRequests, gin.H{"code": 429, "message": message, "retry_after": seconds})
+}
+
+// recordLoginFailure 记录一次登录失败，并顺带清理过期记录
+func (h *AuthHandler) recordLoginFailure(c *gin.Context, username, reason string) {
+	if len(username) > 100 {
+		username = username[:100]
+	}
+	entry := model.LoginFailure{
+		Username:  username,
+		IP:        c.ClientIP(),
+		Reason:    reason,
+		UserAgent: clientUserAgent(c),
+	}
+	if err := h.DB.Create(&entry).Error; err != nil {
+		log.Printf("⚠️  记录登录失败日志失败: %v", err)
+	}
+	h.DB.Where("created_at < ?", time.Now().Add(-loginFailureRetention)).Delete(&model.LoginFailure{})
+}
+
+// clearLoginFailures 登录成功后该用户名的失败记录不再计入锁定判断
+func (h *AuthHandler) clearLoginFailures(username string) {
+	h.DB.Model(&model.LoginFailure{}).Where("username = ? AND cleared = ?", username, false).Update("cleared", true)
+}
+
+// ListLoginFailures 分页查询登录失败记录
+// 查询参数: page, pageSize, username, ip, reason
+// GET /api/login-failures
+func (h *AuthHandler) ListLoginFailures(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := h.DB.Model(&model.LoginFailure{})
+	for _, column := range []string{"username", "ip", "reason"} {
+		if value := c.Query(column); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询登录失败记录失败"})
+		return
+	}
+	var entries []model.LoginFailure
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询登录失败记录失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": entries, "total": total, "page": page, "page_size": pageSize,
+	})
+}
+
+// ClearLoginFailuresRequest 解除登录锁定请求，用户名和 IP 至少填写一个
+type ClearLoginFailuresRequest struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
+
+// ClearLoginFailures 解除用户名或 IP 的登录锁定，失败记录保留
+// POST /api/login-failures/clear
+func (h *AuthHandler) ClearLoginFailures(c *gin.Context) {
+	var req ClearLoginFailuresRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Username == "" && req.IP == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请填写用户名或 IP"})
+		return
+	}
+	query := h.DB.Model(&model.LoginFailure{}).Where("cleared = ?", false)
+	if req.Username != "" {
+		query = query.Where("username = ?", req.Username)
+	}
+	if req.IP != "" {
+		query = query.Where("ip = ?", req.IP)
+	}
+	result := query.Update("cleared", true)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "解除锁定失败"})
+		return
+	}
+	filters := gin.H{"username": req.Username, "ip": req.IP}
+	var targets []string
+	if req.Username != "" {
+		targets = append(targets, "user:"+req.Username)
+	}
+	if req.IP != "" {
+		targets = append(targets, "ip:"+req.IP)
+	}
+	recordAudit(h.DB, c, "clear_login_failures", auditTargetLoginFailure, strings.Join(targets, ","),
+		filters, gin.H{"username": req.Username, "ip": req.IP, "cleared": result.RowsAffected})
+	log.Printf("🔓 %s 解除了登录锁定（用户名: %s, IP: %s）", changedBy(c), req.Username, req.IP)
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": gin.H{"cleared": result.RowsAffected}})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+)
+
+func TestLoginDelay(t *testing.T) {
+	cases := map[int]time.Duration{0: 0, 2: 0, 3: time.Second, 4: 2 * time.Second, 6: 8 * time.Second, 20: loginMaxDelay, 100: loginMaxDelay}
+	for failures, want := range cases {
+		if got := loginDelay(failures); got != want {
+			t.Errorf("loginDelay(%d) = %v, 期望 %v", failures, got, want)
+		}
+	}
+}
+
+func TestLoginThrottleAndLockout(t *testing.T) {
+	r, h := setupAuthTest(t)
+	r.GET("/api/login-failures", h.ListLoginFailures)
+	r.POST("/api/login-failures/clear", h.ClearLoginFailures)
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "login_max_failures_per_user").Update("value", "3")
+
+	login := func(password string) int {
+		return doRuleSetRequest(r, http.MethodPost, "/api/auth/login", LoginRequest{Username: "admin", Password: password}).Code
+	}
+	// 把所有失败记录的时间往前移，模拟时间流逝
+	rewind := func(d time.Duration) {
+		var entries []model.LoginFailure
+		h.DB.Find(&entries)
+		for _, e := range entries {
+			h.DB.Model(&e).UpdateColumn("created_at", e.CreatedAt.Add(-d))
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := login("wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("第 %d 次失败期望 401，实际 %d", i+1, code)
+		}
+	}
+	if code := login("wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("第 3 次失败期望 401，实际 %d", code)
+	}
+
+	// 达到上限后锁定，正确密码也被拒绝
+	w := doRuleSetRequest(r, http.MethodPost, "/api/auth/login", LoginRequest{Username: "admin", Password: "admin"})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("锁定期间期望 429 和 Retry-After，实际 %d", w.Code)
+	}
+	var count int64
+	h.DB.Model(&model.LoginFailure{}).Count(&count)
+	if count != 3 {
+		t.Errorf("被拒绝的请求不应计入失败记录，实际记录 %d 条", count)
+	}
+
+	// 管理员解除锁定后可以登录，成功后失败记录保留但不再计入
+	if w := doRuleSetRequest(r, http.MethodPost, "/api/login-failures/clear", ClearLoginFailuresRequest{Username: "admin"}); w.Code != http.StatusOK {
+		t.Fatalf("解除锁定失败: %d", w.Code)
+	}
+	var audit model.AuditLog
+	if err := h.DB.Where("action = ?", "clear_login_failures").First(&audit).Error; err != nil {
+		t.Fatalf("解除锁定应记录审计日志: %v", err)
+	}
+	if audit.TargetType != auditTargetLoginFailure || audit.TargetID != "user:admin" ||
+		!strings.Contains(audit.BeforeValue, `"username":"admin"`) || !strings.Contains(audit.AfterValue, `"cleared":3`) {
+		t.Errorf("审计日志内容不正确: %+v", audit)
+	}
+	if code := login("admin"); code != http.StatusOK {
+		t.Fatalf("解除锁定后期望 200，实际 %d", code)
+	}
+	w = doRuleSetRequest(r, http.MethodGet, "/api/login-failures?username=admin&reason=bad_password", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"total":3`) {
+		t.Errorf("失败记录应保留供查看: %s", w.Body.String())
+	}
+
+	// 锁定到期后自动解除
+	login("wrong")
+	login("x")
+	login("y")
+	if code := login("admin"); code != http.StatusTooManyRequests {
+		t.Fatalf("再次达到上限期望 429，实际 %d", code)
+	}
+	rewind(16 * time.Minute)
+	if code := login("admin"); code != http.StatusOK {
+		t.Errorf("锁定到期后期望 200，实际 %d", code)
+	}
+}
@@ -0,0 +1,397 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/oidc"
+	"embyforge/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// oidcStateTTL 授权请求的有效期（用户需在此时间内完成身份提供方登录）
+	oidcStateTTL = 10 * time.Minute
+	// oidcDiscoveryTTL 发现文档和 JWKS 的缓存时间
+	oidcDiscoveryTTL = time.Hour
+	// oidcCallbackPath 默认回调路径
+	oidcCallbackPath = "/api/auth/oidc/callback"
+	// oidcLoginPage 登录完成后跳转的前端页面，令牌通过 URL 片段传递，不会发送到服务器日志
+	oidcLoginPage = "/login"
+)
+
+// oidcSettings OIDC 单点登录配置
+type oidcSettings struct {
+	Enabled       bool
+	Config        oidc.Config
+	UsernameClaim string
+	RoleClaim     string
+	RoleMapping   map[string]string
+	DefaultRole   string
+	AutoProvision bool
+	// LinkVerifiedEmail 允许按已验证的 email 绑定用户名与之相同、未启用两步验证的本地用户
+	LinkVerifiedEmail bool
+}
+
+// oidcPending 等待回调的授权请求
+type oidcPending struct {
+	nonce       string
+	verifier    string
+	redirectURL string
+	createdAt   time.Time
+}
+
+// oidcState OIDC 授权请求和客户端缓存
+type oidcState struct {
+	mu        sync.Mutex
+	pending   map[string]oidcPending // state -> 授权请求
+	client    *oidc.Client
+	clientKey string
+	clientAt  time.Time
+}
+
+// parseRoleMapping 解析 "声明值=角色" 映射，忽略无效的条目
+func parseRoleMapping(raw string) map[string]string {
+	mapping := make(map[string]string)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' }) {
+		value, role, ok := strings.Cut(entry, "=")
+		value, role = strings.TrimSpace(value), strings.TrimSpace(role)
+		if !ok || value == "" || !model.IsValidRole(role) {
+			if strings.TrimSpace(entry) != "" {
+				log.Printf("⚠️  [OIDC] 忽略无效的角色映射: %s", entry)
+			}
+			continue
+		}
+		mapping[value] = role
+	}
+	return mapping
+}
+
+// loadOIDCSettings 读取 OIDC 配置，回调地址未配置时按请求地址生成
+func (h *AuthHandler) loadOIDCSettings(c *gin.Context) oidcSettings {
+	enabled, _ := strconv.ParseBool(getSystemConfigValue(h.DB, "oidc_enabled"))
+	autoProvision, _ := strconv.ParseBool(getSystemConfigValue(h.DB, "oidc_auto_provision"))
+	linkVerifiedEmail, _ := strconv.ParseBool(getSystemConfigValue(h.DB, "oidc_link_verified_email"))
+	redirectURL := strings.TrimSpace(getSystemConfigValue(h.DB, "oidc_redirect_url"))
+	if redirectURL == "" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		redirectURL = scheme + "://" + c.Request.Host + oidcCallbackPath
+	}
+	scopes := strings.Fields(getSystemConfigValue(h.DB, "oidc_scopes"))
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	defaultRole := strings.TrimSpace(getSystemConfigValue(h.DB, "oidc_default_role"))
+	if !model.IsValidRole(defaultRole) {
+		defaultRole = ""
+	}
+
+	return oidcSettings{
+		Enabled: enabled,
+		Config: oidc.Config{
+			IssuerURL:    strings.TrimSpace(getSystemConfigValue(h.DB, "oidc_issuer_url")),
+			ClientID:     strings.TrimSpace(getSystemConfigValue(h.DB, "oidc_client_id")),
+			ClientSecret: getSystemConfigValue(h.DB, "oidc_client_secret"),
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+		},
+		UsernameClaim:     strings.TrimSpace(getSystemConfigValue(h.DB, "oidc_username_claim")),
+		RoleClaim:         strings.TrimSpace(getSystemConfigValue(h.DB, "oidc_role_claim")),
+		RoleMapping:       parseRoleMapping(getSystemConfigValue(h.DB, "oidc_role_mapping")),
+		DefaultRole:       defaultRole,
+		AutoProvision:     autoProvision,
+		LinkVerifiedEmail: linkVerifiedEmail,
+	}
+}
+
+// oidcClient 返回缓存的 OIDC 客户端，配置变化或缓存过期时重新发现
+func (h *AuthHandler) oidcClient(ctx context.Context, settings oidcSettings) (*oidc.Client, error) {
+	key := settings.Config.IssuerURL + "|" + settings.Config.ClientID + "|" + settings.Config.ClientSecret + "|" +
+		settings.Config.RedirectURL + "|" + strings.Join(settings.Config.Scopes, " ")
+
+	h.oidc.mu.Lock()
+	defer h.oidc.mu.Unlock()
+	if h.oidc.client != nil && h.oidc.clientKey == key && time.Since(h.oidc.clientAt) < oidcDiscoveryTTL {
+		return h.oidc.client, nil
+	}
+	client, err := oidc.Discover(ctx, settings.Config)
+	if err != nil {
+		return nil, err
+	}
+	h.oidc.client, h.oidc.clientKey, h.oidc.clientAt = client, key, time.Now()
+	return client, nil
+}
+
+// savePending 保存授权请求，顺带清理过期的请求
+func (h *AuthHandler) savePending(state string, pending oidcPending) {
+	h.oidc.mu.Lock()
+	defer h.oidc.mu.Unlock()
+	for s, p := range h.oidc.pending {
+		if time.Since(p.createdAt) > oidcStateTTL {
+			delete(h.oidc.pending, s)
+		}
+	}
+	h.oidc.pending[state] = pending
+}
+
+// takePending 取出并删除授权请求，每个 state 只能使用一次
+func (h *AuthHandler) takePending(state string) (oidcPending, bool) {
+	h.oidc.mu.Lock()
+	defer h.oidc.mu.Unlock()
+	pending, ok := h.oidc.pending[state]
+	delete(h.oidc.pending, state)
+	if !ok || time.Since(pending.createdAt) > oidcStateTTL {
+		return oidcPending{}, false
+	}
+	return pending, true
+}
+
+// GetOIDCStatus 返回是否启用单点登录，供登录页显示入口
+// GET /api/auth/oidc/status
+func (h *AuthHandler) GetOIDCStatus(c *gin.Context) {
+	settings := h.loadOIDCSettings(c)
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"enabled": settings.Enabled && settings.Config.IssuerURL != "" && settings.Config.ClientID != "",
+	}})
+}
+
+// OIDCLogin 发起授权码流程（PKCE），重定向到身份提供方
+// GET /api/auth/oidc/login
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	settings := h.loadOIDCSettings(c)
+	if !settings.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "未启用单点登录"})
+		return
+	}
+	client, err := h.oidcClient(c.Request.Context(), settings)
+	if err != nil {
+		log.Printf("❌ [OIDC] %v", err)
+		redirectOIDCError(c, "单点登录配置错误，请联系管理员")
+		return
+	}
+
+	state, err1 := oidc.RandomString(24)
+	nonce, err2 := oidc.RandomString(24)
+	verifier, challenge, err3 := oidc.NewPKCE()
+	if err := errors.Join(err1, err2, err3); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成授权请求失败"})
+		return
+	}
+	h.savePending(state, oidcPending{
+		nonce: nonce, verifier: verifier, redirectURL: settings.Config.RedirectURL, createdAt: time.Now(),
+	})
+	c.Redirect(http.StatusFound, client.AuthCodeURL(state, nonce, challenge))
+}
+
+// OIDCCallback 处理身份提供方回调：换取并校验 ID Token，映射角色，签发登录会话
+// GET /api/auth/oidc/callback
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if errCode := c.Query("error"); errCode != "" {
+		log.Printf("🔐 [OIDC] 身份提供方返回错误: %s %s", errCode, c.Query("error_description"))
+		redirectOIDCError(c, "单点登录被取消或失败")
+		return
+	}
+	pending, ok := h.takePending(c.Query("state"))
+	if !ok {
+		redirectOIDCError(c, "登录请求已过期，请重新登录")
+		return
+	}
+
+	settings := h.loadOIDCSettings(c)
+	if !settings.Enabled {
+		redirectOIDCError(c, "未启用单点登录")
+		return
+	}
+	// 回调地址必须与发起授权时一致，否则令牌端点会拒绝
+	settings.Config.RedirectURL = pending.redirectURL
+	client, err := h.oidcClient(c.Request.Context(), settings)
+	if err != nil {
+		log.Printf("❌ [OIDC] %v", err)
+		redirectOIDCError(c, "单点登录配置错误，请联系管理员")
+		return
+	}
+
+	rawIDToken, err := client.Exchange(c.Request.Context(), c.Query("code"), pending.verifier)
+	if err != nil {
+		log.Printf("❌ [OIDC] 换取令牌失败: %v", err)
+		redirectOIDCError(c, "单点登录失败，请重试")
+		return
+	}
+	claims, err := client.VerifyIDToken(c.Request.Context(), rawIDToken, pending.nonce)
+	if err != nil {
+		log.Printf("❌ [OIDC] %v", err)
+		redirectOIDCError(c, "单点登录失败，请重试")
+		return
+	}
+
+	user, err := h.oidcUser(settings, client.Provider.Issuer, claims)
+	if err != nil {
+		log.Printf("🔐 [OIDC] 单点登录被拒绝: %v", err)
+		redirectOIDCError(c, err.Error())
+		return
+	}
+	resp, err := h.issueSession(c, user)
+	if err != nil {
+		redirectOIDCError(c, "令牌生成失败")
+		return
+	}
+
+	log.Printf("🔐 用户 %s 通过单点登录成功（角色: %s）", user.Username, user.Role)
+	fragment := url.Values{}
+	fragment.Set("token", resp.Token)
+	fragment.Set("refresh_token", resp.RefreshToken)
+	fragment.Set("expires_in", strconv.FormatInt(resp.ExpiresIn, 10))
+	c.Redirect(http.StatusFound, oidcLoginPage+"#"+fragment.Encode())
+}
+
+// redirectOIDCError 跳转回登录页并显示错误信息
+func redirectOIDCError(c *gin.Context, message string) {
+	fragment := url.Values{}
+	fragment.Set("oidc_error", message)
+	c.Redirect(http.StatusFound, oidcLoginPage+"#"+fragment.Encode())
+}
+
+// oidcRole 根据声明映射角色，多个值匹配时取权限最高的角色，未匹配时使用默认角色
+func oidcRole(settings oidcSettings, values []string) string {
+	role := ""
+	for _, v := range values {
+		if mapped, ok := settings.RoleMapping[v]; ok && (role == "" || model.RoleAtLeast(mapped, role)) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = settings.DefaultRole
+	}
+	return role
+}
+
+// oidcUsername 读取用户名声明，依次回退到 email 和 sub
+func oidcUsername(settings oidcSettings, claims map[string]interface{}) string {
+	for _, name := range []string{settings.UsernameClaim, "preferred_username", "email", "sub"} {
+		if name == "" {
+			continue
+		}
+		if v, _ := claims[name].(string); strings.TrimSpace(v) != "" {
+			v = strings.TrimSpace(v)
+			if len(v) > 50 {
+				v = v[:50]
+			}
+			return v
+		}
+	}
+	return ""
+}
+
+// oidcVerifiedEmail 返回身份提供方已验证的 email，未验证时返回空字符串
+func oidcVerifiedEmail(claims map[string]interface{}) string {
+	email, _ := claims["email"].(string)
+	verified := false
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		verified = v
+	case string:
+		verified, _ = strconv.ParseBool(v)
+	}
+	if !verified {
+		return ""
+	}
+	return strings.TrimSpace(email)
+}
+
+// oidcUser 查找或创建单点登录对应的用户，并按映射同步角色
+// 查找顺序：已绑定的 subject → 开启 oidc_link_verified_email 时按已验证的 email 绑定同名本地用户 → 自动创建。
+// 用户名声明不唯一也不稳定，不按用户名绑定已有账户；其他本地用户由管理员在用户管理中绑定 subject
+func (h *AuthHandler) oidcUser(settings oidcSettings, issuer string, claims map[string]interface{}) (*model.User, error) {
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errors.New("ID Token 缺少 sub 声明")
+	}
+	subject := strings.TrimRight(issuer, "/") + "|" + sub
+	username := oidcUsername(settings, claims)
+	role := oidcRole(settings, oidc.ClaimStrings(claims, settings.RoleClaim))
+	if role == "" {
+		return nil, fmt.Errorf("账户 %s 没有访问 EmbyForge 的权限", username)
+	}
+
+	var user model.User
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("oidc_subject = ?", subject).First(&user).Error
+		if email := oidcVerifiedEmail(claims); errors.Is(err, gorm.ErrRecordNotFound) && settings.LinkVerifiedEmail && email != "" {
+			// 启用两步验证的账户不自动绑定，避免单点登录绕过两步验证
+			err = tx.Where("username = ? AND oidc_subject = '' AND totp_enabled = ?", email, false).First(&user).Error
+			if err == nil {
+				log.Printf("🔗 [OIDC] 本地用户 %s 已按已验证的 email 绑定单点登录身份", user.Username)
+				if err := tx.Model(&user).Update("oidc_subject", subject).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if !settings.AutoProvision {
+				return fmt.Errorf("单点登录身份 %s 未绑定本地用户，请联系管理员绑定", subject)
+			}
+			return h.provisionOIDCUser(tx, &user, username, subject, role)
+		}
+		if err != nil {
+			return err
+		}
+
+		if user.Role == role {
+			return nil
+		}
+		// 映射结果会降级最后一个管理员时拒绝登录，不保留映射未授予的管理员权限
+		if user.Role == model.RoleAdmin {
+			if err := ensureOtherAdmin(tx, user.ID); err != nil {
+				return fmt.Errorf("用户 %s 是最后一个管理员，单点登录映射的角色为 %s，请使用本地账户登录", user.Username, role)
+			}
+		}
+		log.Printf("🔐 [OIDC] 用户 %s 角色按映射同步: %s -> %s", user.Username, user.Role, role)
+		user.Role = role
+		return tx.Model(&user).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// provisionOIDCUser 自动创建单点登录用户，密码为随机值，只能通过单点登录或管理员重置密码后登录
+func (h *AuthHandler) provisionOIDCUser(tx *gorm.DB, user *model.User, username, subject, role string) error {
+	if username == "" {
+		return errors.New("ID Token 缺少用户名声明")
+	}
+	var count int64
+	tx.Model(&model.User{}).Where("username = ?", username).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("用户名 %s 已被其他账户使用，请联系管理员", username)
+	}
+	random, err := util.RandomToken(32)
+	if err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(random), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	*user = model.User{Username: username, Password: string(hashed), Role: role, OIDCSubject: subject}
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+	log.Printf("👤 [OIDC] 自动创建用户 %s（%s）", username, role)
+	return nil
+}
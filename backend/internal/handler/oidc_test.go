@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeOIDCProvider 本地 OIDC 身份提供方替身：发现文档、JWKS 和校验 PKCE 的令牌端点
+type fakeOIDCProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string                 // 最近一次授权请求的 code_challenge
+	nonce     string                 // 最近一次授权请求的 nonce
+	claims    map[string]interface{} // 下一次签发的 ID Token 附加声明
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	p := &fakeOIDCProvider{key: key}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 p.server.URL,
+				"authorization_endpoint": p.server.URL + "/authorize",
+				"token_endpoint":         p.server.URL + "/token",
+				"jwks_uri":               p.server.URL + "/jwks",
+			})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kid": "k1", "kty": "RSA", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		case "/token":
+			r.ParseForm()
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			claims := jwt.MapClaims{
+				"iss": p.server.URL, "aud": "embyforge", "nonce": p.nonce,
+				"iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix(),
+			}
+			for k, v := range p.claims {
+				claims[k] = v
+			}
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = "k1"
+			signed, _ := token.SignedString(key)
+			json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "access_token": "at", "token_type": "Bearer"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func setupOIDCTest(t *testing.T) (*gin.Engine, *AuthHandler, *fakeOIDCProvider) {
+	t.Helper()
+	r, h := setupAuthTest(t)
+	r.GET("/api/auth/oidc/login", h.OIDCLogin)
+	r.GET("/api/auth/oidc/callback", h.OIDCCallback)
+	p := newFakeOIDCProvider(t)
+	for key, value := range map[string]string{
+		"oidc_enabled":        "true",
+		"oidc_issuer_url":     p.server.URL,
+		"oidc_client_id":      "embyforge",
+		"oidc_redirect_url":   "http://embyforge.test/api/auth/oidc/callback",
+		"oidc_role_mapping":   "media-admins=admin\nmedia=operator, bogus=root",
+		"oidc_auto_provision": "true",
+	} {
+		h.DB.Model(&model.SystemConfig{}).Where("key = ?", key).Update("value", value)
+	}
+	return r, h, p
+}
+
+// oidcSignIn 走完授权码流程，返回回调重定向后 URL 片段中的参数
+func oidcSignIn(t *testing.T, r *gin.Engine, p *fakeOIDCProvider, claims map[string]interface{}) url.Values {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("发起登录期望 302，实际 %d %s", w.Code, w.Body.String())
+	}
+	authURL, _ := url.Parse(w.Header().Get("Location"))
+	q := authURL.Query()
+	if !strings.HasPrefix(authURL.String(), p.server.URL+"/authorize") || q.Get("code_challenge_method") != "S256" ||
+		q.Get("redirect_uri") != "http://embyforge.test/api/auth/oidc/callback" {
+		t.Fatalf("授权地址不正确: %s", authURL)
+	}
+	p.challenge, p.nonce, p.claims = q.Get("code_challenge"), q.Get("nonce"), claims
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?code=good-code&state="+url.QueryEscape(q.Get("state")), nil))
+	location := w.Header().Get("Location")
+	if w.Code != http.StatusFound || !strings.HasPrefix(location, "/login#") {
+		t.Fatalf("回调期望重定向到登录页，实际 %d %s", w.Code, location)
+	}
+	fragment, _ := url.ParseQuery(strings.TrimPrefix(location, "/login#"))
+
+	// 同一个 state 不能重复使用
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?code=good-code&state="+url.QueryEscape(q.Get("state")), nil))
+	if !strings.Contains(w.Header().Get("Location"), "oidc_error") {
+		t.Errorf("重复使用 state 应失败: %s", w.Header().Get("Location"))
+	}
+	return fragment
+}
+
+func TestOIDCLoginProvisionsAndMapsRoles(t *testing.T) {
+	r, h, p := setupOIDCTest(t)
+
+	got := oidcSignIn(t, r, p, map[string]interface{}{"sub": "u-1", "preferred_username": "alice", "groups": []string{"family", "media"}})
+	if got.Get("token") == "" || got.Get("refresh_token") == "" {
+		t.Fatalf("单点登录应签发令牌: %v", got)
+	}
+	var alice model.User
+	if err := h.DB.Where("username = ?", "alice").First(&alice).Error; err != nil {
+		t.Fatalf("应自动创建用户: %v", err)
+	}
+	if alice.Role != model.RoleOperator || alice.OIDCSubject != p.server.URL+"|u-1" {
+		t.Errorf("用户信息不正确: role=%s subject=%s", alice.Role, alice.OIDCSubject)
+	}
+
+	// 组变化后角色随之同步，按 subject 识别同一用户
+	oidcSignIn(t, r, p, map[string]interface{}{"sub": "u-1", "preferred_username": "alice", "groups": []string{"media", "media-admins"}})
+	h.DB.First(&alice, alice.ID)
+	if alice.Role != model.RoleAdmin {
+		t.Errorf("角色应同步为 admin，实际 %s", alice.Role)
+	}
+
+	// 没有匹配的组且未配置默认角色时拒绝
+	got = oidcSignIn(t, r, p, map[string]interface{}{"sub": "u-2", "preferred_username": "bob", "groups": []string{"family"}})
+	if got.Get("oidc_error") == "" || got.Get("token") != "" {
+		t.Errorf("未映射角色应拒绝登录: %v", got)
+	}
+
+	// 关闭自动创建：未知用户被拒绝，管理员绑定 subject 后可以登录
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "oidc_auto_provision").Update("value", "false")
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "oidc_default_role").Update("value", "viewer")
+	got = oidcSignIn(t, r, p, map[string]interface{}{"sub": "u-3", "preferred_username": "carol"})
+	if got.Get("oidc_error") == "" {
+		t.Errorf("未开启自动创建时未知用户应被拒绝: %v", got)
+	}
+	h.DB.Create(&model.User{Username: "dave", Password: "x", Role: model.RoleOperator, OIDCSubject: p.server.URL + "|u-4"})
+	got = oidcSignIn(t, r, p, map[string]interface{}{"sub": "u-4", "preferred_username": "someone"})
+	var dave model.User
+	h.DB.Where("username = ?", "dave").First(&dave)
+	if got.Get("token") == "" || dave.Role != model.RoleViewer {
+		t.Errorf("管理员绑定的用户应能登录并使用默认角色: %v role=%s", got, dave.Role)
+	}
+}
+
+func TestOIDCRejectsUsernameTakeover(t *testing.T) {
+	r, h, p := setupOIDCTest(t)
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "oidc_default_role").Update("value", "viewer")
+
+	// 用户名声明与本地管理员相同：不绑定、不自动创建，拒绝登录
+	for _, provision := range []string{"true", "false"} {
+		h.DB.Model(&model.SystemConfig{}).Where("key = ?", "oidc_auto_provision").Update("value", provision)
+		got := oidcSignIn(t, r, p, map[string]interface{}{"sub": "evil", "preferred_username": "admin", "email": "admin"})
+		if got.Get("oidc_error") == "" || got.Get("token") != "" {
+			t.Errorf("同名声明不应接管本地管理员（auto_provision=%s）: %v", provision, got)
+		}
+	}
+	var admin model.User
+	h.DB.Where("username = ?", "admin").First(&admin)
+	if admin.OIDCSubject != "" || admin.Role != model.RoleAdmin {
+		t.Fatalf("本地管理员不应被绑定: subject=%s role=%s", admin.OIDCSubject, admin.Role)
+	}
+
+	// 开启按已验证 email 绑定后，未验证的 email 和启用两步验证的用户仍不绑定
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "oidc_auto_provision").Update("value", "false")
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "oidc_link_verified_email").Update("value", "true")
+	h.DB.Create(&model.User{Username: "erin@example.com", Password: "x", Role: model.RoleOperator, TOTPEnabled: true})
+	h.DB.Create(&model.User{Username: "frank@example.com", Password: "x", Role: model.RoleOperator})
+	for _, claims := range []map[string]interface{}{
+		{"sub": "u-5", "email": "frank@example.com", "email_verified": false},
+		{"sub": "u-6", "email": "erin@example.com", "email_verified": true},
+	} {
+		if got := oidcSignIn(t, r, p, claims); got.Get("token") != "" {
+			t.Errorf("不应绑定: %v", claims)
+		}
+	}
+	got := oidcSignIn(t, r, p, map[string]interface{}{"sub": "u-7", "email": "frank@example.com", "email_verified": true})
+	var frank model.User
+	h.DB.Where("username = ?", "frank@example.com").First(&frank)
+	if got.Get("token") == "" || frank.OIDCSubject != p.server.URL+"|u-7" {
+		t.Errorf("已验证的 email 应绑定同名用户: %v subject=%s", got, frank.OIDCSubject)
+	}
+
+	// 映射会降级最后一个管理员时拒绝登录，而不是保留管理员角色
+	h.DB.Model(&admin).Update("oidc_subject", p.server.URL+"|root")
+	got = oidcSignIn(t, r, p, map[string]interface{}{"sub": "root", "preferred_username": "admin"})
+	h.DB.First(&admin, admin.ID)
+	if got.Get("token") != "" || admin.Role != model.RoleAdmin {
+		t.Errorf("映射降级最后一个管理员应拒绝登录: %v role=%s", got, admin.Role)
+	}
+}
+
+func TestOIDCRejectsTamperedPKCE(t *testing.T) {
+	r, _, p := setupOIDCTest(t)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+	authURL, _ := url.Parse(w.Header().Get("Location"))
+	p.challenge = "not-the-real-challenge"
+	p.nonce = authURL.Query().Get("nonce")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?code=good-code&state="+url.QueryEscape(authURL.Query().Get("state")), nil))
+	if !strings.Contains(w.Header().Get("Location"), "oidc_error") {
+		t.Errorf("PKCE 校验失败时应拒绝登录: %s", w.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"embyforge/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupPasswordChangeTest 注册登录、个人信息和修改密码路由，受保护路由启用强制修改初始密码
+func setupPasswordChangeTest(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, h := setupAuthTest(t)
+	profile := &ProfileHandler{DB: h.DB}
+	protected := r.Group("/api", middleware.JWTAuth(h.JWTSecret, h.DB), middleware.ActiveSession(h.DB), middleware.UserRole(h.DB),
+		middleware.PasswordChanged(map[string]bool{"GET /api/profile": true, "PUT /api/profile/password": true}))
+	protected.GET("/profile", profile.GetProfile)
+	protected.PUT("/profile/password", profile.ChangePassword)
+	protected.GET("/profile/sessions", h.ListSessions)
+	return r
+}
+
+func TestMustChangePassword(t *testing.T) {
+	r := setupPasswordChangeTest(t)
+	login := sessionLogin(t, r)
+	if !login.MustChangePassword {
+		t.Fatal("默认管理员登录后应提示修改初始密码")
+	}
+
+	w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", login.Token)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("修改初始密码前访问其他接口期望 403，实际 %d", w.Code)
+	}
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["password_change_required"] != true {
+		t.Errorf("响应应包含 password_change_required: %s", w.Body.String())
+	}
+	if w := doSessionRequest(r, http.MethodGet, "/api/profile", login.Token); w.Code != http.StatusOK {
+		t.Errorf("修改初始密码前应能获取个人信息，实际 %d", w.Code)
+	}
+
+	same := ChangePasswordRequest{OldPassword: "admin", NewPassword: "admin"}
+	if w := doJSONWithToken(r, http.MethodPut, "/api/profile/password", login.Token, same); w.Code != http.StatusBadRequest {
+		t.Errorf("新密码与初始密码相同期望 400，实际 %d", w.Code)
+	}
+	changed := ChangePasswordRequest{OldPassword: "admin", NewPassword: "n3w-password"}
+	if w := doJSONWithToken(r, http.MethodPut, "/api/profile/password", login.Token, changed); w.Code != http.StatusOK {
+		t.Fatalf("修改密码失败: %d %s", w.Code, w.Body.String())
+	}
+
+	w = doRuleSetRequest(r, http.MethodPost, "/api/auth/login", LoginRequest{Username: "admin", Password: "n3w-password"})
+	var relogin LoginResponse
+	json.Unmarshal(w.Body.Bytes(), &relogin)
+	if w.Code != http.StatusOK || relogin.MustChangePassword {
+		t.Fatalf("修改密码后重新登录不应再要求修改密码: %d %s", w.Code, w.Body.String())
+	}
+	if w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", relogin.Token); w.Code != http.StatusOK {
+		t.Errorf("修改密码后应能访问其他接口，实际 %d", w.Code)
+	}
+}
@@ -1,8 +1,6 @@
 package handler
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,15 +15,6 @@ import (
 	"gorm.io/gorm"
 )
 
-// generateRandomSecret 生成随机 JWT 密钥
-func generateRandomSecret() string {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		log.Fatal("生成随机 JWT 密钥失败:", err)
-	}
-	return hex.EncodeToString(b)
-}
-
 // ProfileHandler 个人设置处理器
 type ProfileHandler struct {
 	DB         *gorm.DB
@@ -49,9 +38,12 @@ func (h *ProfileHandler) GetProfile(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"data": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"avatar":   user.Avatar,
+			"id":                   user.ID,
+			"username":             user.Username,
+			"avatar":               user.Avatar,
+			"role":                 user.Role,
+			"totp_enabled":         user.TOTPEnabled,
+			"must_change_password": user.MustChangePassword,
 		},
 	})
 }
@@ -84,6 +76,11 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if user.MustChangePassword && req.NewPassword == req.OldPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "新密码不能与初始密码相同"})
+		return
+	}
+
 	// 生成新密码哈希
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -91,18 +88,15 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	h.DB.Model(&user).Update("password", string(hashed))
+	h.DB.Model(&user).Updates(map[string]interface{}{"password": string(hashed), "must_change_password": false})
 
-	// 更新数据库中的 JWT secret，使所有旧 token 在下次重启后失效
-	newSecret := generateRandomSecret()
-	h.DB.Where("`key` = ?", "jwt_secret").Delete(&model.SystemConfig{})
-	h.DB.Create(&model.SystemConfig{
-		Key:         "jwt_secret",
-		Value:       newSecret,
-		Description: "JWT 签名密钥（自动生成，修改密码时会更新）",
-	})
+	// 撤销该用户的所有会话（包括当前会话），其他用户不受影响
+	if _, err := revokeUserSessions(h.DB, user.ID, 0); err != nil {
+		log.Printf("⚠️  撤销用户 %s 的会话失败: %v", user.Username, err)
+	}
 
-	log.Printf("🔐 用户 %s 修改了密码，JWT 密钥已更新", user.Username)
+	recordAudit(h.DB, c, "change_password", auditTargetUser, fmt.Sprint(user.ID), nil, nil)
+	log.Printf("🔐 用户 %s 修改了密码，已注销其所有会话", user.Username)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "密码修改成功，请重新登录"})
 }
 
@@ -130,6 +124,8 @@ func (h *ProfileHandler) ChangeUsername(c *gin.Context) {
 	}
 
 	h.DB.Model(&model.User{}).Where("id = ?", userID).Update("username", req.Username)
+	recordAudit(h.DB, c, "change_username", auditTargetUser, fmt.Sprint(userID),
+		gin.H{"username": changedBy(c)}, gin.H{"username": req.Username})
 	log.Printf("👤 用户 ID=%v 修改用户名为 %s", userID, req.Username)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "用户名修改成功"})
 }
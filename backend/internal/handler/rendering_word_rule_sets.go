@@ -0,0 +1,482 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RuleSetRequest 创建/更新规则集请求
+type RuleSetRequest struct {
+	Name      string                      `json:"name"`
+	TmdbID    int                         `json:"tmdb_id"`
+	MediaType string                      `json:"media_type"`
+	Rules     []service.RenderingWordRule `json:"rules"`
+	Enabled   *bool                       `json:"enabled"` // 为空时创建默认启用、更新保持不变
+	Comment   string                      `json:"comment"`
+	SortOrder *int                        `json:"sort_order"` // 为空时创建排在最后、更新保持不变
+
+	OmitHeader bool `json:"omit_header"`
+}
+
+// RuleSetResponse 规则集响应（规则已解析为结构体）
+type RuleSetResponse struct {
+	model.RenderingWordRuleSet
+	Rules []service.RenderingWordRule `json:"rules"`
+}
+
+// RuleSetVersionResponse 规则集版本响应
+type RuleSetVersionResponse struct {
+	model.RenderingWordRuleSetVersion
+	Rules []service.RenderingWordRule `json:"rules"`
+}
+
+// FieldChange 规则集字段变更
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// decodeRules 解析规则 JSON，格式错误时返回空列表
+func decodeRules(data string) []service.RenderingWordRule {
+	rules := []service.RenderingWordRule{}
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &rules)
+	}
+	return rules
+}
+
+// encodeRules 序列化规则列表
+func encodeRules(rules []service.RenderingWordRule) string {
+	if rules == nil {
+		rules = []service.RenderingWordRule{}
+	}
+	data, _ := json.Marshal(rules)
+	return string(data)
+}
+
+// toRuleSetSeries 将规则集版本快照转换为渲染词生成器的输入
+func toRuleSetSeries(v model.RenderingWordRuleSetVersion) service.RenderingWordSeries {
+	return service.RenderingWordSeries{
+		Name:       v.Name,
+		TmdbID:     v.TmdbID,
+		MediaType:  v.MediaType,
+		Rules:      decodeRules(v.Rules),
+		OmitHeader: v.OmitHeader,
+	}
+}
+
+// changedBy 当前操作用户名
+func changedBy(c *gin.Context) string {
+	return c.GetString("username")
+}
+
+// validateRuleSetRequest 规范化并校验规则集请求，校验失败时直接写入 400 响应
+func validateRuleSetRequest(c *gin.Context, req *RuleSetRequest) bool {
+	req.Name = strings.TrimSpace(req.Name)
+	req.MediaType = strings.TrimSpace(req.MediaType)
+	if req.MediaType == "" {
+		req.MediaType = "tv"
+	}
+
+	errs := service.ValidateRenderingWordSeries(0, service.RenderingWordSeries{
+		Name:      req.Name,
+		TmdbID:    req.TmdbID,
+		MediaType: req.MediaType,
+		Rules:     req.Rules,
+	})
+	if len(errs) > 0 {
+		respondRuleValidationErrors(c, errs)
+		return false
+	}
+	return true
+}
+
+// findRuleSet 按路径参数 id 查询规则集，不存在时直接写入 404 响应
+func (h *RenderingWordsHandler) findRuleSet(c *gin.Context) (*model.RenderingWordRuleSet, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的规则集 ID"})
+		return nil, false
+	}
+	var ruleSet model.RenderingWordRuleSet
+	if err := h.DB.First(&ruleSet, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "规则集不存在"})
+		return nil, false
+	}
+	return &ruleSet, true
+}
+
+// checkDuplicateTmdbID 同一 TMDB 条目只允许一个规则集（TMDB ID 为 0 的通用规则集不限）
+func (h *RenderingWordsHandler) checkDuplicateTmdbID(c *gin.Context, tmdbID int, mediaType string, excludeID uint) bool {
+	if tmdbID <= 0 {
+		return true
+	}
+	var count int64
+	h.DB.Model(&model.RenderingWordRuleSet{}).
+		Where("tmdb_id = ? AND media_type = ? AND id != ?", tmdbID, mediaType, excludeID).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "该 TMDB 条目已存在规则集"})
+		return false
+	}
+	return true
+}
+
+// nextSortOrder 新规则集默认排在最后
+func (h *RenderingWordsHandler) nextSortOrder() int {
+	var maxOrder int
+	h.DB.Model(&model.RenderingWordRuleSet{}).Select("COALESCE(MAX(sort_order), 0)").Scan(&maxOrder)
+	return maxOrder + 1
+}
+
+// saveWithVersion 在事务中保存规则集并写入版本快照
+func (h *RenderingWordsHandler) saveWithVersion(ruleSet *model.RenderingWordRuleSet, changeType, user string) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		return saveRuleSetVersion(tx, ruleSet, changeType, user)
+	})
+}
+
+// saveRuleSetVersion 保存规则集并写入当前版本的快照（调用方负责事务）
+func saveRuleSetVersion(tx *gorm.DB, ruleSet *model.RenderingWordRuleSet, changeType, user string) error {
+	if err := tx.Save(ruleSet).Error; err != nil {
+		return err
+	}
+	snapshot := ruleSet.Snapshot(changeType, user)
+	return tx.Create(&snapshot).Error
+}
+
+// ListRuleSets 分页获取规则集
+// GET /api/rendering-words/rule-sets
+func (h *RenderingWordsHandler) ListRuleSets(c *gin.Context) {
+	search := c.DefaultQuery("search", "")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := h.DB.Model(&model.RenderingWordRuleSet{})
+	if search != "" {
+		if tmdbID, err := strconv.Atoi(search); err == nil {
+			query = query.Where("name LIKE ? OR tmdb_id = ?", "%"+search+"%", tmdbID)
+		} else {
+			query = query.Where("name LIKE ?", "%"+search+"%")
+		}
+	}
+	if enabled := c.Query("enabled"); enabled != "" {
+		query = query.Where("enabled = ?", enabled == "true" || enabled == "1")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var ruleSets []model.RenderingWordRuleSet
+	query.Order("sort_order ASC, name ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&ruleSets)
+
+	data := make([]RuleSetResponse, len(ruleSets))
+	for i, rs := range ruleSets {
+		data[i] = RuleSetResponse{RenderingWordRuleSet: rs, Rules: decodeRules(rs.Rules)}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      data,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetRuleSet 获取单个规则集
+// GET /api/rendering-words/rule-sets/:id
+func (h *RenderingWordsHandler) GetRuleSet(c *gin.Context) {
+	ruleSet, ok := h.findRuleSet(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": RuleSetResponse{RenderingWordRuleSet: *ruleSet, Rules: decodeRules(ruleSet.Rules)}})
+}
+
+// CreateRuleSet 创建规则集，同时记录版本 1
+// POST /api/rendering-words/rule-sets
+func (h *RenderingWordsHandler) CreateRuleSet(c *gin.Context) {
+	var req RuleSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	if !validateRuleSetRequest(c, &req) || !h.checkDuplicateTmdbID(c, req.TmdbID, req.MediaType, 0) {
+		return
+	}
+
+	ruleSet := model.RenderingWordRuleSet{
+		Name:      req.Name,
+		TmdbID:    req.TmdbID,
+		MediaType: req.MediaType,
+		Rules:     encodeRules(req.Rules),
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		Comment:   req.Comment,
+		Version:   1,
+		SortOrder: h.nextSortOrder(),
+
+		OmitHeader: req.OmitHeader,
+	}
+	if req.SortOrder != nil {
+		ruleSet.SortOrder = *req.SortOrder
+	}
+	if err := h.saveWithVersion(&ruleSet, model.RuleSetChangeCreate, changedBy(c)); err != nil {
+		log.Printf("❌ 创建规则集失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存规则集失败"})
+		return
+	}
+
+	log.Printf("📝 已创建渲染词规则集: %s (TMDB ID=%d)", ruleSet.Name, ruleSet.TmdbID)
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": RuleSetResponse{RenderingWordRuleSet: ruleSet, Rules: decodeRules(ruleSet.Rules)}})
+}
+
+// UpdateRuleSet 更新规则集，版本号递增并记录快照；内容未变化时不产生新版本
+// PUT /api/rendering-words/rule-sets/:id
+func (h *RenderingWordsHandler) UpdateRuleSet(c *gin.Context) {
+	ruleSet, ok := h.findRuleSet(c)
+	if !ok {
+		return
+	}
+
+	var req RuleSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	if !validateRuleSetRequest(c, &req) || !h.checkDuplicateTmdbID(c, req.TmdbID, req.MediaType, ruleSet.ID) {
+		return
+	}
+
+	updated := *ruleSet
+	updated.Name = req.Name
+	updated.TmdbID = req.TmdbID
+	updated.MediaType = req.MediaType
+	updated.Rules = encodeRules(req.Rules)
+	updated.Comment = req.Comment
+	updated.OmitHeader = req.OmitHeader
+	if req.Enabled != nil {
+		updated.Enabled = *req.Enabled
+	}
+	if req.SortOrder != nil {
+		updated.SortOrder = *req.SortOrder
+	}
+
+	if len(ruleSetChanges(ruleSet.Snapshot("", ""), updated.Snapshot("", ""))) == 0 && updated.SortOrder == ruleSet.SortOrder {
+		c.JSON(http.StatusOK, gin.H{"message": "内容未变化", "data": RuleSetResponse{RenderingWordRuleSet: *ruleSet, Rules: decodeRules(ruleSet.Rules)}})
+		return
+	}
+
+	updated.Version = ruleSet.Version + 1
+	if err := h.saveWithVersion(&updated, model.RuleSetChangeUpdate, changedBy(c)); err != nil {
+		log.Printf("❌ 更新规则集失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存规则集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": RuleSetResponse{RenderingWordRuleSet: updated, Rules: decodeRules(updated.Rules)}})
+}
+
+// DeleteRuleSet 删除规则集及其全部版本
+// DELETE /api/rendering-words/rule-sets/:id
+func (h *RenderingWordsHandler) DeleteRuleSet(c *gin.Context) {
+	ruleSet, ok := h.findRuleSet(c)
+	if !ok {
+		return
+	}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("rule_set_id = ?", ruleSet.ID).Delete(&model.RenderingWordRuleSetVersion{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(ruleSet).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除规则集失败"})
+		return
+	}
+	log.Printf("🗑️ 已删除渲染词规则集: %s", ruleSet.Name)
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// ListRuleSetVersions 获取规则集的版本历史（新版本在前）
+// GET /api/rendering-words/rule-sets/:id/versions
+func (h *RenderingWordsHandler) ListRuleSetVersions(c *gin.Context) {
+	ruleSet, ok := h.findRuleSet(c)
+	if !ok {
+		return
+	}
+	var versions []model.RenderingWordRuleSetVersion
+	h.DB.Where("rule_set_id = ?", ruleSet.ID).Order("version DESC").Find(&versions)
+
+	data := make([]RuleSetVersionResponse, len(versions))
+	for i, v := range versions {
+		data[i] = RuleSetVersionResponse{RenderingWordRuleSetVersion: v, Rules: decodeRules(v.Rules)}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "current_version": ruleSet.Version})
+}
+
+// findVersion 查询规则集的指定版本
+func (h *RenderingWordsHandler) findVersion(ruleSetID uint, version int) (*model.RenderingWordRuleSetVersion, error) {
+	var v model.RenderingWordRuleSetVersion
+	if err := h.DB.Where("rule_set_id = ? AND version = ?", ruleSetID, version).First(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// DiffRuleSetVersion 对比两个版本：字段变更 + 渲染词行级差异
+// GET /api/rendering-words/rule-sets/:id/versions/:version/diff?against=N
+// against 缺省时与上一个版本对比，version 为 1 时与空规则集对比
+func (h *RenderingWordsHandler) DiffRuleSetVersion(c *gin.Context) {
+	ruleSet, ok := h.findRuleSet(c)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的版本号"})
+		return
+	}
+	against := version - 1
+	if s := c.Query("against"); s != "" {
+		if against, err = strconv.Atoi(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的对比版本号"})
+			return
+		}
+	}
+
+	target, err := h.findVersion(ruleSet.ID, version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "版本不存在"})
+		return
+	}
+	base := &model.RenderingWordRuleSetVersion{Rules: "[]"}
+	if against > 0 {
+		if base, err = h.findVersion(ruleSet.ID, against); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "对比版本不存在"})
+			return
+		}
+	}
+
+	oldLines := service.RenderingWordLines(toRuleSetSeries(*base))
+	newLines := service.RenderingWordLines(toRuleSetSeries(*target))
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"from_version": against,
+		"to_version":   version,
+		"changes":      ruleSetChanges(*base, *target),
+		"lines":        service.DiffLines(oldLines, newLines),
+	}})
+}
+
+// RollbackRuleSet 回滚到指定版本：以该版本内容生成一个新版本，历史版本保持不变
+// POST /api/rendering-words/rule-sets/:id/versions/:version/rollback
+func (h *RenderingWordsHandler) RollbackRuleSet(c *gin.Context) {
+	ruleSet, ok := h.findRuleSet(c)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的版本号"})
+		return
+	}
+	target, err := h.findVersion(ruleSet.ID, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "版本不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询版本失败"})
+		return
+	}
+	if !h.checkDuplicateTmdbID(c, target.TmdbID, target.MediaType, ruleSet.ID) {
+		return
+	}
+
+	restored := *ruleSet
+	restored.Name = target.Name
+	restored.TmdbID = target.TmdbID
+	restored.MediaType = target.MediaType
+	restored.Rules = target.Rules
+	restored.Enabled = target.Enabled
+	restored.Comment = target.Comment
+	restored.OmitHeader = target.OmitHeader
+	restored.Version = ruleSet.Version + 1
+
+	if err := h.saveWithVersion(&restored, model.RuleSetChangeRollback, changedBy(c)); err != nil {
+		log.Printf("❌ 回滚规则集失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "回滚规则集失败"})
+		return
+	}
+
+	log.Printf("⏪ 规则集 %s 已回滚到版本 %d（新版本 %d）", restored.Name, version, restored.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": RuleSetResponse{RenderingWordRuleSet: restored, Rules: decodeRules(restored.Rules)}})
+}
+
+// ExportRuleSets 将所有启用的规则集导出为渲染词文本文件
+// GET /api/rendering-words/rule-sets/export
+func (h *RenderingWordsHandler) ExportRuleSets(c *gin.Context) {
+	content, errs := h.generateEnabledRuleSets()
+	if len(errs) > 0 {
+		respondRuleValidationErrors(c, errs)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+customWordsFileName+`"`)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content))
+}
+
+// generateEnabledRuleSets 生成所有启用规则集的渲染词文本（按排序值、名称排序）
+func (h *RenderingWordsHandler) generateEnabledRuleSets() (string, []service.RuleValidationError) {
+	var ruleSets []model.RenderingWordRuleSet
+	h.DB.Where("enabled = ?", true).Order("sort_order ASC, name ASC, id ASC").Find(&ruleSets)
+
+	seriesList := make([]service.RenderingWordSeries, len(ruleSets))
+	for i, rs := range ruleSets {
+		seriesList[i] = toRuleSetSeries(rs.Snapshot("", ""))
+	}
+	return service.GenerateRenderingWords(seriesList)
+}
+
+// ruleSetChanges 对比两个版本快照的字段变更（规则变更以行级差异展示，这里只标记是否变化）
+func ruleSetChanges(old, new model.RenderingWordRuleSetVersion) []FieldChange {
+	changes := []FieldChange{}
+	if old.Name != new.Name {
+		changes = append(changes, FieldChange{Field: "name", Old: old.Name, New: new.Name})
+	}
+	if old.TmdbID != new.TmdbID {
+		changes = append(changes, FieldChange{Field: "tmdb_id", Old: old.TmdbID, New: new.TmdbID})
+	}
+	if old.MediaType != new.MediaType {
+		changes = append(changes, FieldChange{Field: "media_type", Old: old.MediaType, New: new.MediaType})
+	}
+	if old.Enabled != new.Enabled {
+		changes = append(changes, FieldChange{Field: "enabled", Old: old.Enabled, New: new.Enabled})
+	}
+	if old.Comment != new.Comment {
+		changes = append(changes, FieldChange{Field: "comment", Old: old.Comment, New: new.Comment})
+	}
+	if old.OmitHeader != new.OmitHeader {
+		changes = append(changes, FieldChange{Field: "omit_header", Old: old.OmitHeader, New: new.OmitHeader})
+	}
+	if encodeRules(decodeRules(old.Rules)) != encodeRules(decodeRules(new.Rules)) {
+		changes = append(changes, FieldChange{Field: "rules", Old: len(decodeRules(old.Rules)), New: len(decodeRules(new.Rules))})
+	}
+	return changes
+}
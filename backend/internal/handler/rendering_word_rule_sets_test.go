@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupRuleSetTest 创建测试用的 Gin 引擎和 RenderingWordsHandler
+func setupRuleSetTest(t *testing.T) (*gin.Engine, *RenderingWordsHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("InitDB 失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	h := NewRenderingWordsHandler(db)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", "tester") })
+	r.GET("/api/rendering-words/rule-sets", h.ListRuleSets)
+	r.GET("/api/rendering-words/rule-sets/export", h.ExportRuleSets)
+	r.POST("/api/rendering-words/rule-sets", h.CreateRuleSet)
+	r.PUT("/api/rendering-words/rule-sets/:id", h.UpdateRuleSet)
+	r.DELETE("/api/rendering-words/rule-sets/:id", h.DeleteRuleSet)
+	r.GET("/api/rendering-words/rule-sets/:id/versions", h.ListRuleSetVersions)
+	r.GET("/api/rendering-words/rule-sets/:id/versions/:version/diff", h.DiffRuleSetVersion)
+	r.POST("/api/rendering-words/rule-sets/:id/versions/:version/rollback", h.RollbackRuleSet)
+	return r, h
+}
+
+func doRuleSetRequest(r *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRuleSet_VersionHistoryAndRollback(t *testing.T) {
+	r, h := setupRuleSetTest(t)
+
+	v1 := RuleSetRequest{
+		Name:   "葬送的芙莉莲",
+		TmdbID: 209867,
+		Rules: []service.RenderingWordRule{
+			{Type: service.RuleTypeMapping, SourceSeason: 1, SourceEpisodes: "29-40", TargetSeason: 2, Offset: "EP-28"},
+		},
+	}
+	w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets", v1)
+	if w.Code != http.StatusOK {
+		t.Fatalf("创建规则集失败: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data RuleSetResponse `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	id := created.Data.ID
+	if created.Data.Version != 1 || !created.Data.Enabled || len(created.Data.Rules) != 1 {
+		t.Fatalf("创建结果不符合预期: %+v", created.Data)
+	}
+
+	// 同一 TMDB 条目重复创建应冲突
+	if w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets", v1); w.Code != http.StatusConflict {
+		t.Errorf("重复 TMDB ID 期望 409，实际 %d", w.Code)
+	}
+
+	// 非法规则应返回 400 并指明字段
+	bad := v1
+	bad.TmdbID = 1
+	bad.Rules = []service.RenderingWordRule{{Type: service.RuleTypeMapping, SourceSeason: 1, SourceEpisodes: "x", TargetSeason: 2, Offset: "EP"}}
+	if w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets", bad); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "source_episodes") {
+		t.Errorf("非法规则期望 400，实际 %d %s", w.Code, w.Body.String())
+	}
+
+	// 更新生成版本 2
+	v2 := v1
+	v2.Rules = append([]service.RenderingWordRule{}, v1.Rules...)
+	v2.Rules = append(v2.Rules, service.RenderingWordRule{Type: service.RuleTypeBlock, Pattern: "广告"})
+	path := fmt.Sprintf("/api/rendering-words/rule-sets/%d", id)
+	if w := doRuleSetRequest(r, http.MethodPut, path, v2); w.Code != http.StatusOK {
+		t.Fatalf("更新规则集失败: %d %s", w.Code, w.Body.String())
+	}
+	// 内容未变化时不产生新版本
+	doRuleSetRequest(r, http.MethodPut, path, v2)
+
+	var versionCount int64
+	h.DB.Model(&model.RenderingWordRuleSetVersion{}).Where("rule_set_id = ?", id).Count(&versionCount)
+	if versionCount != 2 {
+		t.Fatalf("期望 2 个版本，实际 %d", versionCount)
+	}
+
+	// 版本 2 与版本 1 的差异应只新增屏蔽词一行
+	w = doRuleSetRequest(r, http.MethodGet, path+"/versions/2/diff", nil)
+	var diff struct {
+		Data struct {
+			Lines []service.DiffLine `json:"lines"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &diff)
+	var added []string
+	for _, l := range diff.Data.Lines {
+		if l.Op != service.DiffEqual {
+			added = append(added, l.Op+l.Text)
+		}
+	}
+	if len(added) != 1 || added[0] != "+广告" {
+		t.Errorf("差异不符合预期: %v", added)
+	}
+
+	// 回滚到版本 1 生成版本 3，内容与版本 1 一致
+	w = doRuleSetRequest(r, http.MethodPost, path+"/versions/1/rollback", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("回滚失败: %d %s", w.Code, w.Body.String())
+	}
+	var current model.RenderingWordRuleSet
+	h.DB.First(&current, id)
+	if current.Version != 3 || len(decodeRules(current.Rules)) != 1 {
+		t.Errorf("回滚后状态不符合预期: version=%d rules=%s", current.Version, current.Rules)
+	}
+	var latest model.RenderingWordRuleSetVersion
+	h.DB.Where("rule_set_id = ? AND version = 3", id).First(&latest)
+	if latest.ChangeType != model.RuleSetChangeRollback || latest.ChangedBy != "tester" {
+		t.Errorf("回滚版本记录不符合预期: %+v", latest)
+	}
+
+	// 删除后版本一并删除
+	if w := doRuleSetRequest(r, http.MethodDelete, path, nil); w.Code != http.StatusOK {
+		t.Fatalf("删除失败: %d", w.Code)
+	}
+	h.DB.Model(&model.RenderingWordRuleSetVersion{}).Where("rule_set_id = ?", id).Count(&versionCount)
+	if versionCount != 0 {
+		t.Errorf("删除规则集后仍有 %d 个版本", versionCount)
+	}
+}
+
+func TestRuleSet_ExportOnlyEnabled(t *testing.T) {
+	r, _ := setupRuleSetTest(t)
+
+	disabled := false
+	doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets", RuleSetRequest{
+		Name:  "启用",
+		Rules: []service.RenderingWordRule{{Type: service.RuleTypeBlock, Pattern: "AAA"}},
+	})
+	doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets", RuleSetRequest{
+		Name:    "停用",
+		Enabled: &disabled,
+		Rules:   []service.RenderingWordRule{{Type: service.RuleTypeBlock, Pattern: "BBB"}},
+	})
+
+	w := doRuleSetRequest(r, http.MethodGet, "/api/rendering-words/rule-sets/export", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("导出失败: %d %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if body != "# 启用\nAAA\n" {
+		t.Errorf("导出内容不符合预期: %q", body)
+	}
+}
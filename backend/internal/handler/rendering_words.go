@@ -4,9 +4,10 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 
 	"embyforge/internal/model"
-	"embyforge/internal/tmdb"
+	"embyforge/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -35,15 +36,18 @@ type ImportCandidate struct {
 	EmbyItemID       string        `json:"emby_item_id"`
 	Name             string        `json:"name"`
 	TmdbID           int           `json:"tmdb_id"`
+	TmdbName         string        `json:"tmdb_name"` // TMDB 本地化节目名称
 	Seasons          []SeasonInfo  `json:"seasons"`
 	RecommendedRules []MappingRule `json:"recommended_rules"`
+	AlignmentError   string        `json:"alignment_error,omitempty"` // 场景3 无法自动对齐时的原因
 }
 
 // SeasonInfo 季信息
 type SeasonInfo struct {
-	SeasonNumber  int `json:"season_number"`
-	LocalEpisodes int `json:"local_episodes"`
-	TmdbEpisodes  int `json:"tmdb_episodes"`
+	SeasonNumber   int    `json:"season_number"`
+	LocalEpisodes  int    `json:"local_episodes"`
+	TmdbEpisodes   int    `json:"tmdb_episodes"`
+	TmdbSeasonName string `json:"tmdb_season_name"` // TMDB 本地化季名称
 }
 
 // GetImportCandidates 获取可导入的候选剧集
@@ -51,7 +55,7 @@ type SeasonInfo struct {
 // 支持三种场景：
 // 1. 本地多季 → TMDB 1季：本地 S2/S3... 映射到 TMDB S1，偏移量为正数
 // 2. 本地1季 → TMDB多季：本地 S1 的后半部分映射到 TMDB S2/S3...，偏移量为负数
-// 3. 其他季数不匹配：本地和 TMDB 都有多季但数量不同
+// 3. 其他季数不匹配：本地和 TMDB 都有多季但数量不同，按累计集数对齐 TMDB 季边界推断规则
 func (h *RenderingWordsHandler) GetImportCandidates(c *gin.Context) {
 	search := c.DefaultQuery("search", "")
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -135,12 +139,23 @@ func (h *RenderingWordsHandler) GetImportCandidates(c *gin.Context) {
 		}
 
 		// 构建 tmdb_id -> season_number -> episode_count 的映射
+		// 同时按语言偏好收集本地化的节目名称和季名称
+		languages := getTMDBLanguages(h.DB)
 		tmdbMap := make(map[int]map[int]int)
+		tmdbNameMap := make(map[int]string)
+		tmdbSeasonNameMap := make(map[int]map[int]string)
 		for _, tc := range tmdbCaches {
 			if _, exists := tmdbMap[tc.TmdbID]; !exists {
 				tmdbMap[tc.TmdbID] = make(map[int]int)
+				tmdbSeasonNameMap[tc.TmdbID] = make(map[int]string)
 			}
 			tmdbMap[tc.TmdbID][tc.SeasonNumber] = tc.EpisodeCount
+
+			name, seasonName := tc.LocalizedNames(languages)
+			if tmdbNameMap[tc.TmdbID] == "" {
+				tmdbNameMap[tc.TmdbID] = name
+			}
+			tmdbSeasonNameMap[tc.TmdbID][tc.SeasonNumber] = seasonName
 		}
 
 		// 构建候选列表
@@ -162,9 +177,10 @@ func (h *RenderingWordsHandler) GetImportCandidates(c *gin.Context) {
 					}
 				}
 				seasonInfos = append(seasonInfos, SeasonInfo{
-					SeasonNumber:  season.SeasonNumber,
-					LocalEpisodes: season.EpisodeCount,
-					TmdbEpisodes:  tmdbEpisodes,
+					SeasonNumber:   season.SeasonNumber,
+					LocalEpisodes:  season.EpisodeCount,
+					TmdbEpisodes:   tmdbEpisodes,
+					TmdbSeasonName: tmdbSeasonNameMap[info.TmdbID][season.SeasonNumber],
 				})
 			}
 
@@ -257,14 +273,40 @@ func (h *RenderingWordsHandler) GetImportCandidates(c *gin.Context) {
 					cumulativeEpisodes += tmdbEpCount
 				}
 			}
-			// 场景3：本地多季 vs TMDB多季但数量不同，暂不自动生成规则，用户手动配置
+			var alignmentError string
+			if info.LocalSeasonCount > 1 && info.TmdbSeasonCount > 1 {
+				// 场景3：本地多季 vs TMDB多季但数量不同
+				// 例：本地 S1(12集)+S2(12集)，TMDB S1(24集)+S2(12集)+S3(...)
+				// 规则：s=2, e=1-12 => s=1, e=EP+12
+				localCounts := make([]SeasonEpisodeCount, 0, len(localSeasons))
+				for _, season := range localSeasons {
+					localCounts = append(localCounts, SeasonEpisodeCount{SeasonNumber: season.SeasonNumber, EpisodeCount: season.EpisodeCount})
+				}
+				tmdbCounts := make([]SeasonEpisodeCount, 0, len(tmdbMap[info.TmdbID]))
+				for sn, count := range tmdbMap[info.TmdbID] {
+					tmdbCounts = append(tmdbCounts, SeasonEpisodeCount{SeasonNumber: sn, EpisodeCount: count})
+				}
+				rules, err := InferMultiSeasonRules(localCounts, tmdbCounts)
+				if err != nil {
+					alignmentError = err.Error()
+				} else {
+					recommendedRules = rules
+				}
+			}
+
+			tmdbName := tmdbNameMap[info.TmdbID]
+			if tmdbName == "" {
+				tmdbName = info.TmdbName
+			}
 
 			candidates = append(candidates, ImportCandidate{
 				EmbyItemID:       embyItemID,
 				Name:             info.Name,
 				TmdbID:           info.TmdbID,
+				TmdbName:         tmdbName,
 				Seasons:          seasonInfos,
 				RecommendedRules: recommendedRules,
+				AlignmentError:   alignmentError,
 			})
 		}
 	}
@@ -290,17 +332,22 @@ func (h *RenderingWordsHandler) ValidateTmdbID(c *gin.Context) {
 		return
 	}
 
-	// 获取 TMDB API Key
-	var config model.SystemConfig
-	if err := h.DB.Where("key = ?", "tmdb_api_key").First(&config).Error; err != nil {
+	// 根据系统配置创建 TMDB 客户端（支持读访问令牌、反向代理和代理地址）
+	tmdbClient, err := newTMDBClientFromConfig(h.DB)
+	if err == errTMDBNotConfigured {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400,
 			"message": "请先配置 TMDB API Key",
 		})
 		return
 	}
-
-	tmdbClient := tmdb.NewClient(config.Value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "TMDB 连接配置无效: " + err.Error(),
+		})
+		return
+	}
 
 	// 获取 TMDB 数据
 	details, err := tmdbClient.GetTVShowDetails(tmdbID)
@@ -333,3 +380,74 @@ func (h *RenderingWordsHandler) ValidateTmdbID(c *gin.Context) {
 		"seasons": seasons,
 	})
 }
+
+// GenerateRequest 渲染词生成请求
+type GenerateRequest struct {
+	Series []service.RenderingWordSeries `json:"series"`
+}
+
+// customWordsFileName 导出的渲染词文件名
+const customWordsFileName = "symedia_custom_words.txt"
+
+// generateFromRequest 解析请求并生成渲染词文本，校验失败时直接写入 400 响应
+func generateFromRequest(c *gin.Context) (string, bool) {
+	var req GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return "", false
+	}
+	if len(req.Series) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请至少提供一部剧集的规则"})
+		return "", false
+	}
+
+	content, errs := service.GenerateRenderingWords(req.Series)
+	if len(errs) > 0 {
+		respondRuleValidationErrors(c, errs)
+		return "", false
+	}
+	return content, true
+}
+
+// respondRuleValidationErrors 写入规则校验失败的 400 响应，errors 字段指明出错的剧集和规则
+func respondRuleValidationErrors(c *gin.Context, errs []service.RuleValidationError) {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Error()
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code":    400,
+		"message": "规则校验失败: " + strings.Join(messages, "; "),
+		"errors":  errs,
+	})
+}
+
+// GenerateRenderingWords 将规则生成为 Symedia 自定义渲染词文本
+// POST /api/rendering-words/generate
+func (h *RenderingWordsHandler) GenerateRenderingWords(c *gin.Context) {
+	content, ok := generateFromRequest(c)
+	if !ok {
+		return
+	}
+	lineCount := 0
+	if content != "" {
+		lineCount = strings.Count(content, "\n")
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"content":    content,
+			"line_count": lineCount,
+		},
+	})
+}
+
+// ExportRenderingWords 将规则生成为渲染词文本文件下载
+// POST /api/rendering-words/export
+func (h *RenderingWordsHandler) ExportRenderingWords(c *gin.Context) {
+	content, ok := generateFromRequest(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+customWordsFileName+`"`)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content))
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxImportFileSize 导入文件大小上限
+const maxImportFileSize = 5 << 20
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Merged    int      `json:"merged"`  // 合并到前面同一 TMDB 条目或同名块的块数
+	Skipped   []string `json:"skipped"` // 未导入的块及原因
+}
+
+// ImportRuleSets 导入 Symedia 自定义渲染词文件为规则集
+// 上传文件（multipart 字段 file），或 source=github 时读取 GitHub 配置中的仓库文件；dry_run=true 时只返回解析结果
+// 从 GitHub 导入没有自动生成标记的文件时，如果所有块都已导入为规则集，记录文件版本，下次发布时整体替换该文件；
+// 有未导入的块时不记录，避免发布时删除这些内容
+// 已存在的规则集（同一 TMDB 条目，或同名的通用规则集）更新为新版本，内容未变化时只调整排序
+// POST /api/rendering-words/rule-sets/import
+func (h *RenderingWordsHandler) ImportRuleSets(c *gin.Context) {
+	var content string
+	var target *gitHubTarget
+	importedSHA := ""
+	if c.Query("source") == "github" {
+		var ok bool
+		target, ok = h.loadGitHubTarget(c)
+		if !ok {
+			return
+		}
+		file, err := target.Client.GetFile(c.Request.Context(), target.Owner, target.Repo, target.FilePath, target.Config.Branch)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"code": 502, "message": err.Error()})
+			return
+		}
+		if file == nil {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "仓库中不存在渲染词文件: " + target.FilePath})
+			return
+		}
+		content = file.Content
+		if !service.HasManagedBlock(content) {
+			importedSHA = file.SHA
+		}
+	} else {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请上传渲染词文件"})
+			return
+		}
+		if fileHeader.Size > maxImportFileSize {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "文件过大，最大 5MB"})
+			return
+		}
+		f, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "读取上传文件失败"})
+			return
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, maxImportFileSize))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "读取上传文件失败"})
+			return
+		}
+		content = string(data)
+	}
+
+	seriesList := service.ParseRenderingWords(content)
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, gin.H{"data": seriesList})
+		return
+	}
+
+	var result ImportResult
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = importRuleSets(tx, seriesList, changedBy(c))
+		if err != nil || target == nil {
+			return err
+		}
+		if len(result.Skipped) > 0 {
+			importedSHA = ""
+		}
+		// 使用 UpdateColumns 跳过加密钩子，避免重复加密已解密的令牌字段
+		return tx.Model(&model.WebhookConfig{}).Where("id = ?", target.Config.ID).
+			UpdateColumns(map[string]interface{}{"imported_sha": importedSHA}).Error
+	})
+	if err != nil {
+		log.Printf("❌ 导入渲染词失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "导入渲染词失败"})
+		return
+	}
+
+	log.Printf("📥 渲染词导入完成: 新建 %d, 更新 %d, 未变化 %d, 合并 %d, 跳过 %d",
+		result.Created, result.Updated, result.Unchanged, result.Merged, len(result.Skipped))
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": result})
+}
+
+// importRuleSetKey 规则集的导入匹配键：有 TMDB 条目时按条目，否则按名称
+func importRuleSetKey(series service.RenderingWordSeries) string {
+	if series.TmdbID == 0 {
+		return "name/" + series.Name
+	}
+	mediaType := series.MediaType
+	if mediaType == "" {
+		mediaType = "tv"
+	}
+	return fmt.Sprintf("%d/%s", series.TmdbID, mediaType)
+}
+
+// mergeDuplicateSeries 将同一 TMDB 条目或同名的块（如按季分开书写的块）合并到文件中第一个块，
+// 后续块的名称作为原样保留的注释行，规则按文件顺序追加
+func mergeDuplicateSeries(seriesList []service.RenderingWordSeries) ([]service.RenderingWordSeries, int) {
+	var merged []service.RenderingWordSeries
+	index := make(map[string]int)
+	count := 0
+	for _, series := range seriesList {
+		key := importRuleSetKey(series)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			series.Rules = append([]service.RenderingWordRule(nil), series.Rules...)
+			merged = append(merged, series)
+			continue
+		}
+		if !series.OmitHeader {
+			merged[i].Rules = append(merged[i].Rules, service.RenderingWordRule{Type: service.RuleTypeRaw, Pattern: "# " + series.Name})
+		}
+		merged[i].Rules = append(merged[i].Rules, series.Rules...)
+		count++
+	}
+	return merged, count
+}
+
+// keepInvalidRulesAsRaw 将未通过校验的规则改为原样保留的行，返回仍无法通过校验的错误（剧集级错误）
+func keepInvalidRulesAsRaw(seriesIndex int, series *service.RenderingWordSeries) []service.RuleValidationError {
+	errs := service.ValidateRenderingWordSeries(seriesIndex, *series)
+	for _, e := range errs {
+		if e.RuleIndex >= 0 && series.Rules[e.RuleIndex].Type != service.RuleTypeRaw {
+			rule := series.Rules[e.RuleIndex]
+			series.Rules[e.RuleIndex] = service.RenderingWordRule{Type: service.RuleTypeRaw, Pattern: service.FormatRenderingWordRule(*series, rule)}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return service.ValidateRenderingWordSeries(seriesIndex, *series)
+}
+
+// importRuleSets 将解析出的剧集规则写入规则集，排序值按文件中的顺序设置
+// 同一 TMDB 条目或同名的块合并导入，未通过校验的规则原样保留，只有剧集本身无效的块才跳过
+func importRuleSets(tx *gorm.DB, seriesList []service.RenderingWordSeries, user string) (ImportResult, error) {
+	result := ImportResult{Skipped: []string{}}
+	seriesList, result.Merged = mergeDuplicateSeries(seriesList)
+
+	for i, series := range seriesList {
+		mediaType := series.MediaType
+		if mediaType == "" {
+			mediaType = "tv"
+		}
+		if errs := keepInvalidRulesAsRaw(i, &series); len(errs) > 0 {
+			result.Skipped = append(result.Skipped, errs[0].Error())
+			continue
+		}
+
+		var existing model.RenderingWordRuleSet
+		query := tx.Where("tmdb_id = ? AND media_type = ?", series.TmdbID, mediaType)
+		if series.TmdbID == 0 {
+			query = tx.Where("tmdb_id = 0 AND name = ?", series.Name)
+		}
+		err := query.First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+
+		imported := model.RenderingWordRuleSet{
+			Name:       series.Name,
+			TmdbID:     series.TmdbID,
+			MediaType:  mediaType,
+			Rules:      encodeRules(series.Rules),
+			Enabled:    true,
+			Version:    1,
+			SortOrder:  i + 1,
+			OmitHeader: series.OmitHeader,
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := saveRuleSetVersion(tx, &imported, model.RuleSetChangeImport, user); err != nil {
+				return result, err
+			}
+			result.Created++
+			continue
+		}
+
+		updated := existing
+		updated.Name, updated.Rules, updated.OmitHeader, updated.SortOrder = imported.Name, imported.Rules, imported.OmitHeader, imported.SortOrder
+		if len(ruleSetChanges(existing.Snapshot("", ""), updated.Snapshot("", ""))) == 0 {
+			if err := tx.Model(&existing).Update("sort_order", updated.SortOrder).Error; err != nil {
+				return result, err
+			}
+			result.Unchanged++
+			continue
+		}
+		updated.Version = existing.Version + 1
+		if err := saveRuleSetVersion(tx, &updated, model.RuleSetChangeImport, user); err != nil {
+			return result, err
+		}
+		result.Updated++
+	}
+	return result, nil
+}
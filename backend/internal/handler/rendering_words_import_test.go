@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func uploadCustomWords(r *gin.Engine, content string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", "custom_words.txt")
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/rendering-words/rule-sets/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestImportRuleSets_RoundTrip 导入手写文件后导出内容与原文一致，重复导入不产生新版本
+func TestImportRuleSets_RoundTrip(t *testing.T) {
+	r, h := setupRuleSetTest(t)
+	r.POST("/api/rendering-words/rule-sets/import", h.ImportRuleSets)
+
+	content := "# 通用\n" +
+		"国语配音\n" +
+		"#手写的奇怪注释\n" +
+		"\n" +
+		"# 葬送的芙莉莲\n" +
+		"# 第二季\n" +
+		"@?{[tmdbid=209867;type=tv;s=1;e=29-40]} => {[s=2;e=EP-28]}\n" +
+		"\n" +
+		"无名称块 => 替换\n"
+
+	w := uploadCustomWords(r, content)
+	var resp struct {
+		Data ImportResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Data.Created != 3 {
+		t.Fatalf("导入失败: %d %s", w.Code, w.Body.String())
+	}
+
+	w = doRuleSetRequest(r, http.MethodGet, "/api/rendering-words/rule-sets/export", nil)
+	if w.Body.String() != content {
+		t.Errorf("导出内容与原文不一致:\n%q\n%q", w.Body.String(), content)
+	}
+
+	// 重复导入：内容未变化
+	w = uploadCustomWords(r, content)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.Unchanged != 3 || resp.Data.Created != 0 || resp.Data.Updated != 0 {
+		t.Errorf("重复导入结果不正确: %+v", resp.Data)
+	}
+
+	// 修改后导入：已有规则集生成新版本
+	modified := "# 葬送的芙莉莲\n@?{[tmdbid=209867;type=tv;s=1;e=29-41]} => {[s=2;e=EP-28]}\n"
+	w = uploadCustomWords(r, modified)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.Updated != 1 {
+		t.Fatalf("修改后导入应更新 1 个规则集: %+v", resp.Data)
+	}
+	var ruleSet model.RenderingWordRuleSet
+	h.DB.Where("tmdb_id = ?", 209867).First(&ruleSet)
+	var latest model.RenderingWordRuleSetVersion
+	h.DB.Where("rule_set_id = ?", ruleSet.ID).Order("version DESC").First(&latest)
+	if ruleSet.Version != 2 || latest.ChangeType != model.RuleSetChangeImport {
+		t.Errorf("导入更新的版本记录不正确: version=%d change=%s", ruleSet.Version, latest.ChangeType)
+	}
+}
+
+// TestImportRuleSets_MergesDuplicateBlocks 按季分开书写的同一剧集块合并导入，不同文件的无名称块互不覆盖
+func TestImportRuleSets_MergesDuplicateBlocks(t *testing.T) {
+	r, h := setupRuleSetTest(t)
+	r.POST("/api/rendering-words/rule-sets/import", h.ImportRuleSets)
+
+	content := "# 葬送的芙莉莲\n" +
+		"@?{[tmdbid=209867;type=tv;s=1;e=29-40]} => {[s=2;e=EP-28]}\n" +
+		"\n" +
+		"# 葬送的芙莉莲 第三季\n" +
+		"@?{[tmdbid=209867;type=tv;s=1;e=41-52]} => {[s=3;e=EP-40]}\n"
+	w := uploadCustomWords(r, content)
+	var resp struct {
+		Data ImportResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Data.Created != 1 || resp.Data.Merged != 1 || len(resp.Data.Skipped) != 0 {
+		t.Fatalf("同一剧集的块应合并导入: %d %s", w.Code, w.Body.String())
+	}
+	w = doRuleSetRequest(r, http.MethodGet, "/api/rendering-words/rule-sets/export", nil)
+	for _, line := range []string{"# 葬送的芙莉莲 第三季", "e=29-40", "e=41-52"} {
+		if !strings.Contains(w.Body.String(), line) {
+			t.Errorf("合并后丢失内容 %q:\n%s", line, w.Body.String())
+		}
+	}
+
+	uploadCustomWords(r, "无名称 => 替换一\n")
+	uploadCustomWords(r, "无名称 => 替换二\n")
+	var count int64
+	h.DB.Model(&model.RenderingWordRuleSet{}).Where("tmdb_id = 0").Count(&count)
+	if count != 2 {
+		t.Errorf("不同文件的无名称块不应互相覆盖，实际 %d 个规则集", count)
+	}
+}
+
+func TestKeepInvalidRulesAsRaw(t *testing.T) {
+	series := service.RenderingWordSeries{Name: "通用", Rules: []service.RenderingWordRule{
+		{Type: service.RuleTypeBlock, Pattern: "广告"},
+		{Type: service.RuleTypeBlock, Pattern: "#标签"},
+	}}
+	if errs := keepInvalidRulesAsRaw(0, &series); len(errs) != 0 {
+		t.Fatalf("规则级错误应改为原样保留: %v", errs)
+	}
+	if r := series.Rules[1]; r.Type != service.RuleTypeRaw || r.Pattern != "#标签" {
+		t.Errorf("无效规则应原样保留: %+v", r)
+	}
+	if series.Rules[0].Type != service.RuleTypeBlock {
+		t.Errorf("有效规则不应改变: %+v", series.Rules[0])
+	}
+}
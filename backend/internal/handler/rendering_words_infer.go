@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// SeasonEpisodeCount 季号与集数
+type SeasonEpisodeCount struct {
+	SeasonNumber int
+	EpisodeCount int
+}
+
+// InferMultiSeasonRules 场景3：本地多季 vs TMDB 多季（季数不同）的映射规则推断
+//
+// 把本地各季与 TMDB 各季分别按季号顺序首尾相接成一条连续的集序列，
+// 本地第 k 集（累计）对应 TMDB 第 k 集（累计）。本地每一季按 TMDB 季边界切分成若干段，
+// 每段生成一条规则；季号和集号都不变的段无需规则，因此返回的是最少规则集合。
+//
+// 以下情况无法对齐，返回错误：
+//   - 本地或 TMDB 没有正片季
+//   - 本地季号不连续（中间缺季时无法确定累计集数）
+//   - 本地总集数超过 TMDB 总集数
+func InferMultiSeasonRules(localSeasons, tmdbSeasons []SeasonEpisodeCount) ([]MappingRule, error) {
+	local := regularSeasons(localSeasons)
+	tmdb := regularSeasons(tmdbSeasons)
+	if len(local) == 0 {
+		return nil, fmt.Errorf("本地没有正片季")
+	}
+	if len(tmdb) == 0 {
+		return nil, fmt.Errorf("TMDB 没有正片季")
+	}
+
+	localTotal := 0
+	for i, s := range local {
+		if i > 0 && s.SeasonNumber != local[i-1].SeasonNumber+1 {
+			return nil, fmt.Errorf("本地季号不连续（S%d 之后是 S%d），无法按累计集数对齐",
+				local[i-1].SeasonNumber, s.SeasonNumber)
+		}
+		localTotal += s.EpisodeCount
+	}
+	tmdbTotal := 0
+	for _, s := range tmdb {
+		tmdbTotal += s.EpisodeCount
+	}
+	if localTotal > tmdbTotal {
+		return nil, fmt.Errorf("本地总集数 %d 超过 TMDB 总集数 %d，无法对齐", localTotal, tmdbTotal)
+	}
+
+	// tmdbStarts[i] 为 TMDB 第 i 季之前的累计集数
+	tmdbStarts := make([]int, len(tmdb))
+	for i := 1; i < len(tmdb); i++ {
+		tmdbStarts[i] = tmdbStarts[i-1] + tmdb[i-1].EpisodeCount
+	}
+
+	rules := []MappingRule{}
+	localStart := 0 // 当前本地季之前的累计集数
+	t := 0          // 当前所在的 TMDB 季下标
+	for _, s := range local {
+		ep := 1
+		for ep <= s.EpisodeCount {
+			abs := localStart + ep // 累计集号，从 1 开始
+			for abs > tmdbStarts[t]+tmdb[t].EpisodeCount {
+				t++
+			}
+			// 本段截止到本地季末尾或 TMDB 季末尾（取先到者）
+			end := s.EpisodeCount
+			if tmdbEnd := tmdbStarts[t] + tmdb[t].EpisodeCount - localStart; tmdbEnd < end {
+				end = tmdbEnd
+			}
+
+			offset := localStart - tmdbStarts[t]
+			if tmdb[t].SeasonNumber != s.SeasonNumber || offset != 0 {
+				rules = append(rules, MappingRule{
+					SourceSeason:   s.SeasonNumber,
+					SourceEpisodes: formatEpisodeRange(ep, end),
+					TargetSeason:   tmdb[t].SeasonNumber,
+					Offset:         formatEpisodeOffset(offset),
+				})
+			}
+			ep = end + 1
+		}
+		localStart += s.EpisodeCount
+	}
+	return rules, nil
+}
+
+// regularSeasons 过滤特别篇（S0）并按季号排序
+func regularSeasons(seasons []SeasonEpisodeCount) []SeasonEpisodeCount {
+	result := make([]SeasonEpisodeCount, 0, len(seasons))
+	for _, s := range seasons {
+		if s.SeasonNumber > 0 {
+			result = append(result, s)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SeasonNumber < result[j].SeasonNumber })
+	return result
+}
+
+// formatEpisodeRange 集数范围：单集为 "n"，多集为 "n-m"
+func formatEpisodeRange(start, end int) string {
+	if start == end {
+		return strconv.Itoa(start)
+	}
+	return strconv.Itoa(start) + "-" + strconv.Itoa(end)
+}
+
+// formatEpisodeOffset 集偏移表达式：EP、EP+n、EP-n
+func formatEpisodeOffset(offset int) string {
+	switch {
+	case offset > 0:
+		return "EP+" + strconv.Itoa(offset)
+	case offset < 0:
+		return "EP" + strconv.Itoa(offset)
+	default:
+		return "EP"
+	}
+}
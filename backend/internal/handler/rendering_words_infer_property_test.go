@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// genSeasons 生成从 S1 开始连续编号的季集数
+func genSeasons(t *rapid.T, label string, minSeasons, maxSeasons, maxEpisodes int) []SeasonEpisodeCount {
+	n := rapid.IntRange(minSeasons, maxSeasons).Draw(t, label+"_seasons")
+	seasons := make([]SeasonEpisodeCount, n)
+	for i := range seasons {
+		seasons[i] = SeasonEpisodeCount{
+			SeasonNumber: i + 1,
+			EpisodeCount: rapid.IntRange(1, maxEpisodes).Draw(t, fmt.Sprintf("%s_s%d", label, i+1)),
+		}
+	}
+	return seasons
+}
+
+// splitSeasons 将总集数 total 随机切分为若干本地季
+func splitSeasons(t *rapid.T, total int) []SeasonEpisodeCount {
+	var seasons []SeasonEpisodeCount
+	remaining := total
+	for sn := 1; remaining > 0; sn++ {
+		count := rapid.IntRange(1, remaining).Draw(t, fmt.Sprintf("local_s%d", sn))
+		seasons = append(seasons, SeasonEpisodeCount{SeasonNumber: sn, EpisodeCount: count})
+		remaining -= count
+	}
+	return seasons
+}
+
+// applyMappingRules 按规则映射本地 (季, 集)，无匹配规则时保持不变
+func applyMappingRules(rules []MappingRule, season, episode int) (int, int, int) {
+	matched := 0
+	targetSeason, targetEpisode := season, episode
+	for _, r := range rules {
+		if r.SourceSeason != season {
+			continue
+		}
+		start, end := parseRangeForTest(r.SourceEpisodes)
+		if episode < start || episode > end {
+			continue
+		}
+		matched++
+		offset := 0
+		if r.Offset != "EP" {
+			offset, _ = strconv.Atoi(strings.TrimPrefix(r.Offset, "EP"))
+		}
+		targetSeason, targetEpisode = r.TargetSeason, episode+offset
+	}
+	return targetSeason, targetEpisode, matched
+}
+
+func parseRangeForTest(s string) (int, int) {
+	parts := strings.SplitN(s, "-", 2)
+	start, _ := strconv.Atoi(parts[0])
+	if len(parts) == 1 {
+		return start, start
+	}
+	end, _ := strconv.Atoi(parts[1])
+	return start, end
+}
+
+// Feature: rendering-words, Property: multi-season inference maps every episode to its cumulative TMDB position
+// 对于任意 TMDB 季结构和任意切分方式（本地总集数不超过 TMDB），推断出的规则应把本地每一集
+// 映射到累计集号相同的 TMDB 季集，且每集最多命中一条规则。
+func TestProperty_InferMultiSeasonRulesAlignment(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		tmdbSeasons := genSeasons(t, "tmdb", 1, 6, 30)
+		tmdbTotal := 0
+		for _, s := range tmdbSeasons {
+			tmdbTotal += s.EpisodeCount
+		}
+		localTotal := rapid.IntRange(1, tmdbTotal).Draw(t, "localTotal")
+		localSeasons := splitSeasons(t, localTotal)
+
+		rules, err := InferMultiSeasonRules(localSeasons, tmdbSeasons)
+		if err != nil {
+			t.Fatalf("可对齐的季结构返回错误: %v", err)
+		}
+
+		abs := 0
+		for _, ls := range localSeasons {
+			for ep := 1; ep <= ls.EpisodeCount; ep++ {
+				abs++
+				// 期望的 TMDB 位置
+				wantSeason, wantEpisode, rem := 0, 0, abs
+				for _, ts := range tmdbSeasons {
+					if rem <= ts.EpisodeCount {
+						wantSeason, wantEpisode = ts.SeasonNumber, rem
+						break
+					}
+					rem -= ts.EpisodeCount
+				}
+
+				gotSeason, gotEpisode, matched := applyMappingRules(rules, ls.SeasonNumber, ep)
+				if matched > 1 {
+					t.Fatalf("S%dE%d 命中 %d 条规则", ls.SeasonNumber, ep, matched)
+				}
+				if gotSeason != wantSeason || gotEpisode != wantEpisode {
+					t.Fatalf("S%dE%d 映射为 S%dE%d，期望 S%dE%d（规则 %+v）",
+						ls.SeasonNumber, ep, gotSeason, gotEpisode, wantSeason, wantEpisode, rules)
+				}
+			}
+		}
+	})
+}
+
+// Feature: rendering-words, Property: multi-season inference emits the minimal rule set
+// 推断结果不包含恒等规则，规则数不超过 本地季数 + TMDB 季数 - 1（每个边界最多切出一段）；
+// 本地与 TMDB 季结构完全一致时不需要任何规则。
+func TestProperty_InferMultiSeasonRulesMinimal(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		tmdbSeasons := genSeasons(t, "tmdb", 1, 6, 30)
+		tmdbTotal := 0
+		for _, s := range tmdbSeasons {
+			tmdbTotal += s.EpisodeCount
+		}
+		localSeasons := splitSeasons(t, rapid.IntRange(1, tmdbTotal).Draw(t, "localTotal"))
+
+		rules, err := InferMultiSeasonRules(localSeasons, tmdbSeasons)
+		if err != nil {
+			t.Fatalf("可对齐的季结构返回错误: %v", err)
+		}
+		for _, r := range rules {
+			if r.SourceSeason == r.TargetSeason && r.Offset == "EP" {
+				t.Fatalf("出现恒等规则: %+v", r)
+			}
+		}
+		if max := len(localSeasons) + len(tmdbSeasons) - 1; len(rules) > max {
+			t.Fatalf("规则数 %d 超过上限 %d", len(rules), max)
+		}
+
+		identical, err := InferMultiSeasonRules(tmdbSeasons, tmdbSeasons)
+		if err != nil || len(identical) != 0 {
+			t.Fatalf("季结构一致时期望无规则，实际 %+v, err=%v", identical, err)
+		}
+	})
+}
+
+// Feature: rendering-words, Property: multi-season inference reports unalignable structures
+// 本地总集数超过 TMDB 总集数，或本地季号不连续时，应返回错误而不是生成规则。
+func TestProperty_InferMultiSeasonRulesUnalignable(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		tmdbSeasons := genSeasons(t, "tmdb", 1, 5, 20)
+		tmdbTotal := 0
+		for _, s := range tmdbSeasons {
+			tmdbTotal += s.EpisodeCount
+		}
+
+		overflow := splitSeasons(t, tmdbTotal+rapid.IntRange(1, 20).Draw(t, "extra"))
+		if rules, err := InferMultiSeasonRules(overflow, tmdbSeasons); err == nil {
+			t.Fatalf("本地集数超出时期望错误，实际规则 %+v", rules)
+		}
+
+		gapped := genSeasons(t, "local", 2, 5, 20)
+		gap := rapid.IntRange(1, len(gapped)-1).Draw(t, "gapAt")
+		for i := gap; i < len(gapped); i++ {
+			gapped[i].SeasonNumber++
+		}
+		if rules, err := InferMultiSeasonRules(gapped, tmdbSeasons); err == nil {
+			t.Fatalf("本地季号不连续时期望错误，实际规则 %+v", rules)
+		}
+	})
+}
+
+// TestInferMultiSeasonRules_Example 本地 S1(12)+S2(12)+S3(12)，TMDB S1(24)+S2(12)
+func TestInferMultiSeasonRules_Example(t *testing.T) {
+	rules, err := InferMultiSeasonRules(
+		[]SeasonEpisodeCount{{1, 12}, {2, 12}, {3, 12}},
+		[]SeasonEpisodeCount{{0, 3}, {1, 24}, {2, 12}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []MappingRule{
+		{SourceSeason: 2, SourceEpisodes: "1-12", TargetSeason: 1, Offset: "EP+12"},
+		{SourceSeason: 3, SourceEpisodes: "1-12", TargetSeason: 2, Offset: "EP"},
+	}
+	if fmt.Sprint(rules) != fmt.Sprint(want) {
+		t.Errorf("rules = %+v, want %+v", rules, want)
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+	"embyforge/internal/tmdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewRequest 规则模拟请求：指定已保存的规则集，或直接提交一部剧集的规则
+type PreviewRequest struct {
+	EmbyItemID string                       `json:"emby_item_id" binding:"required"`
+	RuleSetID  uint                         `json:"rule_set_id"`
+	Series     *service.RenderingWordSeries `json:"series"`
+}
+
+// PreviewRenderingWords 对剧集的本地缓存集应用规则，预览每集映射后的 TMDB 季集
+// POST /api/rendering-words/preview
+func (h *RenderingWordsHandler) PreviewRenderingWords(c *gin.Context) {
+	var req PreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+
+	var series service.RenderingWordSeries
+	switch {
+	case req.RuleSetID > 0:
+		var ruleSet model.RenderingWordRuleSet
+		if err := h.DB.First(&ruleSet, req.RuleSetID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "规则集不存在"})
+			return
+		}
+		series = toRuleSetSeries(ruleSet.Snapshot("", ""))
+	case req.Series != nil:
+		series = *req.Series
+		if errs := service.ValidateRenderingWordSeries(0, series); len(errs) > 0 {
+			respondRuleValidationErrors(c, errs)
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请指定规则集或提交规则"})
+		return
+	}
+
+	// 本地集：media_caches 中属于该剧集的 Episode 记录
+	var episodeCaches []model.MediaCache
+	h.DB.Where("type = ? AND series_id = ?", "Episode", req.EmbyItemID).Find(&episodeCaches)
+	if len(episodeCaches) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "缓存中没有该剧集的分集，请先同步缓存"})
+		return
+	}
+	episodes := make([]service.LocalEpisode, len(episodeCaches))
+	for i, ec := range episodeCaches {
+		episodes[i] = service.LocalEpisode{
+			EmbyItemID:    ec.EmbyItemID,
+			Name:          ec.Name,
+			SeasonNumber:  ec.ParentIndexNumber,
+			EpisodeNumber: ec.IndexNumber,
+		}
+	}
+
+	tmdbID := series.TmdbID
+	if tmdbID <= 0 {
+		tmdbID = h.seriesTmdbID(req.EmbyItemID)
+	}
+	if tmdbID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无法确定剧集的 TMDB ID"})
+		return
+	}
+
+	tmdbSeasons := h.loadTmdbSeasonEpisodes(c.Request.Context(), tmdbID, series.Rules, episodes)
+	previews, summary := service.SimulateRenderingWordRules(series.Rules, episodes, tmdbSeasons)
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"tmdb_id":       tmdbID,
+		"episodes":      previews,
+		"summary":       summary,
+		"not_simulated": service.NotSimulatedRuleIndexes(series.Rules),
+	}})
+}
+
+// seriesTmdbID 从剧集缓存的 ProviderIds 中读取 TMDB ID
+func (h *RenderingWordsHandler) seriesTmdbID(embyItemID string) int {
+	var seriesCache model.MediaCache
+	if err := h.DB.Where("emby_item_id = ?", embyItemID).First(&seriesCache).Error; err != nil {
+		return 0
+	}
+	tmdbID, _ := strconv.Atoi(seriesCache.ToMediaItem().ProviderIds["Tmdb"])
+	return tmdbID
+}
+
+// loadTmdbSeasonEpisodes 获取 TMDB 各季集数和规则目标季的每集标题
+// 集数优先取 TMDB 缓存；配置了 TMDB 时为涉及的季请求季详情以获取标题，请求失败只影响标题
+func (h *RenderingWordsHandler) loadTmdbSeasonEpisodes(ctx context.Context, tmdbID int, rules []service.RenderingWordRule, episodes []service.LocalEpisode) map[int]service.TmdbSeasonEpisodes {
+	seasons := make(map[int]service.TmdbSeasonEpisodes)
+
+	var caches []model.TmdbCache
+	h.DB.Where("tmdb_id = ?", tmdbID).Find(&caches)
+	for _, tc := range caches {
+		seasons[tc.SeasonNumber] = service.TmdbSeasonEpisodes{EpisodeCount: tc.EpisodeCount}
+	}
+
+	client, _ := loadTMDBClient(h.DB)
+	if client == nil {
+		return seasons
+	}
+
+	// 缓存中没有该节目时直接请求详情获取季结构
+	if len(seasons) == 0 {
+		details, err := client.GetTVShowDetailsWithContext(ctx, tmdbID)
+		if err != nil {
+			log.Printf("⚠️ 规则预览获取 TMDB 详情失败: %v", err)
+			return seasons
+		}
+		for _, s := range details.Seasons {
+			seasons[s.SeasonNumber] = service.TmdbSeasonEpisodes{EpisodeCount: s.EpisodeCount}
+		}
+	}
+
+	// 只为本地集可能落入的季获取标题：规则的目标季和本地集所在的季
+	wanted := make(map[int]bool)
+	for _, rule := range rules {
+		if rule.Type == service.RuleTypeMapping {
+			wanted[rule.TargetSeason] = true
+		}
+	}
+	for _, ep := range episodes {
+		wanted[ep.SeasonNumber] = true
+	}
+	for sn := range wanted {
+		if _, exists := seasons[sn]; !exists {
+			continue
+		}
+		details, err := client.GetTVSeasonDetailsWithContext(ctx, tmdbID, sn)
+		if err != nil {
+			log.Printf("⚠️ 规则预览获取 TMDB 季详情失败: %v", err)
+			continue
+		}
+		titles := make(map[int]string, len(details.Episodes))
+		for _, ep := range details.Episodes {
+			titles[ep.EpisodeNumber] = strings.TrimSpace(ep.Name)
+		}
+		seasons[sn] = service.TmdbSeasonEpisodes{EpisodeCount: maxEpisodeNumber(details, seasons[sn].EpisodeCount), Titles: titles}
+	}
+	return seasons
+}
+
+// maxEpisodeNumber 季详情中的最大集号，与缓存集数取较大者
+func maxEpisodeNumber(details *tmdb.SeasonDetails, cached int) int {
+	max := cached
+	for _, ep := range details.Episodes {
+		if ep.EpisodeNumber > max {
+			max = ep.EpisodeNumber
+		}
+	}
+	return max
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+)
+
+// TestPreviewRenderingWords 预览接口的请求校验和响应结构
+func TestPreviewRenderingWords(t *testing.T) {
+	r, h := setupRuleSetTest(t)
+	r.POST("/api/rendering-words/preview", h.PreviewRenderingWords)
+
+	now := time.Now()
+	for i, ep := range []struct{ season, episode int }{{2, 1}, {2, 2}, {1, 1}} {
+		h.DB.Create(&model.MediaCache{
+			EmbyItemID: "ep-" + string(rune('a'+i)), Name: "分集", Type: "Episode", SeriesID: "series-1",
+			ParentIndexNumber: ep.season, IndexNumber: ep.episode, CachedAt: now,
+		})
+	}
+	h.DB.Create(&model.TmdbCache{TmdbID: 8801, SeasonNumber: 1, EpisodeCount: 14, CachedAt: now, UpdatedAt: now})
+
+	// 未指定规则集也未提交规则
+	w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/preview", PreviewRequest{EmbyItemID: "series-1"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("缺少规则应返回 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	series := &service.RenderingWordSeries{
+		Name:   "测试剧集",
+		TmdbID: 8801,
+		Rules: []service.RenderingWordRule{
+			{Type: service.RuleTypeOffset, Prefix: "第", Suffix: "话", Offset: "EP-1"},
+			{Type: service.RuleTypeMapping, SourceSeason: 2, SourceEpisodes: "1-2", TargetSeason: 1, Offset: "EP+12"},
+		},
+	}
+
+	// 缓存中没有分集
+	w = doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/preview", PreviewRequest{EmbyItemID: "unknown", Series: series})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("没有分集应返回 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/preview", PreviewRequest{EmbyItemID: "series-1", Series: series})
+	if w.Code != http.StatusOK {
+		t.Fatalf("预览失败 %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			TmdbID       int                      `json:"tmdb_id"`
+			Episodes     []service.EpisodePreview `json:"episodes"`
+			Summary      service.PreviewSummary   `json:"summary"`
+			NotSimulated []int                    `json:"not_simulated"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Data.TmdbID != 8801 {
+		t.Errorf("tmdb_id = %d, want 8801", resp.Data.TmdbID)
+	}
+	if len(resp.Data.Episodes) != 3 || resp.Data.Episodes[0].Source != "S01E01" ||
+		resp.Data.Episodes[1].Target != "S01E13" || resp.Data.Episodes[1].RuleIndex != 1 {
+		t.Errorf("分集预览不正确: %+v", resp.Data.Episodes)
+	}
+	want := service.PreviewSummary{Total: 3, Covered: 2, Uncovered: 1}
+	if resp.Data.Summary != want {
+		t.Errorf("summary = %+v, want %+v", resp.Data.Summary, want)
+	}
+	if len(resp.Data.NotSimulated) != 1 || resp.Data.NotSimulated[0] != 0 {
+		t.Errorf("集偏移规则应列入 not_simulated: %v", resp.Data.NotSimulated)
+	}
+}
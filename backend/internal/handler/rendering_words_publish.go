@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"embyforge/internal/github"
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultPublishMessage 发布渲染词的默认提交说明
+const defaultPublishMessage = "chore: 更新 Symedia 自定义渲染词（EmbyForge 发布）"
+
+// PublishRequest 发布请求
+type PublishRequest struct {
+	Message string `json:"message"` // 提交说明，为空时使用默认说明
+}
+
+// gitHubTarget 渲染词文件在 GitHub 仓库中的位置
+type gitHubTarget struct {
+	Client   *github.Client
+	Owner    string
+	Repo     string
+	FilePath string
+	Config   model.WebhookConfig
+}
+
+// loadGitHubTarget 从第一个 GitHub 来源的 Webhook 配置和 GitHub 令牌配置解析渲染词文件位置，失败时直接写入错误响应
+func (h *RenderingWordsHandler) loadGitHubTarget(c *gin.Context) (*gitHubTarget, bool) {
+	var config model.WebhookConfig
+	if err := h.DB.Where("provider = ?", model.WebhookProviderGitHub).Order("id").First(&config).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请先配置 GitHub 仓库"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 GitHub 配置失败"})
+		return nil, false
+	}
+	filePath := strings.Trim(strings.TrimSpace(config.FilePath), "/")
+	if filePath == "" || strings.ContainsAny(filePath, "*?[,\n") {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "GitHub 配置的文件路径必须是具体文件"})
+		return nil, false
+	}
+	owner, repo, err := github.ParseRepoURL(config.RepoUrl)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return nil, false
+	}
+	client, err := github.NewClient(getSystemConfigValue(h.DB, "github_token"), getSystemConfigValue(h.DB, "github_api_base_url"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return nil, false
+	}
+	return &gitHubTarget{Client: client, Owner: owner, Repo: repo, FilePath: filePath, Config: config}, true
+}
+
+// PublishRenderingWords 将所有启用的规则集生成渲染词，合并到 GitHub 仓库文件的自动生成区域并提交
+// 目标仓库、分支和文件取自 Webhook 配置，提交后由仓库的 Webhook 触发 Symedia 刷新
+// 文件没有标记且已整体导入为规则集时替换整个文件；导入后文件又被修改则拒绝发布，避免覆盖或重复规则
+// POST /api/rendering-words/publish
+func (h *RenderingWordsHandler) PublishRenderingWords(c *gin.Context) {
+	var req PublishRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+			return
+		}
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		message = defaultPublishMessage
+	}
+
+	target, ok := h.loadGitHubTarget(c)
+	if !ok {
+		return
+	}
+	client, owner, repo, filePath, config := target.Client, target.Owner, target.Repo, target.FilePath, target.Config
+
+	generated, errs := h.generateEnabledRuleSets()
+	if len(errs) > 0 {
+		respondRuleValidationErrors(c, errs)
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := client.GetFile(ctx, owner, repo, filePath, config.Branch)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "message": err.Error()})
+		return
+	}
+	existingContent, existingSHA := "", ""
+	if existing != nil {
+		existingContent, existingSHA = existing.Content, existing.SHA
+	}
+
+	base := existingContent
+	if existing != nil && config.ImportedSHA != "" && !service.HasManagedBlock(existingContent) {
+		if existing.SHA != config.ImportedSHA {
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "仓库文件在导入后已被修改，请重新导入或在文件中添加自动生成标记后再发布"})
+			return
+		}
+		base = ""
+	}
+
+	merged, err := service.MergeManagedBlock(base, generated)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": err.Error()})
+		return
+	}
+	if existing != nil && merged == existingContent {
+		c.JSON(http.StatusOK, gin.H{"message": "内容未变化，无需提交", "data": gin.H{
+			"changed":    false,
+			"commit_sha": config.LastPublishedSHA,
+		}})
+		return
+	}
+
+	result, err := client.PutFile(ctx, owner, repo, filePath, github.PutFileRequest{
+		Message: message,
+		Content: merged,
+		SHA:     existingSHA,
+		Branch:  config.Branch,
+	})
+
+	logEntry := model.WebhookLog{
+		Source:   "publish",
+		RepoName: owner + "/" + repo,
+		Branch:   config.Branch,
+		Success:  err == nil,
+	}
+	if err != nil {
+		logEntry.ErrorMsg = err.Error()
+	} else {
+		logEntry.CommitSHA = result.CommitSHA
+	}
+	if dbErr := h.DB.Create(&logEntry).Error; dbErr != nil {
+		log.Printf("⚠️  [Publish] 记录日志失败: %v", dbErr)
+	}
+
+	if err != nil {
+		log.Printf("❌ [Publish] 发布渲染词失败: %v", err)
+		if github.IsConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "文件在读取后已被其他提交修改，请重新发布"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "message": err.Error()})
+		return
+	}
+
+	// 使用 UpdateColumns 跳过加密钩子，避免重复加密已解密的令牌字段
+	now := time.Now()
+	if dbErr := h.DB.Model(&model.WebhookConfig{}).Where("id = ?", config.ID).UpdateColumns(map[string]interface{}{
+		"last_published_sha": result.CommitSHA,
+		"last_published_at":  now,
+		"imported_sha":       "",
+	}).Error; dbErr != nil {
+		log.Printf("⚠️  [Publish] 记录发布提交失败: %v", dbErr)
+	}
+
+	log.Printf("✅ [Publish] 渲染词已发布: repo=%s/%s, branch=%s, file=%s, commit=%s",
+		owner, repo, config.Branch, filePath, result.CommitSHA)
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": gin.H{
+		"changed":      true,
+		"commit_sha":   result.CommitSHA,
+		"commit_url":   result.HTMLURL,
+		"published_at": now,
+	}})
+}
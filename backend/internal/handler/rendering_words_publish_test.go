@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"embyforge/internal/model"
+	"embyforge/internal/service"
+)
+
+// fakeGitHubRepo 单文件的 GitHub contents API 替身
+type fakeGitHubRepo struct {
+	mu      sync.Mutex
+	content string
+	sha     string
+	commits int
+}
+
+func (f *fakeGitHubRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path != "/repos/owner/words/contents/symedia/custom_words.txt" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if f.sha == "" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"type": "file", "sha": f.sha, "encoding": "base64",
+			"content": base64.StdEncoding.EncodeToString([]byte(f.content)),
+		})
+	case http.MethodPut:
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["sha"] != f.sha {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"sha mismatch"}`))
+			return
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(body["content"])
+		f.content = string(decoded)
+		f.commits++
+		f.sha = "blob" + string(rune('0'+f.commits))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": map[string]string{"sha": f.sha},
+			"commit":  map[string]string{"sha": "commit" + string(rune('0'+f.commits))},
+		})
+	}
+}
+
+func TestPublishRenderingWords(t *testing.T) {
+	r, h := setupRuleSetTest(t)
+	r.POST("/api/rendering-words/publish", h.PublishRenderingWords)
+
+	repo := &fakeGitHubRepo{content: "# 手写规则\nAAA => BBB\n", sha: "blob0"}
+	server := httptest.NewServer(repo)
+	defer server.Close()
+
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "github_token").Update("value", "pat")
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "github_api_base_url").Update("value", server.URL)
+	h.DB.Create(&model.WebhookConfig{
+		SymediaUrl: "http://symedia", AuthToken: "t", Secret: "s", WebhookUrl: "/api/webhook/github",
+		RepoUrl: "https://github.com/owner/words", Branch: "main", FilePath: "symedia/custom_words.txt",
+	})
+
+	doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets", RuleSetRequest{
+		Name:  "屏蔽",
+		Rules: []service.RenderingWordRule{{Type: service.RuleTypeBlock, Pattern: "广告"}},
+	})
+
+	w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/publish", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("发布失败: %d %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(repo.content, "# 手写规则\nAAA => BBB\n\n"+service.ManagedBlockBegin+"\n# 屏蔽\n广告\n") {
+		t.Errorf("合并后的文件内容不正确: %q", repo.content)
+	}
+
+	var config model.WebhookConfig
+	h.DB.First(&config)
+	if config.LastPublishedSHA != "commit1" || config.LastPublishedAt == nil || config.AuthToken != "t" {
+		t.Errorf("发布提交记录不正确: sha=%q at=%v token=%q", config.LastPublishedSHA, config.LastPublishedAt, config.AuthToken)
+	}
+	var logEntry model.WebhookLog
+	h.DB.Where("source = ?", "publish").First(&logEntry)
+	if !logEntry.Success || logEntry.CommitSHA != "commit1" || logEntry.RepoName != "owner/words" {
+		t.Errorf("发布日志不正确: %+v", logEntry)
+	}
+
+	// 内容未变化时不产生新提交
+	w = doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/publish", nil)
+	if w.Code != http.StatusOK || repo.commits != 1 {
+		t.Errorf("重复发布不应产生新提交: %d commits=%d", w.Code, repo.commits)
+	}
+
+	// 标记损坏时拒绝发布
+	repo.content = service.ManagedBlockBegin + "\n手写\n"
+	w = doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/publish", nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("标记不完整时期望 409，实际 %d", w.Code)
+	}
+}
+
+// TestImportThenPublishUnmarkedFile 从 GitHub 导入没有标记的文件后发布，整个文件由自动生成区域替换，规则不重复
+func TestImportThenPublishUnmarkedFile(t *testing.T) {
+	r, h := setupRuleSetTest(t)
+	r.POST("/api/rendering-words/rule-sets/import", h.ImportRuleSets)
+	r.POST("/api/rendering-words/publish", h.PublishRenderingWords)
+
+	repo := &fakeGitHubRepo{content: "# 屏蔽\n广告\n", sha: "blob0"}
+	server := httptest.NewServer(repo)
+	defer server.Close()
+
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "github_token").Update("value", "pat")
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "github_api_base_url").Update("value", server.URL)
+	h.DB.Create(&model.WebhookConfig{
+		SymediaUrl: "http://symedia", AuthToken: "t", Secret: "s", WebhookUrl: "/api/webhook/github",
+		RepoUrl: "https://github.com/owner/words", Branch: "main", FilePath: "symedia/custom_words.txt",
+	})
+
+	if w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets/import?source=github", nil); w.Code != http.StatusOK {
+		t.Fatalf("从 GitHub 导入失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/publish", nil); w.Code != http.StatusOK {
+		t.Fatalf("发布失败: %d %s", w.Code, w.Body.String())
+	}
+	if want := service.ManagedBlockBegin + "\n# 屏蔽\n广告\n" + service.ManagedBlockEnd + "\n"; repo.content != want {
+		t.Errorf("导入的文件应整体替换为自动生成区域: %q", repo.content)
+	}
+	var config model.WebhookConfig
+	h.DB.First(&config)
+	if config.ImportedSHA != "" {
+		t.Errorf("发布后应清除导入记录: %q", config.ImportedSHA)
+	}
+
+	// 导入后文件又被修改时拒绝发布
+	repo.content, repo.sha = "# 屏蔽\n广告\n", "blob-unmarked"
+	doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/rule-sets/import?source=github", nil)
+	repo.content, repo.sha = "# 屏蔽\n广告\n\n# 手写\nAAA => BBB\n", "blob-edited"
+	if w := doRuleSetRequest(r, http.MethodPost, "/api/rendering-words/publish", nil); w.Code != http.StatusConflict {
+		t.Errorf("导入后文件被修改时期望 409，实际 %d", w.Code)
+	}
+	if strings.Count(repo.content, "广告") != 1 {
+		t.Errorf("拒绝发布时不应修改文件: %q", repo.content)
+	}
+}
@@ -35,13 +35,10 @@ func NewScanHandler(db *gorm.DB) *ScanHandler {
 	}
 }
 
-// getTMDBAPIKey 从数据库读取 TMDB API Key
-func (h *ScanHandler) getTMDBAPIKey() (string, error) {
-	var config model.SystemConfig
-	if err := h.DB.Where("key = ?", "tmdb_api_key").First(&config).Error; err != nil {
-		return "", err
-	}
-	return config.Value, nil
+// getTMDBClient 根据系统配置创建 TMDB 客户端
+// 未配置凭据或代理/基础地址无效时返回可直接展示给用户的错误提示
+func (h *ScanHandler) getTMDBClient() (*tmdb.Client, string) {
+	return loadTMDBClient(h.DB)
 }
 
 // getEmbyClient 从数据库获取 Emby 配置并创建客户端
@@ -229,17 +226,15 @@ func (h *ScanHandler) StartEpisodeMappingScan(c *gin.Context) {
 		return
 	}
 
-	tmdbAPIKey, err := h.getTMDBAPIKey()
-	if err != nil || tmdbAPIKey == "" {
+	tmdbClient, msg := h.getTMDBClient()
+	if tmdbClient == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400,
-			"message": "请在系统配置页面配置 TMDB API Key",
+			"message": msg,
 		})
 		return
 	}
 
-	tmdbClient := tmdb.NewClient(tmdbAPIKey)
-
 	ctx, cancel := context.WithTimeout(c.Request.Context(), defaultScanTimeout)
 	defer cancel()
 
@@ -352,22 +347,34 @@ func (h *ScanHandler) AnalyzeEpisodeMapping(c *gin.Context) {
 		return
 	}
 
-	// 获取 TMDB API Key
-	tmdbAPIKey, err := h.getTMDBAPIKey()
-	if err != nil || tmdbAPIKey == "" {
+	// 根据系统配置创建 TMDB 客户端
+	tmdbClient, msg := h.getTMDBClient()
+	if tmdbClient == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400,
-			"message": "请在系统配置页面配置 TMDB API Key",
+			"message": msg,
 		})
 		return
 	}
 
-	tmdbClient := tmdb.NewClient(tmdbAPIKey)
+	// TVDB 为可选参考数据源，配置无效时提示用户而不是静默回退
+	tvdbClient, err := newTVDBClientFromConfig(h.DB)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "TVDB 连接配置无效: " + err.Error(),
+		})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), defaultScanTimeout)
 	defer cancel()
 
-	result, err := h.ScanService.AnalyzeEpisodeMappingFromCacheWithContext(ctx, tmdbClient)
+	result, err := h.ScanService.AnalyzeEpisodeMappingFromCacheWithSources(ctx, service.EpisodeMappingSources{
+		TMDB:          tmdbClient,
+		TVDB:          tvdbClient,
+		DefaultSource: getSystemConfigValue(h.DB, "episode_mapping_reference_source"),
+	})
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			log.Printf("⚠️ 异常映射分析超时")
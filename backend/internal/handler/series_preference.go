@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"embyforge/internal/model"
+	"embyforge/internal/tmdb"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SeriesPreferenceHandler 剧集偏好处理器（固定 TMDB 剧集组等）
+type SeriesPreferenceHandler struct {
+	DB *gorm.DB
+}
+
+// NewSeriesPreferenceHandler 创建剧集偏好处理器
+func NewSeriesPreferenceHandler(db *gorm.DB) *SeriesPreferenceHandler {
+	return &SeriesPreferenceHandler{DB: db}
+}
+
+// PinEpisodeGroupRequest 固定剧集组请求
+type PinEpisodeGroupRequest struct {
+	TmdbID         int    `json:"tmdb_id"`
+	EpisodeGroupID string `json:"episode_group_id" binding:"required"`
+}
+
+// SetReferenceSourceRequest 设置参考数据源请求，为空表示使用全局设置
+type SetReferenceSourceRequest struct {
+	ReferenceSource string `json:"reference_source"`
+}
+
+// ListEpisodeGroups 获取电视节目的 TMDB 剧集组列表
+// GET /api/tmdb/tv/:tmdbId/episode-groups
+func (h *SeriesPreferenceHandler) ListEpisodeGroups(c *gin.Context) {
+	tmdbID, err := strconv.Atoi(c.Param("tmdbId"))
+	if err != nil || tmdbID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "TMDB ID 格式错误"})
+		return
+	}
+
+	client, msg := loadTMDBClient(h.DB)
+	if client == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+
+	groups, err := client.GetTVEpisodeGroupsWithContext(c.Request.Context(), tmdbID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "message": err.Error()})
+		return
+	}
+	if groups == nil {
+		groups = []tmdb.EpisodeGroupSummary{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// GetEpisodeGroup 获取剧集组详情及换算后的季结构
+// GET /api/tmdb/episode-groups/:groupId
+func (h *SeriesPreferenceHandler) GetEpisodeGroup(c *gin.Context) {
+	client, msg := loadTMDBClient(h.DB)
+	if client == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+
+	group, err := client.GetEpisodeGroupWithContext(c.Request.Context(), c.Param("groupId"))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"group":   group,
+		"seasons": group.Seasons(),
+	}})
+}
+
+// ListSeriesPreferences 获取所有剧集偏好
+// GET /api/series-preferences
+func (h *SeriesPreferenceHandler) ListSeriesPreferences(c *gin.Context) {
+	var prefs []model.SeriesPreference
+	if err := h.DB.Order("updated_at DESC").Find(&prefs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询剧集偏好失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": prefs})
+}
+
+// PinEpisodeGroup 为剧集固定 TMDB 剧集组，异常映射分析将按该剧集组对比
+// PUT /api/series-preferences/:embyItemId/episode-group
+func (h *SeriesPreferenceHandler) PinEpisodeGroup(c *gin.Context) {
+	embyItemID := c.Param("embyItemId")
+
+	var req PinEpisodeGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.EpisodeGroupID) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请选择剧集组"})
+		return
+	}
+
+	series, ok := h.findSeriesCache(c, embyItemID)
+	if !ok {
+		return
+	}
+	pref, err := h.findOrInitPreference(embyItemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询剧集偏好失败"})
+		return
+	}
+
+	// TMDB ID 优先取请求参数，其次取已保存的偏好，最后取媒体缓存中的 ProviderIds
+	tmdbID := req.TmdbID
+	if tmdbID <= 0 {
+		tmdbID = pref.TmdbID
+	}
+	if tmdbID <= 0 {
+		tmdbID, _ = strconv.Atoi(series.ToMediaItem().ProviderIds["Tmdb"])
+	}
+	if tmdbID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无法确定剧集的 TMDB ID"})
+		return
+	}
+
+	client, msg := loadTMDBClient(h.DB)
+	if client == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+
+	// 校验剧集组属于该剧集，同时记录名称便于展示
+	groups, err := client.GetTVEpisodeGroupsWithContext(c.Request.Context(), tmdbID)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "message": err.Error()})
+		return
+	}
+	groupID := strings.TrimSpace(req.EpisodeGroupID)
+	var group *tmdb.EpisodeGroupSummary
+	for i := range groups {
+		if groups[i].ID == groupID {
+			group = &groups[i]
+			break
+		}
+	}
+	if group == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "剧集组不属于该剧集 (TMDB ID=" + strconv.Itoa(tmdbID) + ")"})
+		return
+	}
+
+	pref.TmdbID = tmdbID
+	pref.EpisodeGroupID = group.ID
+	pref.EpisodeGroupName = group.Name
+
+	if err := h.DB.Save(pref).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存剧集偏好失败"})
+		return
+	}
+
+	log.Printf("📌 剧集 %s 已固定剧集组: %s (%s)", embyItemID, group.Name, group.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": pref})
+}
+
+// UnpinEpisodeGroup 取消固定剧集组，恢复按默认季结构对比
+// DELETE /api/series-preferences/:embyItemId/episode-group
+func (h *SeriesPreferenceHandler) UnpinEpisodeGroup(c *gin.Context) {
+	result := h.DB.Model(&model.SeriesPreference{}).
+		Where("emby_item_id = ?", c.Param("embyItemId")).
+		Updates(map[string]interface{}{"episode_group_id": "", "episode_group_name": ""})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存剧集偏好失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "剧集偏好不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// SetReferenceSource 设置剧集异常映射分析的参考数据源（tmdb/tvdb），为空时恢复使用全局设置
+// PUT /api/series-preferences/:embyItemId/reference-source
+func (h *SeriesPreferenceHandler) SetReferenceSource(c *gin.Context) {
+	embyItemID := c.Param("embyItemId")
+
+	var req SetReferenceSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	source := strings.ToLower(strings.TrimSpace(req.ReferenceSource))
+	if source != "" && source != model.ReferenceSourceTMDB && source != model.ReferenceSourceTVDB {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参考数据源只能是 tmdb 或 tvdb"})
+		return
+	}
+	if _, ok := h.findSeriesCache(c, embyItemID); !ok {
+		return
+	}
+
+	pref, err := h.findOrInitPreference(embyItemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询剧集偏好失败"})
+		return
+	}
+	pref.ReferenceSource = source
+
+	if err := h.DB.Save(pref).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存剧集偏好失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": pref})
+}
+
+// findSeriesCache 从媒体缓存中查询剧集，不存在时写入 404 响应并返回 false
+// 剧集偏好只能为媒体缓存中已有的剧集创建，避免任意 ID 产生无效记录
+func (h *SeriesPreferenceHandler) findSeriesCache(c *gin.Context, embyItemID string) (*model.MediaCache, bool) {
+	var series model.MediaCache
+	err := h.DB.Where("emby_item_id = ? AND type = ?", embyItemID, "Series").First(&series).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "媒体缓存中不存在该剧集，请先同步媒体库"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询媒体缓存失败"})
+		return nil, false
+	}
+	return &series, true
+}
+
+// findOrInitPreference 查询剧集偏好，不存在时返回未保存的新记录
+func (h *SeriesPreferenceHandler) findOrInitPreference(embyItemID string) (*model.SeriesPreference, error) {
+	var pref model.SeriesPreference
+	err := h.DB.Where("emby_item_id = ?", embyItemID).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.SeriesPreference{EmbyItemID: embyItemID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
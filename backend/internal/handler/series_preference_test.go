@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupSeriesPreferenceTest(t *testing.T) (*gin.Engine, *SeriesPreferenceHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("InitDB 失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	h := NewSeriesPreferenceHandler(db)
+
+	r := gin.New()
+	r.PUT("/api/series-preferences/:embyItemId/episode-group", h.PinEpisodeGroup)
+	r.PUT("/api/series-preferences/:embyItemId/reference-source", h.SetReferenceSource)
+	return r, h
+}
+
+// TestPinEpisodeGroup_ValidatesSeriesAndGroup 固定剧集组时校验剧集存在于媒体缓存且剧集组属于该剧集
+func TestPinEpisodeGroup_ValidatesSeriesAndGroup(t *testing.T) {
+	r, h := setupSeriesPreferenceTest(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/3/tv/9101/episode_groups", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":9101,"results":[{"id":"grp-own","name":"Absolute Order","type":2}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "tmdb_api_key").Update("value", "key")
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "tmdb_base_url").Update("value", server.URL)
+	h.DB.Create(&model.MediaCache{
+		EmbyItemID: "series-1", Name: "测试剧集", Type: "Series",
+		ProviderIDs: `{"Tmdb":"9101"}`, CachedAt: time.Now(),
+	})
+
+	// 媒体缓存中不存在的剧集不能创建偏好
+	w := doRuleSetRequest(r, http.MethodPut, "/api/series-preferences/unknown/episode-group",
+		PinEpisodeGroupRequest{EpisodeGroupID: "grp-own"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("未知剧集应返回 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+	w = doRuleSetRequest(r, http.MethodPut, "/api/series-preferences/unknown/reference-source",
+		SetReferenceSourceRequest{ReferenceSource: "tvdb"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("未知剧集设置参考数据源应返回 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 不属于该剧集的剧集组被拒绝
+	w = doRuleSetRequest(r, http.MethodPut, "/api/series-preferences/series-1/episode-group",
+		PinEpisodeGroupRequest{EpisodeGroupID: "grp-other"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("不属于该剧集的剧集组应返回 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	var count int64
+	h.DB.Model(&model.SeriesPreference{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("校验失败时不应创建剧集偏好，实际 %d 条", count)
+	}
+
+	// 未传 TMDB ID 时从媒体缓存的 ProviderIds 读取
+	w = doRuleSetRequest(r, http.MethodPut, "/api/series-preferences/series-1/episode-group",
+		PinEpisodeGroupRequest{EpisodeGroupID: "grp-own"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("固定剧集组失败 %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data model.SeriesPreference `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.TmdbID != 9101 || resp.Data.EpisodeGroupID != "grp-own" || resp.Data.EpisodeGroupName != "Absolute Order" {
+		t.Fatalf("剧集偏好不正确: %+v", resp.Data)
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"embyforge/internal/middleware"
+	"embyforge/internal/model"
+	"embyforge/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// refreshTokenTTL 刷新令牌有效期，每次刷新后重新计算
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// errSessionInvalid 刷新令牌无效、已轮换、已撤销或已过期
+var errSessionInvalid = errors.New("会话已失效，请重新登录")
+
+// clientUserAgent 读取请求的 User-Agent，超长时截断
+func clientUserAgent(c *gin.Context) string {
+	ua := c.GetHeader("User-Agent")
+	if len(ua) > 500 {
+		ua = ua[:500]
+	}
+	return ua
+}
+
+// revokeUserSessions 撤销用户除 exceptID 外的所有有效会话，exceptID 为 0 时全部撤销，返回撤销数量
+func revokeUserSessions(db *gorm.DB, userID, exceptID uint) (int64, error) {
+	now := time.Now()
+	result := db.Model(&model.UserSession{}).
+		Where("user_id = ? AND id != ? AND revoked_at IS NULL", userID, exceptID).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// issueSession 为用户创建登录会话，返回访问令牌和刷新令牌
+func (h *AuthHandler) issueSession(c *gin.Context, user *model.User) (*LoginResponse, error) {
+	now := time.Now()
+	// 顺带清理该用户已过期或已撤销的旧会话
+	h.DB.Where("user_id = ? AND (expires_at < ? OR revoked_at IS NOT NULL)", user.ID, now).Delete(&model.UserSession{})
+
+	refreshToken, err := util.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	session := model.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: util.HashToken(refreshToken),
+		UserAgent:        clientUserAgent(c),
+		IP:               c.ClientIP(),
+		LastUsedAt:       &now,
+		ExpiresAt:        now.Add(refreshTokenTTL),
+	}
+	if err := h.DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return h.tokenResponse(user, session.ID, refreshToken)
+}
+
+// tokenResponse 为会话签发新的访问令牌并组装响应
+func (h *AuthHandler) tokenResponse(user *model.User, sessionID uint, refreshToken string) (*LoginResponse, error) {
+	token, err := middleware.GenerateSessionToken(user.ID, user.Username, sessionID, h.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResponse{
+		Token:              token,
+		RefreshToken:       refreshToken,
+		ExpiresIn:          int64(middleware.AccessTokenTTL.Seconds()),
+		Username:           user.Username,
+		Role:               user.Role,
+		MustChangePassword: user.MustChangePassword,
+	}, nil
+}
+
+// rotateSession 校验刷新令牌并轮换为新的刷新令牌
+// 已被轮换的旧令牌再次出现说明令牌可能泄露，此时撤销整个会话
+func (h *AuthHandler) rotateSession(c *gin.Context, refreshToken string) (*model.UserSession, string, error) {
+	now := time.Now()
+	hash := util.HashToken(refreshToken)
+
+	var session model.UserSession
+	if err := h.DB.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
+		var reused model.UserSession
+		if h.DB.Where("previous_token_hash = ? AND revoked_at IS NULL", hash).First(&reused).Error == nil {
+			h.DB.Model(&reused).Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+			log.Printf("⚠️  会话 %d 的旧刷新令牌被重复使用，已撤销该会话", reused.ID)
+		}
+		return nil, "", errSessionInvalid
+	}
+	if !session.Active(now) {
+		return nil, "", errSessionInvalid
+	}
+
+	newToken, err := util.RandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	// 条件更新保证同一刷新令牌并发使用时只有一次成功
+	result := h.DB.Model(&model.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  util.HashToken(newToken),
+			"previous_token_hash": hash,
+			"last_used_at":        now,
+			"expires_at":          now.Add(refreshTokenTTL),
+			"ip":                  c.ClientIP(),
+			"user_agent":          clientUserAgent(c),
+			"updated_at":          now,
+		})
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, "", errSessionInvalid
+	}
+	return &session, newToken, nil
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换
+// POST /api/auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+
+	session, refreshToken, err := h.rotateSession(c, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, errSessionInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "刷新令牌失败"})
+		return
+	}
+
+	var user model.User
+	if err := h.DB.First(&user, session.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户不存在或已被删除"})
+		return
+	}
+	resp, err := h.tokenResponse(&user, session.ID, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "令牌生成失败"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout 注销当前会话
+// POST /api/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	now := time.Now()
+	h.DB.Model(&model.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", c.GetUint("sessionID"), c.GetUint("userID")).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已注销"})
+}
+
+// SessionResponse 会话信息响应
+type SessionResponse struct {
+	model.UserSession
+	Current bool `json:"current"` // 是否为发起请求的会话
+}
+
+// ListSessions 获取当前用户的有效会话
+// GET /api/profile/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	var sessions []model.UserSession
+	if err := h.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", c.GetUint("userID"), time.Now()).
+		Order("last_used_at DESC, id DESC").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询会话失败"})
+		return
+	}
+	current := c.GetUint("sessionID")
+	data := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, SessionResponse{UserSession: session, Current: session.ID == current})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// RevokeSession 撤销当前用户的指定会话
+// DELETE /api/profile/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的会话 ID"})
+		return
+	}
+	now := time.Now()
+	result := h.DB.Model(&model.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, c.GetUint("userID")).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "撤销会话失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "会话不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "会话已撤销"})
+}
+
+// RevokeOtherSessions 注销当前用户的所有其他会话
+// POST /api/profile/sessions/revoke-others
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	n, err := revokeUserSessions(h.DB, c.GetUint("userID"), c.GetUint("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "撤销会话失败"})
+		return
+	}
+	log.Printf("🔐 用户 %s 注销了其他 %d 个会话", changedBy(c), n)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已注销其他会话", "data": gin.H{"revoked": n}})
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"embyforge/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupSessionTest 在登录路由基础上注册刷新、注销和会话管理路由
+func setupSessionTest(t *testing.T) (*gin.Engine, *AuthHandler) {
+	t.Helper()
+	r, h := setupAuthTest(t)
+	r.POST("/api/auth/refresh", h.Refresh)
+	protected := r.Group("/api", middleware.JWTAuth(h.JWTSecret, h.DB), middleware.ActiveSession(h.DB), middleware.UserRole(h.DB))
+	protected.POST("/auth/logout", h.Logout)
+	protected.GET("/profile/sessions", h.ListSessions)
+	protected.DELETE("/profile/sessions/:id", h.RevokeSession)
+	protected.POST("/profile/sessions/revoke-others", h.RevokeOtherSessions)
+	return r, h
+}
+
+func sessionLogin(t *testing.T, r *gin.Engine) LoginResponse {
+	t.Helper()
+	w := doRuleSetRequest(r, http.MethodPost, "/api/auth/login", LoginRequest{Username: "admin", Password: "admin"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("登录失败: %d %s", w.Code, w.Body.String())
+	}
+	var resp LoginResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Token == "" || resp.RefreshToken == "" || resp.ExpiresIn <= 0 {
+		t.Fatalf("登录响应缺少令牌: %+v", resp)
+	}
+	return resp
+}
+
+func doSessionRequest(r *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	return doJSONWithToken(r, method, path, token, struct{}{})
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	r, h := setupSessionTest(t)
+	login := sessionLogin(t, r)
+
+	var stored int64
+	h.DB.Table("user_sessions").Where("refresh_token_hash = ?", login.RefreshToken).Count(&stored)
+	if stored != 0 {
+		t.Fatal("刷新令牌不应明文存储")
+	}
+
+	w := doRuleSetRequest(r, http.MethodPost, "/api/auth/refresh", RefreshRequest{RefreshToken: login.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("刷新失败: %d %s", w.Code, w.Body.String())
+	}
+	var refreshed LoginResponse
+	json.Unmarshal(w.Body.Bytes(), &refreshed)
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Fatal("刷新后刷新令牌应轮换")
+	}
+	if w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", refreshed.Token); w.Code != http.StatusOK {
+		t.Fatalf("新访问令牌应可用: %d", w.Code)
+	}
+
+	// 旧刷新令牌被重复使用：拒绝并撤销整个会话
+	w = doRuleSetRequest(r, http.MethodPost, "/api/auth/refresh", RefreshRequest{RefreshToken: login.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("重复使用旧刷新令牌期望 401，实际 %d", w.Code)
+	}
+	w = doRuleSetRequest(r, http.MethodPost, "/api/auth/refresh", RefreshRequest{RefreshToken: refreshed.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("会话撤销后新刷新令牌也应失效，实际 %d", w.Code)
+	}
+	if w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", refreshed.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("会话撤销后访问令牌应立即失效，实际 %d", w.Code)
+	}
+}
+
+func TestSessionRevocation(t *testing.T) {
+	r, _ := setupSessionTest(t)
+	first := sessionLogin(t, r)
+	second := sessionLogin(t, r)
+	third := sessionLogin(t, r)
+
+	w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", first.Token)
+	var list struct {
+		Data []SessionResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Data) != 3 {
+		t.Fatalf("期望 3 个会话，实际 %d", len(list.Data))
+	}
+	var current, secondID uint
+	for _, s := range list.Data {
+		if s.Current {
+			current = s.ID
+		}
+	}
+	for _, s := range list.Data {
+		if s.ID != current && secondID == 0 {
+			secondID = s.ID
+		}
+	}
+	if current == 0 {
+		t.Fatal("会话列表应标记当前会话")
+	}
+
+	// 撤销单个会话
+	if w := doSessionRequest(r, http.MethodDelete, fmt.Sprintf("/api/profile/sessions/%d", secondID), first.Token); w.Code != http.StatusOK {
+		t.Fatalf("撤销会话失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 注销其他所有会话后只有当前会话可用
+	if w := doSessionRequest(r, http.MethodPost, "/api/profile/sessions/revoke-others", first.Token); w.Code != http.StatusOK {
+		t.Fatalf("注销其他会话失败: %d", w.Code)
+	}
+	for _, other := range []LoginResponse{second, third} {
+		if w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", other.Token); w.Code != http.StatusUnauthorized {
+			t.Errorf("其他会话应失效，实际 %d", w.Code)
+		}
+	}
+	if w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", first.Token); w.Code != http.StatusOK {
+		t.Errorf("当前会话应保持有效，实际 %d", w.Code)
+	}
+
+	// 注销当前会话
+	doSessionRequest(r, http.MethodPost, "/api/auth/logout", first.Token)
+	if w := doSessionRequest(r, http.MethodGet, "/api/profile/sessions", first.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("注销后访问令牌应失效，实际 %d", w.Code)
+	}
+	w = doRuleSetRequest(r, http.MethodPost, "/api/auth/refresh", RefreshRequest{RefreshToken: first.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("注销后刷新令牌应失效，实际 %d", w.Code)
+	}
+}
+
+func doJSONWithToken(r *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
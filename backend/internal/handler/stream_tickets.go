@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"embyforge/internal/middleware"
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamPolicy 获取流票据需要的角色和 API 令牌授权范围
+type streamPolicy struct {
+	role  string
+	scope string
+}
+
+// streamPolicies 可签发票据的流，与对应 SSE 路由的授权要求保持一致
+var streamPolicies = map[string]streamPolicy{
+	middleware.StreamCacheSync: {role: model.RoleOperator, scope: model.ScopeSync},
+}
+
+// StreamTicketHandler 流票据处理器
+type StreamTicketHandler struct {
+	Tickets *middleware.StreamTickets
+}
+
+// NewStreamTicketHandler 创建流票据处理器
+func NewStreamTicketHandler(tickets *middleware.StreamTickets) *StreamTicketHandler {
+	return &StreamTicketHandler{Tickets: tickets}
+}
+
+// StreamTicketRequest 获取流票据请求
+type StreamTicketRequest struct {
+	Stream string `json:"stream" binding:"required"`
+}
+
+// IssueStreamTicket 签发一次性流票据，用于建立 SSE 连接
+// POST /api/stream-tickets
+func (h *StreamTicketHandler) IssueStreamTicket(c *gin.Context) {
+	var req StreamTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	policy, ok := streamPolicies[req.Stream]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "未知的流: " + req.Stream})
+		return
+	}
+	if !model.RoleAtLeast(c.GetString("role"), policy.role) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": "权限不足"})
+		return
+	}
+	if granted, isToken := c.Get("apiTokenScopes"); isToken && !slices.Contains(granted.([]string), policy.scope) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": "API 令牌缺少授权范围: " + policy.scope})
+		return
+	}
+
+	ticket, expiresAt, err := h.Tickets.Issue(c.GetUint("userID"), c.GetString("username"), req.Stream, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成流票据失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"ticket":     ticket,
+		"stream":     req.Stream,
+		"expires_at": expiresAt,
+		"expires_in": int(time.Until(expiresAt).Seconds()),
+	}})
+}
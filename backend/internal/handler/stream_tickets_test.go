@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"embyforge/internal/middleware"
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupStreamTicketTest 注册票据签发路由和一个使用票据认证的测试流
+func setupStreamTicketTest(t *testing.T) (*gin.Engine, *AuthHandler, *middleware.StreamTickets) {
+	t.Helper()
+	r, h := setupAuthTest(t)
+	tickets := middleware.NewStreamTickets(middleware.StreamTicketTTL)
+	protected := r.Group("/api", middleware.JWTAuth(h.JWTSecret, h.DB), middleware.ActiveSession(h.DB), middleware.UserRole(h.DB))
+	protected.POST("/stream-tickets", NewStreamTicketHandler(tickets).IssueStreamTicket)
+	r.GET("/api/test/stream", middleware.StreamTicketAuth(tickets, middleware.StreamCacheSync),
+		middleware.UserRole(h.DB), middleware.RequireRole(model.RoleOperator), func(c *gin.Context) {
+			c.String(http.StatusOK, c.GetString("username"))
+		})
+	return r, h, tickets
+}
+
+func issueStreamTicket(r *gin.Engine, token, stream string) *httptest.ResponseRecorder {
+	return doJSONWithToken(r, http.MethodPost, "/api/stream-tickets", token, StreamTicketRequest{Stream: stream})
+}
+
+func openTestStream(r *gin.Engine, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/test/stream?"+query, nil))
+	return w
+}
+
+func TestStreamTickets(t *testing.T) {
+	r, h, tickets := setupStreamTicketTest(t)
+	login := sessionLogin(t, r)
+
+	if w := issueStreamTicket(r, "", middleware.StreamCacheSync); w.Code != http.StatusUnauthorized {
+		t.Errorf("未登录签发票据期望 401，实际 %d", w.Code)
+	}
+	if w := issueStreamTicket(r, login.Token, "unknown"); w.Code != http.StatusBadRequest {
+		t.Errorf("未知流期望 400，实际 %d", w.Code)
+	}
+
+	w := issueStreamTicket(r, login.Token, middleware.StreamCacheSync)
+	if w.Code != http.StatusOK {
+		t.Fatalf("签发票据失败: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Ticket    string `json:"ticket"`
+			ExpiresIn int    `json:"expires_in"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.Ticket == "" || resp.Data.ExpiresIn <= 0 || resp.Data.ExpiresIn > int(middleware.StreamTicketTTL.Seconds()) {
+		t.Fatalf("票据响应无效: %s", w.Body.String())
+	}
+
+	// 访问令牌不能代替票据
+	if w := openTestStream(r, "token="+login.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("query 中的访问令牌期望 401，实际 %d", w.Code)
+	}
+	if w := openTestStream(r, "ticket="+login.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("访问令牌作为票据期望 401，实际 %d", w.Code)
+	}
+
+	// 票据只能使用一次
+	if w := openTestStream(r, "ticket="+resp.Data.Ticket); w.Code != http.StatusOK || w.Body.String() != "admin" {
+		t.Fatalf("使用票据建立连接失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := openTestStream(r, "ticket="+resp.Data.Ticket); w.Code != http.StatusUnauthorized {
+		t.Errorf("重复使用票据期望 401，实际 %d", w.Code)
+	}
+
+	// 票据绑定流，其他流不能兑换，兑换失败后票据也作废
+	now := time.Now()
+	other, _, _ := tickets.Issue(1, "admin", "other-stream", now)
+	if w := openTestStream(r, "ticket="+other); w.Code != http.StatusUnauthorized {
+		t.Errorf("其他流的票据期望 401，实际 %d", w.Code)
+	}
+	if _, ok := tickets.Redeem(other, "other-stream", now); ok {
+		t.Error("兑换失败的票据应作废")
+	}
+
+	// 过期票据不可用
+	expired, _, _ := tickets.Issue(1, "admin", middleware.StreamCacheSync, now.Add(-middleware.StreamTicketTTL-time.Second))
+	if w := openTestStream(r, "ticket="+expired); w.Code != http.StatusUnauthorized {
+		t.Errorf("过期票据期望 401，实际 %d", w.Code)
+	}
+
+	// 签发后降级为 viewer：建立连接时按当前角色拒绝；viewer 也不能签发票据
+	ticket, _, _ := tickets.Issue(1, "admin", middleware.StreamCacheSync, now)
+	h.DB.Model(&model.User{}).Where("id = ?", 1).Update("role", model.RoleViewer)
+	if w := openTestStream(r, "ticket="+ticket); w.Code != http.StatusForbidden {
+		t.Errorf("viewer 建立同步流期望 403，实际 %d", w.Code)
+	}
+	if w := issueStreamTicket(r, login.Token, middleware.StreamCacheSync); w.Code != http.StatusForbidden {
+		t.Errorf("viewer 签发同步流票据期望 403，实际 %d", w.Code)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -201,6 +202,11 @@ func (h *SymediaHandler) SaveConfig(c *gin.Context) {
 		return
 	}
 	
+	before := gin.H{
+		"symedia_url": getSystemConfigValue(h.DB, "symedia_url"),
+		"auth_token":  getSystemConfigValue(h.DB, "symedia_auth_token"),
+	}
+
 	// 保存配置到SystemConfig表
 	// 保存symedia_url
 	symediaUrlConfig := model.SystemConfig{
@@ -230,6 +236,8 @@ func (h *SymediaHandler) SaveConfig(c *gin.Context) {
 		return
 	}
 	
+	recordAudit(h.DB, c, "update", auditTargetSymedia, "global", before,
+		gin.H{"symedia_url": req.SymediaUrl, "auth_token": req.AuthToken})
 	log.Printf("✅ [Symedia] 配置保存成功")
 	
 	// 返回成功响应
@@ -317,6 +325,11 @@ func (h *SymediaHandler) ManualRefresh(c *gin.Context) {
 		return
 	}
 	
+	before := gin.H{
+		"symedia_url": getSystemConfigValue(h.DB, "symedia_url"),
+		"auth_token":  getSystemConfigValue(h.DB, "symedia_auth_token"),
+	}
+
 	// 保存配置到SystemConfig表
 	// 保存symedia_url
 	symediaUrlConfig := model.SystemConfig{
@@ -340,6 +353,9 @@ func (h *SymediaHandler) ManualRefresh(c *gin.Context) {
 		// 不影响主流程，继续执行
 	}
 	
+	recordAudit(h.DB, c, "update", auditTargetSymedia, "global", before,
+		gin.H{"symedia_url": req.SymediaUrl, "auth_token": req.AuthToken})
+
 	// 记录成功日志（结构化）
 	structuredLog := util.FormatManualRefreshLog(
 		req.SymediaUrl,
@@ -397,6 +413,19 @@ func maskUrl(urlStr string) string {
 	return urlStr
 }
 
+// githubConfigAudit GitHub Webhook 配置的审计快照
+func githubConfigAudit(config model.WebhookConfig) gin.H {
+	return gin.H{
+		"symedia_url": config.SymediaUrl,
+		"auth_token":  config.AuthToken,
+		"repo_url":    config.RepoUrl,
+		"branch":      config.Branch,
+		"file_path":   config.FilePath,
+		"secret":      config.Secret,
+		"webhook_url": config.WebhookUrl,
+	}
+}
+
 // SaveGithubConfigOnlyRequest 只保存GitHub配置请求结构（不刷新Webhook URL）
 type SaveGithubConfigOnlyRequest struct {
 	RepoUrl   string `json:"repo_url" binding:"required"`
@@ -488,12 +517,14 @@ func (h *SymediaHandler) SaveGithubConfigOnly(c *gin.Context) {
 	}
 	
 	// 更新配置（保留原有的 WebhookUrl）
+	before := githubConfigAudit(existingConfig)
 	existingConfig.SymediaUrl = symediaUrl
 	existingConfig.AuthToken = authToken
 	existingConfig.RepoUrl = req.RepoUrl
 	existingConfig.Branch = req.Branch
 	existingConfig.FilePath = filePath
 	existingConfig.Secret = req.Secret
+	after := githubConfigAudit(existingConfig) // 保存时加密钩子会改写令牌字段，先记录快照
 	
 	if err := h.DB.Save(&existingConfig).Error; err != nil {
 		log.Printf("❌ [Symedia] 更新GitHub配置失败: %v", err)
@@ -503,6 +534,7 @@ func (h *SymediaHandler) SaveGithubConfigOnly(c *gin.Context) {
 		return
 	}
 	
+	recordAudit(h.DB, c, "update", auditTargetGithubConfig, strconv.Itoa(int(existingConfig.ID)), before, after)
 	log.Printf("✅ [Symedia] GitHub配置保存成功: repo=%s, branch=%s", req.RepoUrl, req.Branch)
 	
 	// 返回成功响应
@@ -601,6 +633,8 @@ func (h *SymediaHandler) SaveGithubConfig(c *gin.Context) {
 		WebhookUrl: webhookUrl,
 	}
 	
+	after := githubConfigAudit(webhookConfig) // 保存时加密钩子会改写令牌字段，先记录快照
+
 	// 查找是否已存在配置
 	var existingConfig model.WebhookConfig
 	err := h.DB.First(&existingConfig).Error
@@ -614,6 +648,7 @@ func (h *SymediaHandler) SaveGithubConfig(c *gin.Context) {
 			})
 			return
 		}
+		recordAudit(h.DB, c, "create", auditTargetGithubConfig, strconv.Itoa(int(webhookConfig.ID)), nil, after)
 		log.Printf("✅ [Symedia] 创建GitHub配置成功: repo=%s, branch=%s", req.RepoUrl, req.Branch)
 	} else if err != nil {
 		// 查询出错
@@ -632,6 +667,7 @@ func (h *SymediaHandler) SaveGithubConfig(c *gin.Context) {
 			})
 			return
 		}
+		recordAudit(h.DB, c, "update", auditTargetGithubConfig, strconv.Itoa(int(existingConfig.ID)), githubConfigAudit(existingConfig), after)
 		log.Printf("✅ [Symedia] 更新GitHub配置成功: repo=%s, branch=%s", req.RepoUrl, req.Branch)
 	}
 	
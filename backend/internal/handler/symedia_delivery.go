@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultDeliveryMaxAttempts 默认最大尝试次数（含首次）
+	defaultDeliveryMaxAttempts = 6
+	// deliveryBaseBackoff 首次重试的等待时间，之后每次翻倍
+	deliveryBaseBackoff = 30 * time.Second
+	// deliveryMaxBackoff 重试等待时间上限
+	deliveryMaxBackoff = time.Hour
+	// deliveryPollInterval 后台检查到期投递的间隔
+	deliveryPollInterval = 15 * time.Second
+	// deliveryBatchSize 每次检查最多处理的投递数
+	deliveryBatchSize = 20
+)
+
+// deliveryBackoff 第 attempts 次尝试失败后到下一次重试的等待时间
+func deliveryBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := deliveryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= deliveryMaxBackoff {
+			return deliveryMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// DeliveryQueue Symedia 刷新投递队列
+// 失败的刷新持久化到 symedia_deliveries 表，重启后继续重试
+type DeliveryQueue struct {
+	DB      *gorm.DB
+	Symedia *SymediaHandler
+
+	mu      sync.Mutex // 串行化投递尝试，避免后台重试与手动重放同时处理同一记录
+	startMu sync.Mutex
+	stopCh  chan struct{}
+}
+
+// NewDeliveryQueue 创建投递队列
+func NewDeliveryQueue(db *gorm.DB, symedia *SymediaHandler) *DeliveryQueue {
+	return &DeliveryQueue{DB: db, Symedia: symedia}
+}
+
+// Start 启动后台重试（非阻塞）
+func (q *DeliveryQueue) Start() {
+	q.startMu.Lock()
+	defer q.startMu.Unlock()
+	if q.stopCh != nil {
+		return
+	}
+	q.stopCh = make(chan struct{})
+	go q.loop(q.stopCh)
+	log.Printf("📮 Symedia 刷新重试队列已启动，检查间隔: %v", deliveryPollInterval)
+}
+
+// Stop 停止后台重试
+func (q *DeliveryQueue) Stop() {
+	q.startMu.Lock()
+	defer q.startMu.Unlock()
+	if q.stopCh == nil {
+		return
+	}
+	close(q.stopCh)
+	q.stopCh = nil
+}
+
+func (q *DeliveryQueue) loop(stopCh chan struct{}) {
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			q.ProcessDue(time.Now())
+		}
+	}
+}
+
+// maxAttempts 读取系统配置的最大尝试次数
+func (q *DeliveryQueue) maxAttempts() int {
+	n, err := strconv.Atoi(getSystemConfigValue(q.DB, "symedia_delivery_max_attempts"))
+	if err != nil || n < 1 {
+		return defaultDeliveryMaxAttempts
+	}
+	return n
+}
+
+// refresh 刷新 Webhook 配置对应的 Symedia，配置未单独设置 Symedia 时使用全局配置
+func (q *DeliveryQueue) refresh(config *model.WebhookConfig) error {
+	symediaUrl, authToken := config.SymediaUrl, config.AuthToken
+	if strings.TrimSpace(symediaUrl) == "" {
+		symediaUrl, authToken = getSystemConfigValue(q.DB, "symedia_url"), getSystemConfigValue(q.DB, "symedia_auth_token")
+	}
+	if strings.TrimSpace(symediaUrl) == "" {
+		return fmt.Errorf("未配置Symedia地址")
+	}
+	return q.Symedia.callSymediaAPI(symediaUrl, authToken)
+}
+
+// Enqueue 记录首次刷新失败的投递，按退避时间等待重试；最大尝试次数为 1 时直接进入死信列表
+func (q *DeliveryQueue) Enqueue(config *model.WebhookConfig, logEntry *model.WebhookLog, cause error) (*model.SymediaDelivery, error) {
+	now := time.Now()
+	delivery := model.SymediaDelivery{
+		WebhookConfigID: config.ID,
+		WebhookLogID:    logEntry.ID,
+		Source:          logEntry.Source,
+		RepoName:        logEntry.RepoName,
+		Branch:          logEntry.Branch,
+		CommitSHA:       logEntry.CommitSHA,
+		Status:          model.DeliveryStatusPending,
+		Attempts:        1,
+		MaxAttempts:     q.maxAttempts(),
+		LastAttemptAt:   &now,
+		LastError:       cause.Error(),
+	}
+	if delivery.Attempts >= delivery.MaxAttempts {
+		delivery.Status = model.DeliveryStatusDead
+	} else {
+		next := now.Add(deliveryBackoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+	if err := q.DB.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ProcessDue 处理所有到期的待重试投递，返回处理数量
+func (q *DeliveryQueue) ProcessDue(now time.Time) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var due []model.SymediaDelivery
+	if err := q.DB.Where("status = ? AND next_attempt_at <= ?", model.DeliveryStatusPending, now).
+		Order("next_attempt_at").Limit(deliveryBatchSize).Find(&due).Error; err != nil {
+		log.Printf("⚠️  [Delivery] 查询待重试投递失败: %v", err)
+		return 0
+	}
+	for i := range due {
+		q.attempt(&due[i], now)
+	}
+	return len(due)
+}
+
+// attempt 执行一次投递尝试并更新状态，调用方需持有 q.mu
+func (q *DeliveryQueue) attempt(delivery *model.SymediaDelivery, now time.Time) {
+	var config model.WebhookConfig
+	err := q.DB.First(&config, delivery.WebhookConfigID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = fmt.Errorf("Webhook配置已删除")
+	} else if err == nil {
+		err = q.refresh(&config)
+	}
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.NextAttemptAt = nil
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		log.Printf("✅ [Delivery] 重试刷新成功: id=%d, repo=%s, 第 %d 次尝试", delivery.ID, delivery.RepoName, delivery.Attempts)
+	case delivery.Attempts >= delivery.MaxAttempts:
+		delivery.Status = model.DeliveryStatusDead
+		delivery.LastError = err.Error()
+		log.Printf("☠️  [Delivery] 刷新重试次数用尽，进入死信列表: id=%d, repo=%s, error=%v", delivery.ID, delivery.RepoName, err)
+	default:
+		delivery.Status = model.DeliveryStatusPending
+		delivery.LastError = err.Error()
+		next := now.Add(deliveryBackoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		log.Printf("🔁 [Delivery] 重试刷新失败: id=%d, 第 %d/%d 次, 下次重试: %s, error=%v",
+			delivery.ID, delivery.Attempts, delivery.MaxAttempts, next.Format("2006-01-02 15:04:05"), err)
+	}
+	if dbErr := q.DB.Save(delivery).Error; dbErr != nil {
+		log.Printf("⚠️  [Delivery] 更新投递状态失败: %v", dbErr)
+	}
+
+	// 投递结束（成功或进入死信列表）时记录到 Webhook 日志
+	if delivery.Status != model.DeliveryStatusPending {
+		logEntry := model.WebhookLog{
+			Source:          "retry",
+			WebhookConfigID: delivery.WebhookConfigID,
+			RepoName:        delivery.RepoName,
+			Branch:          delivery.Branch,
+			CommitSHA:       delivery.CommitSHA,
+			Success:         err == nil,
+			ErrorMsg:        delivery.LastError,
+		}
+		if dbErr := q.DB.Create(&logEntry).Error; dbErr != nil {
+			log.Printf("⚠️  [Delivery] 记录日志失败: %v", dbErr)
+		}
+	}
+}
+
+// Replay 手动重放投递：重新计算尝试次数并立即尝试一次，失败后按退避规则继续重试
+func (q *DeliveryQueue) Replay(id uint) (*model.SymediaDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var delivery model.SymediaDelivery
+	if err := q.DB.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	delivery.Attempts = 0
+	delivery.MaxAttempts = q.maxAttempts()
+	q.attempt(&delivery, time.Now())
+	return &delivery, nil
+}
+
+// ListDeliveries 获取 Symedia 刷新投递列表，默认返回待重试和死信投递
+// 查询参数 status: pending / dead / succeeded
+// GET /api/symedia/deliveries
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	statuses := []string{model.DeliveryStatusPending, model.DeliveryStatusDead}
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case model.DeliveryStatusPending, model.DeliveryStatusDead, model.DeliveryStatusSucceeded:
+			statuses = []string{status}
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的投递状态: " + status})
+			return
+		}
+	}
+
+	var deliveries []model.SymediaDelivery
+	if err := h.DB.Where("status IN ?", statuses).Order("id DESC").Limit(200).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询投递记录失败"})
+		return
+	}
+
+	var pending, dead int64
+	h.DB.Model(&model.SymediaDelivery{}).Where("status = ?", model.DeliveryStatusPending).Count(&pending)
+	h.DB.Model(&model.SymediaDelivery{}).Where("status = ?", model.DeliveryStatusDead).Count(&dead)
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"items":   deliveries,
+		"pending": pending,
+		"dead":    dead,
+	}})
+}
+
+// ReplayDelivery 手动重放投递（通常用于死信列表中的投递）
+// POST /api/symedia/deliveries/:id/replay
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的投递 ID"})
+		return
+	}
+	delivery, err := h.Deliveries.Replay(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "投递记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "重放投递失败"})
+		return
+	}
+	message := "刷新成功"
+	if delivery.Status != model.DeliveryStatusSucceeded {
+		message = "刷新失败: " + delivery.LastError
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": delivery})
+}
+
+// DeleteDelivery 删除投递记录（放弃重试）
+// DELETE /api/symedia/deliveries/:id
+func (h *WebhookHandler) DeleteDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的投递 ID"})
+		return
+	}
+	result := h.DB.Delete(&model.SymediaDelivery{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除投递记录失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "投递记录不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+)
+
+func TestDeliveryBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		7:  32 * time.Minute,
+		8:  time.Hour,
+		50: time.Hour,
+	}
+	for attempts, want := range cases {
+		if got := deliveryBackoff(attempts); got != want {
+			t.Errorf("deliveryBackoff(%d) = %v, 期望 %v", attempts, got, want)
+		}
+	}
+}
+
+func TestDeliveryQueueRetryAndReplay(t *testing.T) {
+	r, h := setupWebhookTest(t)
+	r.GET("/api/symedia/deliveries", h.ListDeliveries)
+	r.POST("/api/symedia/deliveries/:id/replay", h.ReplayDelivery)
+
+	symedia := &fakeSymedia{fail: true}
+	server := httptest.NewServer(symedia)
+	defer server.Close()
+
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "symedia_delivery_max_attempts").Update("value", "3")
+	h.DB.Create(&model.WebhookConfig{
+		SymediaUrl: server.URL, AuthToken: "token", Secret: "secret", RepoUrl: "https://github.com/owner/words",
+		Branch: "main", FilePath: "*", WebhookUrl: webhookPathPrefix + "aaa",
+	})
+
+	body := []byte(`{"ref":"refs/heads/main","repository":{"full_name":"owner/words"},"head_commit":{"id":"abc","added":["a.txt"]}}`)
+	w := doWebhookRequest(r, "/api/webhook/github/aaa", body, map[string]string{
+		"X-Hub-Signature-256": "sha256=" + hmacHex("secret", body),
+	})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("刷新失败时期望 202，实际 %d %s", w.Code, w.Body.String())
+	}
+
+	reload := func() model.SymediaDelivery {
+		var d model.SymediaDelivery
+		h.DB.First(&d)
+		return d
+	}
+	delivery := reload()
+	if delivery.Status != model.DeliveryStatusPending || delivery.Attempts != 1 || delivery.MaxAttempts != 3 ||
+		delivery.NextAttemptAt == nil || delivery.CommitSHA != "abc" || delivery.WebhookLogID == 0 {
+		t.Fatalf("投递记录不正确: %+v", delivery)
+	}
+
+	// 未到重试时间时不处理
+	first := *delivery.NextAttemptAt
+	if n := h.Deliveries.ProcessDue(first.Add(-time.Second)); n != 0 {
+		t.Errorf("未到期的投递不应处理，实际处理 %d 条", n)
+	}
+
+	h.Deliveries.ProcessDue(first)
+	delivery = reload()
+	if delivery.Attempts != 2 || delivery.Status != model.DeliveryStatusPending || !delivery.NextAttemptAt.Equal(first.Add(time.Minute)) {
+		t.Fatalf("第二次尝试后应按退避等待: %+v", delivery)
+	}
+
+	h.Deliveries.ProcessDue(*delivery.NextAttemptAt)
+	delivery = reload()
+	if delivery.Status != model.DeliveryStatusDead || delivery.Attempts != 3 || delivery.NextAttemptAt != nil {
+		t.Fatalf("超过最大尝试次数应进入死信列表: %+v", delivery)
+	}
+	if symedia.calls != 3 {
+		t.Errorf("Symedia 应被调用 3 次，实际 %d", symedia.calls)
+	}
+
+	w = doRuleSetRequest(r, http.MethodGet, "/api/symedia/deliveries?status=dead", nil)
+	var list struct {
+		Data struct {
+			Items []model.SymediaDelivery `json:"items"`
+			Dead  int64                   `json:"dead"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list.Data.Items) != 1 || list.Data.Dead != 1 {
+		t.Errorf("死信列表不正确: %d %s", w.Code, w.Body.String())
+	}
+
+	symedia.fail = false
+	w = doRuleSetRequest(r, http.MethodPost, "/api/symedia/deliveries/1/replay", nil)
+	delivery = reload()
+	if w.Code != http.StatusOK || delivery.Status != model.DeliveryStatusSucceeded || delivery.DeliveredAt == nil {
+		t.Fatalf("手动重放应刷新成功: %d %s %+v", w.Code, w.Body.String(), delivery)
+	}
+
+	var retryLogs []model.WebhookLog
+	h.DB.Where("source = ?", "retry").Order("id").Find(&retryLogs)
+	if len(retryLogs) != 2 || retryLogs[0].Success || !retryLogs[1].Success {
+		t.Errorf("死信和重放结果应记录到 Webhook 日志: %+v", retryLogs)
+	}
+
+	if w := doRuleSetRequest(r, http.MethodGet, "/api/symedia/deliveries?status=unknown", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("无效状态期望 400，实际 %d", w.Code)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"embyforge/internal/model"
+	"embyforge/internal/util"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,8 +26,28 @@ type UpdateConfigRequest struct {
 	Value string `json:"value"`
 }
 
+// SystemConfigResponse 系统配置响应，敏感配置项只返回脱敏后的值
+type SystemConfigResponse struct {
+	model.SystemConfig
+	Secret bool `json:"secret"` // 是否为敏感配置项
+}
+
+// isSecretConfigKey 判断配置项是否为敏感配置（加密存储的密钥和 JWT 密钥）
+func isSecretConfigKey(key string) bool {
+	return model.IsEncryptedConfigKey(key) || key == "jwt_secret"
+}
+
+// toSystemConfigResponse 敏感配置项的值脱敏后返回，明文不离开服务端
+func toSystemConfigResponse(config model.SystemConfig) SystemConfigResponse {
+	resp := SystemConfigResponse{SystemConfig: config, Secret: isSecretConfigKey(config.Key)}
+	if resp.Secret {
+		resp.Value = util.MaskToken(config.Value)
+	}
+	return resp
+}
+
 // GetAllConfigs GET /api/system-config
-// 返回所有系统配置项
+// 返回所有系统配置项，敏感配置项的值脱敏
 func (h *SystemConfigHandler) GetAllConfigs(c *gin.Context) {
 	var configs []model.SystemConfig
 	if err := h.DB.Order("id ASC").Find(&configs).Error; err != nil {
@@ -34,11 +55,15 @@ func (h *SystemConfigHandler) GetAllConfigs(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询配置失败"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": configs})
+	data := make([]SystemConfigResponse, 0, len(configs))
+	for _, config := range configs {
+		data = append(data, toSystemConfigResponse(config))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
 // UpdateConfig PUT /api/system-config/:key
-// 更新指定 key 的配置值
+// 更新指定 key 的配置值；敏感配置项提交空值或未修改的脱敏值时保留原值
 func (h *SystemConfigHandler) UpdateConfig(c *gin.Context) {
 	key := c.Param("key")
 	if key == "" {
@@ -63,6 +88,12 @@ func (h *SystemConfigHandler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
+	if isSecretConfigKey(key) && (req.Value == "" || req.Value == util.MaskToken(config.Value)) {
+		c.JSON(http.StatusOK, gin.H{"data": toSystemConfigResponse(config), "message": "配置未修改"})
+		return
+	}
+
+	before := auditConfigValue(key, config.Value)
 	config.Value = req.Value
 	if err := h.DB.Save(&config).Error; err != nil {
 		log.Printf("❌ 更新配置项失败: %v", err)
@@ -70,6 +101,9 @@ func (h *SystemConfigHandler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
+	recordAudit(h.DB, c, "update", auditTargetSystemConfig, key, before, auditConfigValue(key, req.Value))
 	log.Printf("⚙️ 系统配置已更新: %s", key)
-	c.JSON(http.StatusOK, gin.H{"data": config, "message": "配置更新成功"})
+	// BeforeSave 钩子已把加密配置项的值替换为密文，响应使用提交的值脱敏
+	config.Value = req.Value
+	c.JSON(http.StatusOK, gin.H{"data": toSystemConfigResponse(config), "message": "配置更新成功"})
 }
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"embyforge/internal/model"
+	"embyforge/internal/util"
 
 	"github.com/gin-gonic/gin"
 	"pgregory.net/rapid"
@@ -174,3 +175,42 @@ func TestUpdateConfig_NotFound(t *testing.T) {
 		t.Fatalf("不存在的 key 应返回 404，实际返回 %d", w.Code)
 	}
 }
+
+// TestSystemConfig_MasksSecrets 敏感配置项只返回脱敏值，提交空值或脱敏值时保留原值
+func TestSystemConfig_MasksSecrets(t *testing.T) {
+	r, h := setupSystemConfigTest(t)
+	const token = "ghp_0123456789abcdefghij"
+
+	put := func(value string) (int, SystemConfigResponse) {
+		body, _ := json.Marshal(UpdateConfigRequest{Value: value})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/system-config/github_token", bytes.NewReader(body)))
+		var resp struct {
+			Data SystemConfigResponse `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	code, resp := put(token)
+	if code != http.StatusOK || resp.Value != util.MaskToken(token) || !resp.Secret {
+		t.Fatalf("更新响应应返回脱敏值: %d %+v", code, resp)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/system-config", nil))
+	if bytes.Contains(w.Body.Bytes(), []byte(token)) {
+		t.Fatalf("查询配置不应返回明文密钥: %s", w.Body.String())
+	}
+
+	for _, value := range []string{"", util.MaskToken(token)} {
+		if code, _ := put(value); code != http.StatusOK {
+			t.Errorf("提交 %q 期望 200，实际 %d", value, code)
+		}
+		var config model.SystemConfig
+		h.DB.Where("key = ?", "github_token").First(&config)
+		if config.Value != token {
+			t.Errorf("提交 %q 后应保留原值，实际 %q", value, config.Value)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+
+	"embyforge/internal/model"
+	"embyforge/internal/tmdb"
+
+	"gorm.io/gorm"
+)
+
+// errTMDBNotConfigured 未配置任何 TMDB 凭据
+var errTMDBNotConfigured = errors.New("未配置 TMDB API Key 或读访问令牌")
+
+// getSystemConfigValue 读取单个系统配置值，不存在时返回空字符串
+func getSystemConfigValue(db *gorm.DB, key string) string {
+	var config model.SystemConfig
+	if err := db.Where("key = ?", key).First(&config).Error; err != nil {
+		return ""
+	}
+	return config.Value
+}
+
+// newTMDBClientFromConfig 根据系统配置创建 TMDB 客户端
+// 读取 tmdb_api_key / tmdb_access_token / tmdb_base_url / tmdb_proxy_url 以及语言偏好
+func newTMDBClientFromConfig(db *gorm.DB) (*tmdb.Client, error) {
+	opts := tmdb.Options{
+		APIKey:      getSystemConfigValue(db, "tmdb_api_key"),
+		AccessToken: getSystemConfigValue(db, "tmdb_access_token"),
+		BaseURL:     getSystemConfigValue(db, "tmdb_base_url"),
+		ProxyURL:    getSystemConfigValue(db, "tmdb_proxy_url"),
+
+		Languages:      getTMDBLanguages(db),
+		ImageLanguages: tmdb.ParseLanguages(getSystemConfigValue(db, "tmdb_image_languages")),
+	}
+	if opts.APIKey == "" && opts.AccessToken == "" {
+		return nil, errTMDBNotConfigured
+	}
+	return tmdb.NewClientWithOptions(opts)
+}
+
+// getTMDBLanguages 读取 TMDB 元数据语言优先级配置
+func getTMDBLanguages(db *gorm.DB) []string {
+	return tmdb.ParseLanguages(getSystemConfigValue(db, "tmdb_languages"))
+}
+
+// loadTMDBClient 根据系统配置创建 TMDB 客户端，失败时返回面向用户的错误信息
+func loadTMDBClient(db *gorm.DB) (*tmdb.Client, string) {
+	client, err := newTMDBClientFromConfig(db)
+	if err == errTMDBNotConfigured {
+		return nil, "请在系统配置页面配置 TMDB API Key"
+	}
+	if err != nil {
+		return nil, "TMDB 连接配置无效: " + err.Error()
+	}
+	return client, ""
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"embyforge/internal/model"
+	"embyforge/internal/tmdb"
+	"embyforge/internal/tvdb"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -112,6 +114,14 @@ func (h *TmdbCacheHandler) GetTmdbCacheStatus(c *gin.Context) {
 	})
 }
 
+// tmdbCacheAudit TMDB 缓存条目的审计快照
+func tmdbCacheAudit(cache model.TmdbCache) gin.H {
+	return gin.H{
+		"tmdb_id": cache.TmdbID, "season_number": cache.SeasonNumber,
+		"season_name": cache.SeasonName, "episode_count": cache.EpisodeCount,
+	}
+}
+
 func (h *TmdbCacheHandler) UpdateTmdbCache(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -131,6 +141,7 @@ func (h *TmdbCacheHandler) UpdateTmdbCache(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "not found"})
 		return
 	}
+	before := tmdbCacheAudit(cache)
 	cache.EpisodeCount = req.EpisodeCount
 	cache.SeasonName = req.SeasonName
 	cache.UpdatedAt = time.Now()
@@ -138,6 +149,7 @@ func (h *TmdbCacheHandler) UpdateTmdbCache(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "update failed"})
 		return
 	}
+	recordAudit(h.DB, c, "update", auditTargetTmdbCache, strconv.Itoa(int(cache.ID)), before, tmdbCacheAudit(cache))
 	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": cache})
 }
 
@@ -147,11 +159,16 @@ func (h *TmdbCacheHandler) DeleteTmdbCache(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "invalid ID"})
 		return
 	}
-	result := h.DB.Delete(&model.TmdbCache{}, id)
-	if result.RowsAffected == 0 {
+	var cache model.TmdbCache
+	if err := h.DB.First(&cache, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "not found"})
 		return
 	}
+	if err := h.DB.Delete(&cache).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "delete failed"})
+		return
+	}
+	recordAudit(h.DB, c, "delete", auditTargetTmdbCache, strconv.Itoa(id), tmdbCacheAudit(cache), nil)
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
 }
 
@@ -162,6 +179,10 @@ func (h *TmdbCacheHandler) DeleteTmdbCacheByShow(c *gin.Context) {
 		return
 	}
 	result := h.DB.Where("tmdb_id = ?", tmdbID).Delete(&model.TmdbCache{})
+	tmdb.ClearSharedCache()
+	tvdb.ClearSharedCache()
+	recordAudit(h.DB, c, "delete_show", auditTargetTmdbCache, "tmdb:"+strconv.Itoa(tmdbID),
+		gin.H{"tmdb_id": tmdbID, "records": result.RowsAffected}, nil)
 	log.Printf("deleted %d TMDB cache records for TMDB ID=%d", result.RowsAffected, tmdbID)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "ok", "data": gin.H{"deleted_count": result.RowsAffected},
@@ -175,6 +196,9 @@ func (h *TmdbCacheHandler) ClearTmdbCache(c *gin.Context) {
 		return
 	}
 	h.DB.Exec("DELETE FROM sqlite_sequence WHERE name='tmdb_caches'")
+	tmdb.ClearSharedCache()
+	tvdb.ClearSharedCache()
+	recordAudit(h.DB, c, "clear", auditTargetTmdbCache, "*", gin.H{"records": result.RowsAffected}, nil)
 	log.Printf("cleared TMDB cache, deleted %d records", result.RowsAffected)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "ok", "data": gin.H{"deleted_count": result.RowsAffected},
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"embyforge/internal/tvdb"
+
+	"gorm.io/gorm"
+)
+
+// newTVDBClientFromConfig 根据系统配置创建 TVDB 客户端
+// 读取 tvdb_api_key / tvdb_pin / tvdb_base_url，未配置 API Key 时返回 nil
+func newTVDBClientFromConfig(db *gorm.DB) (*tvdb.Client, error) {
+	apiKey := getSystemConfigValue(db, "tvdb_api_key")
+	if apiKey == "" {
+		return nil, nil
+	}
+	return tvdb.NewClientWithOptions(tvdb.Options{
+		APIKey:  apiKey,
+		PIN:     getSystemConfigValue(db, "tvdb_pin"),
+		BaseURL: getSystemConfigValue(db, "tvdb_base_url"),
+	})
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// totpIssuer 验证器应用中显示的发行方
+	totpIssuer = "EmbyForge"
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// normalizeRecoveryCode 统一恢复码格式：忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// generateRecoveryCodes 为用户重新生成恢复码（旧恢复码全部作废），返回明文恢复码
+func generateRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := util.RandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&model.UserRecoveryCode{UserID: userID, CodeHash: util.HashToken(raw)}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+// verifySecondFactor 校验 TOTP 验证码或恢复码
+// 验证码通过后记录时间步防止重复使用，恢复码使用后作废
+func verifySecondFactor(db *gorm.DB, user *model.User, code string, now time.Time) bool {
+	if code = strings.TrimSpace(code); code == "" || user.TOTPSecret == "" {
+		return false
+	}
+	secret, err := util.Decrypt(user.TOTPSecret)
+	if err != nil {
+		log.Printf("⚠️  解密用户 %s 的两步验证密钥失败: %v", user.Username, err)
+		return false
+	}
+	if step, ok := util.VerifyTOTP(secret, code, now, user.TOTPLastStep); ok {
+		// 条件更新保证同一验证码并发提交时只有一次成功
+		result := db.Model(&model.User{}).Where("id = ? AND totp_last_step < ?", user.ID, step).
+			UpdateColumn("totp_last_step", step)
+		if result.Error == nil && result.RowsAffected == 1 {
+			user.TOTPLastStep = step
+			return true
+		}
+		return false
+	}
+
+	result := db.Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, util.HashToken(normalizeRecoveryCode(code))).
+		UpdateColumn("used_at", now)
+	if result.Error == nil && result.RowsAffected == 1 {
+		log.Printf("🔐 用户 %s 使用恢复码完成两步验证", user.Username)
+		return true
+	}
+	return false
+}
+
+// currentUser 读取当前登录用户，失败时直接写入错误响应
+func (h *ProfileHandler) currentUser(c *gin.Context) (*model.User, bool) {
+	var user model.User
+	if err := h.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "用户不存在"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// GetTwoFactorStatus 获取当前用户的两步验证状态
+// GET /api/profile/2fa
+func (h *ProfileHandler) GetTwoFactorStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	var remaining int64
+	h.DB.Model(&model.UserRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&remaining)
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"enabled":                  user.TOTPEnabled,
+		"recovery_codes_remaining": remaining,
+	}})
+}
+
+// TwoFactorPasswordRequest 需要确认密码的两步验证请求
+type TwoFactorPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// TwoFactorCodeRequest 需要验证码的两步验证请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest 关闭两步验证请求
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // 验证码或恢复码
+}
+
+// SetupTwoFactor 生成待确认的 TOTP 密钥和扫码地址，需调用 EnableTwoFactor 确认后才生效
+// POST /api/profile/2fa/setup
+func (h *ProfileHandler) SetupTwoFactor(c *gin.Context) {
+	var req TwoFactorPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "两步验证已启用，请先关闭后再重新设置"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "密码错误"})
+		return
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成密钥失败"})
+		return
+	}
+	encrypted, err := util.Encrypt(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "密钥加密失败"})
+		return
+	}
+	if err := h.DB.Model(user).Updates(map[string]interface{}{"totp_secret": encrypted, "totp_last_step": 0}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存密钥失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"secret":           secret,
+		"provisioning_uri": util.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	}})
+}
+
+// EnableTwoFactor 使用验证器生成的验证码确认并启用两步验证，返回一次性恢复码
+// POST /api/profile/2fa/enable
+func (h *ProfileHandler) EnableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "两步验证已启用"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请先生成两步验证密钥"})
+		return
+	}
+	secret, err := util.Decrypt(user.TOTPSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "密钥解密失败"})
+		return
+	}
+	step, valid := util.VerifyTOTP(secret, req.Code, time.Now(), user.TOTPLastStep)
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "验证码错误"})
+		return
+	}
+
+	var codes []string
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "启用两步验证失败"})
+		return
+	}
+
+	log.Printf("🔐 用户 %s 启用了两步验证", user.Username)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "两步验证已启用，请妥善保存恢复码", "data": gin.H{"recovery_codes": codes}})
+}
+
+// DisableTwoFactor 关闭两步验证，需要密码和验证码（或恢复码）
+// POST /api/profile/2fa/disable
+func (h *ProfileHandler) DisableTwoFactor(c *gin.Context) {
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "两步验证未启用"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "密码错误"})
+		return
+	}
+	if !verifySecondFactor(h.DB, user, req.Code, time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "验证码错误"})
+		return
+	}
+	if err := resetTwoFactor(h.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "关闭两步验证失败"})
+		return
+	}
+
+	log.Printf("🔐 用户 %s 关闭了两步验证", user.Username)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "两步验证已关闭"})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+// POST /api/profile/2fa/recovery-codes
+func (h *ProfileHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "两步验证未启用"})
+		return
+	}
+	if !verifySecondFactor(h.DB, user, req.Code, time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "验证码错误"})
+		return
+	}
+
+	var codes []string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = generateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成恢复码失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "恢复码已重新生成", "data": gin.H{"recovery_codes": codes}})
+}
+
+// resetTwoFactor 关闭用户的两步验证并删除密钥和恢复码
+func resetTwoFactor(db *gorm.DB, userID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_enabled": false, "totp_secret": "", "totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserRecoveryCode{}).Error
+	})
+}
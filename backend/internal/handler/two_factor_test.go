@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"embyforge/internal/middleware"
+	"embyforge/internal/model"
+	"embyforge/internal/util"
+)
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 附录 B 测试向量（SHA1，取后 6 位）
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924"} {
+		got, err := util.TOTPCode(secret, util.TOTPStep(time.Unix(unix, 0)))
+		if err != nil || got != want {
+			t.Errorf("T=%d: 期望 %s，实际 %s (%v)", unix, want, got, err)
+		}
+	}
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	r, h := setupSessionTest(t)
+	profile := &ProfileHandler{DB: h.DB}
+	users := NewUserHandler(h.DB)
+	protected := r.Group("/api", middleware.JWTAuth(h.JWTSecret, h.DB), middleware.ActiveSession(h.DB), middleware.UserRole(h.DB))
+	protected.POST("/profile/2fa/setup", profile.SetupTwoFactor)
+	protected.POST("/profile/2fa/enable", profile.EnableTwoFactor)
+	protected.POST("/users/:id/reset-2fa", users.ResetTwoFactor)
+
+	session := sessionLogin(t, r).Token
+	if w := doJSONWithToken(r, http.MethodPost, "/api/profile/2fa/setup", session, TwoFactorPasswordRequest{Password: "wrong"}); w.Code != http.StatusBadRequest {
+		t.Errorf("密码错误期望 400，实际 %d", w.Code)
+	}
+	w := doJSONWithToken(r, http.MethodPost, "/api/profile/2fa/setup", session, TwoFactorPasswordRequest{Password: "admin"})
+	var setup struct {
+		Data struct {
+			Secret          string `json:"secret"`
+			ProvisioningURI string `json:"provisioning_uri"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &setup)
+	if setup.Data.Secret == "" || setup.Data.ProvisioningURI == "" {
+		t.Fatalf("设置响应不完整: %s", w.Body.String())
+	}
+	var user model.User
+	h.DB.First(&user, 1)
+	if user.TOTPEnabled || user.TOTPSecret == setup.Data.Secret {
+		t.Fatal("确认前不应启用，密钥应加密存储")
+	}
+
+	// 确认前登录不需要验证码
+	sessionLogin(t, r)
+
+	now := time.Now()
+	code, _ := util.TOTPCode(setup.Data.Secret, util.TOTPStep(now))
+	w = doJSONWithToken(r, http.MethodPost, "/api/profile/2fa/enable", session, TwoFactorCodeRequest{Code: code})
+	var enabled struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &enabled)
+	if w.Code != http.StatusOK || len(enabled.Data.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("启用两步验证失败: %d %s", w.Code, w.Body.String())
+	}
+
+	login := func(totp string) (int, bool) {
+		w := doRuleSetRequest(r, http.MethodPost, "/api/auth/login", LoginRequest{Username: "admin", Password: "admin", TOTPCode: totp})
+		var resp struct {
+			Token             string `json:"token"`
+			TwoFactorRequired bool   `json:"two_factor_required"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.TwoFactorRequired
+	}
+
+	if status, required := login(""); status != http.StatusUnauthorized || !required {
+		t.Errorf("缺少验证码期望 401 且要求两步验证，实际 %d %v", status, required)
+	}
+	if status, _ := login(code); status != http.StatusUnauthorized {
+		t.Errorf("已使用的验证码不能重复使用，实际 %d", status)
+	}
+	next, _ := util.TOTPCode(setup.Data.Secret, util.TOTPStep(now)+1)
+	if status, _ := login(next); status != http.StatusOK {
+		t.Errorf("有效验证码登录期望 200，实际 %d", status)
+	}
+
+	recovery := enabled.Data.RecoveryCodes[0]
+	if status, _ := login(recovery); status != http.StatusOK {
+		t.Errorf("恢复码登录期望 200，实际 %d", status)
+	}
+	if status, _ := login(recovery); status != http.StatusUnauthorized {
+		t.Errorf("恢复码只能使用一次，实际 %d", status)
+	}
+
+	// 管理员重置后恢复为仅密码登录
+	if w := doJSONWithToken(r, http.MethodPost, "/api/users/1/reset-2fa", session, nil); w.Code != http.StatusOK {
+		t.Fatalf("重置两步验证失败: %d", w.Code)
+	}
+	if status, _ := login(""); status != http.StatusOK {
+		t.Errorf("重置后仅密码登录期望 200，实际 %d", status)
+	}
+	var remaining int64
+	h.DB.Model(&model.UserRecoveryCode{}).Where("user_id = ?", 1).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("重置后应删除恢复码，剩余 %d", remaining)
+	}
+}
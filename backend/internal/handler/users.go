@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// UserHandler 用户管理处理器（仅管理员）
+type UserHandler struct {
+	DB *gorm.DB
+}
+
+// NewUserHandler 创建用户管理处理器
+func NewUserHandler(db *gorm.DB) *UserHandler {
+	return &UserHandler{DB: db}
+}
+
+// UserResponse 用户信息响应
+type UserResponse struct {
+	ID                 uint      `json:"id"`
+	Username           string    `json:"username"`
+	Avatar             string    `json:"avatar"`
+	Role               string    `json:"role"`
+	TOTPEnabled        bool      `json:"totp_enabled"`
+	MustChangePassword bool      `json:"must_change_password"`
+	OIDCSubject        string    `json:"oidc_subject"` // 绑定的单点登录身份（issuer|sub），未绑定时为空
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func toUserResponse(user model.User) UserResponse {
+	return UserResponse{
+		ID:                 user.ID,
+		Username:           user.Username,
+		Avatar:             user.Avatar,
+		Role:               user.Role,
+		TOTPEnabled:        user.TOTPEnabled,
+		MustChangePassword: user.MustChangePassword,
+		OIDCSubject:        user.OIDCSubject,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+	}
+}
+
+// userAudit 用户的审计快照
+func userAudit(user model.User) gin.H {
+	return gin.H{"username": user.Username, "role": user.Role, "totp_enabled": user.TOTPEnabled, "oidc_subject": user.OIDCSubject}
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=2,max=50"`
+	Password string `json:"password" binding:"required,min=4"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// UpdateUserRequest 更新用户请求，字段为空表示不修改
+// OIDCSubject 为单点登录身份（issuer|sub，可在被拒绝的单点登录日志中查看），传空字符串解除绑定
+type UpdateUserRequest struct {
+	Role        string  `json:"role"`
+	Password    string  `json:"password" binding:"omitempty,min=4"`
+	OIDCSubject *string `json:"oidc_subject"`
+}
+
+// errLastAdmin 操作会导致系统中没有管理员
+var errLastAdmin = errors.New("至少需要保留一个管理员")
+
+// ensureOtherAdmin 确认除指定用户外还有其他管理员
+func ensureOtherAdmin(tx *gorm.DB, userID uint) error {
+	var count int64
+	if err := tx.Model(&model.User{}).Where("role = ? AND id != ?", model.RoleAdmin, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errLastAdmin
+	}
+	return nil
+}
+
+// findUser 按路由参数查询用户，失败时直接写入错误响应
+func (h *UserHandler) findUser(c *gin.Context) (*model.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的用户 ID"})
+		return nil, false
+	}
+	var user model.User
+	if err := h.DB.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "用户不存在"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询用户失败"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// ListUsers 获取所有用户
+// GET /api/users
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var users []model.User
+	if err := h.DB.Order("id").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询用户失败"})
+		return
+	}
+	data := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		data = append(data, toUserResponse(user))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// CreateUser 创建用户
+// POST /api/users
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	if !model.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的角色: " + req.Role})
+		return
+	}
+
+	var count int64
+	h.DB.Model(&model.User{}).Where("username = ?", req.Username).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "用户名已被占用"})
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "密码加密失败"})
+		return
+	}
+	user := model.User{Username: req.Username, Password: string(hashed), Role: req.Role}
+	if err := h.DB.Create(&user).Error; err != nil {
+		log.Printf("❌ 创建用户失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建用户失败"})
+		return
+	}
+
+	recordAudit(h.DB, c, "create", auditTargetUser, strconv.Itoa(int(user.ID)), nil, userAudit(user))
+	log.Printf("👤 %s 创建了用户 %s（%s）", changedBy(c), user.Username, user.Role)
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": toUserResponse(user)})
+}
+
+// UpdateUser 修改用户角色、重置密码或绑定单点登录身份，不能移除最后一个管理员
+// PUT /api/users/:id
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	if req.Role != "" && !model.IsValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的角色: " + req.Role})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Role != "" && req.Role != user.Role {
+		updates["role"] = req.Role
+	}
+	if req.Password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "密码加密失败"})
+			return
+		}
+		updates["password"] = string(hashed)
+	}
+	if req.OIDCSubject != nil && strings.TrimSpace(*req.OIDCSubject) != user.OIDCSubject {
+		subject := strings.TrimSpace(*req.OIDCSubject)
+		if subject != "" {
+			if !strings.Contains(subject, "|") {
+				c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "单点登录身份格式应为 issuer|sub"})
+				return
+			}
+			var count int64
+			h.DB.Model(&model.User{}).Where("oidc_subject = ? AND id != ?", subject, user.ID).Count(&count)
+			if count > 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "该单点登录身份已绑定其他用户"})
+				return
+			}
+		}
+		updates["oidc_subject"] = subject
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "ok", "data": toUserResponse(*user)})
+		return
+	}
+
+	before := userAudit(*user)
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if _, demote := updates["role"]; demote && user.Role == model.RoleAdmin {
+			if err := ensureOtherAdmin(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return err
+		}
+		// 管理员重置密码后注销该用户的所有会话
+		if _, reset := updates["password"]; reset {
+			_, err := revokeUserSessions(tx, user.ID, 0)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errLastAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新用户失败"})
+		return
+	}
+
+	h.DB.First(user, user.ID)
+	after := userAudit(*user)
+	if _, reset := updates["password"]; reset {
+		after["password_reset"] = true
+	}
+	recordAudit(h.DB, c, "update", auditTargetUser, strconv.Itoa(int(user.ID)), before, after)
+	log.Printf("👤 %s 更新了用户 %s（角色: %s）", changedBy(c), user.Username, user.Role)
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": toUserResponse(*user)})
+}
+
+// DeleteUser 删除用户，不能删除自己或最后一个管理员
+// DELETE /api/users/:id
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	if currentID, _ := c.Get("userID"); currentID == user.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "不能删除当前登录的用户"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if user.Role == model.RoleAdmin {
+			if err := ensureOtherAdmin(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.UserSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.APIToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, user.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, errLastAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除用户失败"})
+		return
+	}
+
+	recordAudit(h.DB, c, "delete", auditTargetUser, strconv.Itoa(int(user.ID)), userAudit(*user), nil)
+	log.Printf("👤 %s 删除了用户 %s", changedBy(c), user.Username)
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// ResetTwoFactor 重置用户的两步验证（用户丢失验证器和恢复码时由管理员操作）
+// POST /api/users/:id/reset-2fa
+func (h *UserHandler) ResetTwoFactor(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	if err := resetTwoFactor(h.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "重置两步验证失败"})
+		return
+	}
+	recordAudit(h.DB, c, "reset_2fa", auditTargetUser, strconv.Itoa(int(user.ID)),
+		gin.H{"totp_enabled": user.TOTPEnabled}, gin.H{"totp_enabled": false})
+	log.Printf("🔐 %s 重置了用户 %s 的两步验证", changedBy(c), user.Username)
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
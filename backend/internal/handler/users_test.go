@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupUserTest(t *testing.T) (*gin.Engine, *UserHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	h := NewUserHandler(db)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		c.Set("username", "admin")
+		c.Next()
+	})
+	r.GET("/api/users", h.ListUsers)
+	r.POST("/api/users", h.CreateUser)
+	r.PUT("/api/users/:id", h.UpdateUser)
+	r.DELETE("/api/users/:id", h.DeleteUser)
+	return r, h
+}
+
+func TestUserManagement(t *testing.T) {
+	r, h := setupUserTest(t)
+
+	w := doRuleSetRequest(r, http.MethodPost, "/api/users", CreateUserRequest{Username: "ops", Password: "secret", Role: "root"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("无效角色期望 400，实际 %d", w.Code)
+	}
+	w = doRuleSetRequest(r, http.MethodPost, "/api/users", CreateUserRequest{Username: "admin", Password: "secret", Role: model.RoleViewer})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("重复用户名期望 400，实际 %d", w.Code)
+	}
+
+	w = doRuleSetRequest(r, http.MethodPost, "/api/users", CreateUserRequest{Username: "ops", Password: "secret", Role: model.RoleOperator})
+	if w.Code != http.StatusOK {
+		t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data UserResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.Role != model.RoleOperator || created.Data.ID == 0 {
+		t.Errorf("创建结果不正确: %+v", created.Data)
+	}
+
+	// 唯一的管理员不能降级或删除
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/users/1", UpdateUserRequest{Role: model.RoleViewer}); w.Code != http.StatusBadRequest {
+		t.Errorf("降级最后一个管理员期望 400，实际 %d", w.Code)
+	}
+	if w := doRuleSetRequest(r, http.MethodDelete, "/api/users/1", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("删除当前用户期望 400，实际 %d", w.Code)
+	}
+
+	// 提升为管理员后，原管理员可以降级
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/users/2", UpdateUserRequest{Role: model.RoleAdmin}); w.Code != http.StatusOK {
+		t.Fatalf("提升角色失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/users/1", UpdateUserRequest{Role: model.RoleOperator}); w.Code != http.StatusOK {
+		t.Errorf("存在其他管理员时应允许降级: %d %s", w.Code, w.Body.String())
+	}
+	if w := doRuleSetRequest(r, http.MethodDelete, "/api/users/2", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("删除最后一个管理员期望 400，实际 %d", w.Code)
+	}
+
+	var user model.User
+	h.DB.First(&user, 1)
+	if user.Role != model.RoleOperator {
+		t.Errorf("角色未更新: %s", user.Role)
+	}
+
+	// 管理员显式绑定单点登录身份，同一身份不能绑定多个用户
+	subject := "https://idp.example.com|u-1"
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/users/2", UpdateUserRequest{OIDCSubject: &subject}); w.Code != http.StatusOK {
+		t.Fatalf("绑定单点登录身份失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/users/1", UpdateUserRequest{OIDCSubject: &subject}); w.Code != http.StatusBadRequest {
+		t.Errorf("重复绑定单点登录身份期望 400，实际 %d", w.Code)
+	}
+	invalid := "u-1"
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/users/1", UpdateUserRequest{OIDCSubject: &invalid}); w.Code != http.StatusBadRequest {
+		t.Errorf("无效的单点登录身份期望 400，实际 %d", w.Code)
+	}
+	unlink := ""
+	if w := doRuleSetRequest(r, http.MethodPut, "/api/users/2", UpdateUserRequest{OIDCSubject: &unlink}); w.Code != http.StatusOK {
+		t.Errorf("解除绑定失败: %d %s", w.Code, w.Body.String())
+	}
+	h.DB.First(&user, 2)
+	if user.OIDCSubject != "" {
+		t.Errorf("解除绑定后 subject 应为空: %s", user.OIDCSubject)
+	}
+}
@@ -3,13 +3,17 @@ package handler
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -22,6 +26,9 @@ import (
 type WebhookHandler struct {
 	DB             *gorm.DB
 	SymediaHandler *SymediaHandler
+	Deliveries     *DeliveryQueue // 刷新失败后的重试队列
+
+	inflight sync.Map // 正在处理的投递（配置ID/投递ID），拒绝并发到达的重复投递
 }
 
 // NewWebhookHandler 创建Webhook处理器
@@ -29,20 +36,85 @@ func NewWebhookHandler(db *gorm.DB, symediaHandler *SymediaHandler) *WebhookHand
 	return &WebhookHandler{
 		DB:             db,
 		SymediaHandler: symediaHandler,
+		Deliveries:     NewDeliveryQueue(db, symediaHandler),
 	}
 }
 
-// GitHubPushEvent GitHub推送事件结构
+// webhookPathPrefix 生成的Webhook URL前缀，其后为配置的路由标识
+const webhookPathPrefix = "/api/webhook/github/"
+
+// GitHubPushEvent GitHub推送事件结构（Gitea 推送格式相同，GitLab 推送事件转换为此结构）
 type GitHubPushEvent struct {
-	Ref        string `json:"ref"` // 分支引用，格式：refs/heads/main
+	Ref        string `json:"ref"`   // 分支引用，格式：refs/heads/main
+	After      string `json:"after"` // 推送后的分支头提交SHA
 	Repository struct {
 		FullName string `json:"full_name"` // 仓库全名，格式：owner/repo
 	} `json:"repository"`
-	HeadCommit struct {
-		ID       string   `json:"id"`       // 提交SHA
-		Modified []string `json:"modified"` // 修改的文件列表
-		Added    []string `json:"added"`    // 新增的文件列表
-	} `json:"head_commit"`
+	HeadCommit PushCommit   `json:"head_commit"`
+	Commits    []PushCommit `json:"commits"` // 本次推送的所有提交
+}
+
+// PushCommit 推送事件中的提交
+type PushCommit struct {
+	ID       string   `json:"id"`       // 提交SHA
+	Modified []string `json:"modified"` // 修改的文件列表
+	Added    []string `json:"added"`    // 新增的文件列表
+	Removed  []string `json:"removed"`  // 删除的文件列表
+}
+
+// gitLabPushEvent GitLab推送事件结构
+type gitLabPushEvent struct {
+	Ref     string `json:"ref"`
+	After   string `json:"after"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"` // 项目全名，格式：group/project
+	} `json:"project"`
+	Commits []PushCommit `json:"commits"`
+}
+
+// pushEventHeaders 各推送来源标识事件类型的请求头，以及推送事件对应的值
+var pushEventHeaders = map[string][2]string{
+	model.WebhookProviderGitHub: {"X-GitHub-Event", "push"},
+	model.WebhookProviderGitea:  {"X-Gitea-Event", "push"},
+	model.WebhookProviderGitLab: {"X-Gitlab-Event", "Push Hook"},
+}
+
+// isPushEvent 判断请求是否为推送事件，未携带事件类型头时按推送事件处理
+func isPushEvent(c *gin.Context, provider string) bool {
+	header, ok := pushEventHeaders[provider]
+	if !ok {
+		return false
+	}
+	event := c.GetHeader(header[0])
+	return event == "" || event == header[1]
+}
+
+// parsePushEvent 按推送来源解析事件数据
+// Gitea 旧版本和 GitLab 不提供 head_commit，取 commits 中与推送后SHA一致的提交（默认最后一个）
+func parsePushEvent(provider string, payload []byte) (*GitHubPushEvent, error) {
+	var event GitHubPushEvent
+	if provider == model.WebhookProviderGitLab {
+		var gitLabEvent gitLabPushEvent
+		if err := json.Unmarshal(payload, &gitLabEvent); err != nil {
+			return nil, err
+		}
+		event.Ref = gitLabEvent.Ref
+		event.After = gitLabEvent.After
+		event.Repository.FullName = gitLabEvent.Project.PathWithNamespace
+		event.Commits = gitLabEvent.Commits
+	} else if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	if event.HeadCommit.ID == "" && len(event.Commits) > 0 {
+		event.HeadCommit = event.Commits[len(event.Commits)-1]
+		for _, commit := range event.Commits {
+			if commit.ID == event.After {
+				event.HeadCommit = commit
+			}
+		}
+	}
+	return &event, nil
 }
 
 // verifyGitHubSignature 验证GitHub Webhook签名
@@ -67,49 +139,207 @@ func (h *WebhookHandler) verifyGitHubSignature(secret string, payload []byte, si
 	return hmac.Equal([]byte(signature), []byte(expectedSignature))
 }
 
+// verifyGiteaSignature 验证Gitea Webhook签名
+// X-Gitea-Signature 为不带前缀的十六进制 HMAC SHA256
+func (h *WebhookHandler) verifyGiteaSignature(secret string, payload []byte, signature string) bool {
+	if signature == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	expectedSignature := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(strings.ToLower(signature)), []byte(expectedSignature))
+}
+
+// verifyGitLabToken 验证GitLab Webhook令牌
+// GitLab 不对请求体签名，X-Gitlab-Token 直接携带配置的密钥
+func (h *WebhookHandler) verifyGitLabToken(secret, token string) bool {
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// verifyWebhookRequest 按配置的推送来源验证请求
+func (h *WebhookHandler) verifyWebhookRequest(c *gin.Context, config *model.WebhookConfig, payload []byte) bool {
+	switch config.Provider {
+	case model.WebhookProviderGitea:
+		return h.verifyGiteaSignature(config.Secret, payload, c.GetHeader("X-Gitea-Signature"))
+	case model.WebhookProviderGitLab:
+		return h.verifyGitLabToken(config.Secret, c.GetHeader("X-Gitlab-Token"))
+	default:
+		return h.verifyGitHubSignature(config.Secret, payload, c.GetHeader("X-Hub-Signature-256"))
+	}
+}
+
+// findWebhookConfig 根据路由参数查找Webhook配置，失败时直接写入错误响应
+// 带参数的路由匹配配置生成的Webhook URL；不带参数的旧路由仅在只有一个配置时可用
+func (h *WebhookHandler) findWebhookConfig(c *gin.Context) (*model.WebhookConfig, bool) {
+	var configs []model.WebhookConfig
+	query := h.DB.Order("id")
+	if id := c.Param("id"); id != "" {
+		query = query.Where("webhook_url = ?", webhookPathPrefix+id)
+	}
+	if err := query.Limit(2).Find(&configs).Error; err != nil {
+		log.Printf("❌ [Webhook] 查询配置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "查询配置失败，请稍后重试",
+		})
+		return nil, false
+	}
+
+	switch {
+	case len(configs) == 0:
+		log.Printf("❌ [Webhook] 未找到Webhook配置: path=%s", c.Request.URL.Path)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "未配置Webhook",
+		})
+		return nil, false
+	case len(configs) > 1:
+		log.Printf("❌ [Webhook] 存在多个Webhook配置，无法确定目标配置: path=%s", c.Request.URL.Path)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "存在多个Webhook配置，请使用配置对应的Webhook URL",
+		})
+		return nil, false
+	}
+	return &configs[0], true
+}
+
+// changedFiles 返回推送中所有提交修改、新增和删除的文件（去重，保持出现顺序）
+// 事件不含 commits 时使用 head_commit
+func changedFiles(event *GitHubPushEvent) []string {
+	commits := event.Commits
+	if len(commits) == 0 {
+		commits = []PushCommit{event.HeadCommit}
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, commit := range commits {
+		for _, list := range [][]string{commit.Modified, commit.Added, commit.Removed} {
+			for _, file := range list {
+				if !seen[file] {
+					seen[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// parseFilePatterns 解析监听路径配置，多个路径用逗号或换行分隔
+// 返回 nil 表示监听所有文件（为空或包含 "*"）
+func parseFilePatterns(filePath string) []string {
+	var patterns []string
+	for _, p := range strings.FieldsFunc(filePath, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		p = strings.TrimPrefix(strings.TrimSpace(p), "/")
+		if p == "" {
+			continue
+		}
+		if p == "*" || p == "**" {
+			return nil
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// matchFilePattern 判断文件路径是否匹配监听路径
+// 支持 path.Match 通配符，"**" 匹配任意层级目录，以 "/" 结尾的路径匹配该目录下的所有文件；
+// 与 gitignore 一致，不含 "/" 的路径（如 custom_words.txt）匹配任意目录下的同名文件
+func matchFilePattern(pattern, file string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+func matchPathSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchPathSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+	return matchPathSegments(pattern[1:], segments[1:])
+}
+
+// encodeMatchedFiles 将匹配的文件列表编码为 JSON 存入日志
+func encodeMatchedFiles(files []string) string {
+	if len(files) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(files)
+	return string(data)
+}
+
 // shouldTriggerRefresh 判断是否应该触发配置刷新
+// 检查推送中所有提交的修改、新增和删除文件，返回匹配监听路径的文件
 // 参数:
-//   - event: GitHub推送事件
+//   - event: 推送事件
 //   - config: Webhook配置
 // 返回:
+//   - []string: 匹配的文件列表
 //   - bool: 是否应该触发刷新
-func (h *WebhookHandler) shouldTriggerRefresh(event *GitHubPushEvent, config *model.WebhookConfig) bool {
+func (h *WebhookHandler) shouldTriggerRefresh(event *GitHubPushEvent, config *model.WebhookConfig) ([]string, bool) {
 	// 检查分支是否匹配
 	branch := strings.TrimPrefix(event.Ref, "refs/heads/")
 	if branch != config.Branch {
 		log.Printf("ℹ️  [Webhook] 分支不匹配: 期望=%s, 实际=%s", config.Branch, branch)
-		return false
+		return nil, false
 	}
 	
-	// 如果配置的文件路径为空或为"*"，则监听所有文件变化
-	if config.FilePath == "" || config.FilePath == "*" {
-		// 合并修改和新增的文件列表
-		allFiles := append(event.HeadCommit.Modified, event.HeadCommit.Added...)
-		if len(allFiles) > 0 {
-			log.Printf("✅ [Webhook] 监听所有文件，检测到 %d 个文件变更", len(allFiles))
-			return true
-		}
+	allFiles := changedFiles(event)
+	if len(allFiles) == 0 {
 		log.Printf("ℹ️  [Webhook] 未检测到文件变更")
-		return false
+		return nil, false
 	}
 	
-	// 检查特定文件路径是否匹配
-	// 合并修改和新增的文件列表
-	allFiles := append(event.HeadCommit.Modified, event.HeadCommit.Added...)
+	// 如果配置的文件路径为空或为"*"，则监听所有文件变化
+	patterns := parseFilePatterns(config.FilePath)
+	if patterns == nil {
+		log.Printf("✅ [Webhook] 监听所有文件，检测到 %d 个文件变更", len(allFiles))
+		return allFiles, true
+	}
 	
+	var matched []string
 	for _, file := range allFiles {
-		if strings.Contains(file, config.FilePath) {
-			log.Printf("✅ [Webhook] 文件路径匹配: %s 包含 %s", file, config.FilePath)
-			return true
+		for _, pattern := range patterns {
+			if matchFilePattern(pattern, file) {
+				matched = append(matched, file)
+				break
+			}
 		}
 	}
+	if len(matched) > 0 {
+		log.Printf("✅ [Webhook] 文件路径匹配: 监听路径=%s, 匹配文件=%v", config.FilePath, matched)
+		return matched, true
+	}
 	
 	log.Printf("ℹ️  [Webhook] 文件路径不匹配: 监听路径=%s, 变更文件=%v", config.FilePath, allFiles)
-	return false
+	return nil, false
 }
 
-// HandleGitHubWebhook 处理GitHub Webhook推送事件
-// POST /api/webhook/github
+// HandleGitHubWebhook 处理GitHub / Gitea / GitLab Webhook推送事件
+// 按路由参数匹配配置，使用配置的推送来源验证请求并刷新配置对应的Symedia
+// POST /api/webhook/github/:id
 func (h *WebhookHandler) HandleGitHubWebhook(c *gin.Context) {
 	// 读取请求体
 	payload, err := io.ReadAll(c.Request.Body)
@@ -152,38 +382,50 @@ func (h *WebhookHandler) HandleGitHubWebhook(c *gin.Context) {
 		jsonPayload = payload
 	}
 	
-	// 获取签名头
-	signature := c.GetHeader("X-Hub-Signature-256")
-	
 	// 查询Webhook配置
-	var config model.WebhookConfig
-	if err := h.DB.First(&config).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			log.Printf("❌ [Webhook] 未找到Webhook配置")
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "未配置Webhook",
-			})
-			return
-		}
-		log.Printf("❌ [Webhook] 查询配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "查询配置失败，请稍后重试",
-		})
+	config, ok := h.findWebhookConfig(c)
+	if !ok {
 		return
 	}
 	
 	// 验证签名（使用原始 payload，不是解码后的）
-	if !h.verifyGitHubSignature(config.Secret, payload, signature) {
-		log.Printf("⚠️  [Webhook] GitHub签名验证失败")
+	if !h.verifyWebhookRequest(c, config, payload) {
+		log.Printf("⚠️  [Webhook] %s签名验证失败: config=%d", config.Provider, config.ID)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "签名验证失败",
 		})
 		return
 	}
 	
-	// 解析GitHub推送事件
-	var event GitHubPushEvent
-	if err := json.Unmarshal(jsonPayload, &event); err != nil {
+	// 拒绝重复投递：正在处理中，或在判定窗口内已处理过
+	deliveryID := webhookDeliveryID(c, config.Provider)
+	if deliveryID != "" {
+		key := fmt.Sprintf("%d/%s", config.ID, deliveryID)
+		if _, loaded := h.inflight.LoadOrStore(key, struct{}{}); loaded || h.isDuplicateDelivery(config.ID, deliveryID, time.Now()) {
+			if !loaded {
+				h.inflight.Delete(key)
+			}
+			log.Printf("⚠️  [Webhook] 重复投递，已拒绝: config=%d, delivery=%s", config.ID, deliveryID)
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "重复的投递",
+			})
+			return
+		}
+		defer h.inflight.Delete(key)
+	}
+	
+	// 非推送事件（如 ping）只确认接收
+	if !isPushEvent(c, config.Provider) {
+		log.Printf("ℹ️  [Webhook] 非推送事件，跳过: config=%d", config.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "事件已接收，但不触发刷新",
+		})
+		return
+	}
+	
+	// 解析推送事件
+	event, err := parsePushEvent(config.Provider, jsonPayload)
+	if err != nil {
 		log.Printf("❌ [Webhook] 无法解析事件: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "无法解析事件数据",
@@ -192,7 +434,8 @@ func (h *WebhookHandler) HandleGitHubWebhook(c *gin.Context) {
 	}
 	
 	// 检查是否应该触发刷新
-	if !h.shouldTriggerRefresh(&event, &config) {
+	matchedFiles, trigger := h.shouldTriggerRefresh(event, config)
+	if !trigger {
 		log.Printf("ℹ️  [Webhook] 事件不匹配监听条件，跳过")
 		c.JSON(http.StatusOK, gin.H{
 			"message": "事件已接收，但不触发刷新",
@@ -208,27 +451,30 @@ func (h *WebhookHandler) HandleGitHubWebhook(c *gin.Context) {
 		shortCommitSHA = commitSHA[:7]
 	}
 	
-	// 合并变更文件列表
-	changedFiles := append(event.HeadCommit.Modified, event.HeadCommit.Added...)
+	// 推送中所有提交的变更文件列表
+	allChangedFiles := changedFiles(event)
 	
-	log.Printf("🔄 [Webhook] GitHub推送触发配置刷新: repo=%s, branch=%s, commit=%s", 
-		event.Repository.FullName, branch, shortCommitSHA)
+	log.Printf("🔄 [Webhook] %s推送触发配置刷新: config=%d, repo=%s, branch=%s, commit=%s", 
+		config.Provider, config.ID, event.Repository.FullName, branch, shortCommitSHA)
 	
 	// 记录开始时间
 	startTime := time.Now()
 	
-	// 调用Symedia API
-	err = h.SymediaHandler.callSymediaAPI(config.SymediaUrl, config.AuthToken)
+	// 调用配置对应的Symedia API
+	err = h.Deliveries.refresh(config)
 	
 	// 计算耗时
 	duration := time.Since(startTime).Milliseconds()
 	
 	// 记录日志到WebhookLog表
 	logEntry := model.WebhookLog{
-		Source:    "github",
+		Source:    config.Provider,
+		WebhookConfigID: config.ID,
+		DeliveryID: deliveryID,
 		RepoName:  event.Repository.FullName,
 		Branch:    branch,
 		CommitSHA: commitSHA,
+		MatchedFiles: encodeMatchedFiles(matchedFiles),
 		Success:   err == nil,
 		ErrorMsg:  "",
 	}
@@ -253,17 +499,26 @@ func (h *WebhookHandler) HandleGitHubWebhook(c *gin.Context) {
 		event.Repository.FullName,
 		branch,
 		commitSHA,
-		changedFiles,
+		allChangedFiles,
 		result,
 		duration,
 		errorMsg,
 	)
 	
-	// 返回响应
+	// 返回响应，刷新失败时加入重试队列
 	if err != nil {
 		log.Printf("❌ [Webhook] 配置刷新失败: %s", structuredLog)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "配置刷新失败，请稍后重试",
+		delivery, qErr := h.Deliveries.Enqueue(config, &logEntry, err)
+		if qErr != nil {
+			log.Printf("⚠️  [Webhook] 加入重试队列失败: %v", qErr)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "配置刷新失败，请稍后重试",
+			})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":     "配置刷新失败，已加入重试队列",
+			"delivery_id": delivery.ID,
 		})
 		return
 	}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/util"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WebhookConfigRequest 创建/更新 Webhook 配置请求
+type WebhookConfigRequest struct {
+	Name       string `json:"name"`
+	Provider   string `json:"provider"`    // github / gitea / gitlab，为空时为 github
+	SymediaUrl string `json:"symedia_url"` // 为空时刷新全局配置的 Symedia
+	AuthToken  string `json:"auth_token"`  // 更新时为空表示保持不变
+	RepoUrl    string `json:"repo_url" binding:"required"`
+	Branch     string `json:"branch" binding:"required"`
+	FilePath   string `json:"file_path"` // 为空或 "*" 表示监听所有文件，支持通配符和逗号分隔的多个路径
+	Secret     string `json:"secret"`    // 创建时必填，更新时为空表示保持不变
+}
+
+// WebhookConfigResponse Webhook 配置响应，令牌和密钥脱敏返回
+type WebhookConfigResponse struct {
+	ID               uint       `json:"id"`
+	Name             string     `json:"name"`
+	Provider         string     `json:"provider"`
+	SymediaUrl       string     `json:"symedia_url"`
+	AuthToken        string     `json:"auth_token"`
+	RepoUrl          string     `json:"repo_url"`
+	Branch           string     `json:"branch"`
+	FilePath         string     `json:"file_path"`
+	Secret           string     `json:"secret"`
+	WebhookUrl       string     `json:"webhook_url"`
+	LastPublishedSHA string     `json:"last_published_sha"`
+	LastPublishedAt  *time.Time `json:"last_published_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func toWebhookConfigResponse(config model.WebhookConfig) WebhookConfigResponse {
+	return WebhookConfigResponse{
+		ID:               config.ID,
+		Name:             config.Name,
+		Provider:         config.Provider,
+		SymediaUrl:       config.SymediaUrl,
+		AuthToken:        util.MaskToken(config.AuthToken),
+		RepoUrl:          config.RepoUrl,
+		Branch:           config.Branch,
+		FilePath:         config.FilePath,
+		Secret:           util.MaskToken(config.Secret),
+		WebhookUrl:       config.WebhookUrl,
+		LastPublishedSHA: config.LastPublishedSHA,
+		LastPublishedAt:  config.LastPublishedAt,
+		CreatedAt:        config.CreatedAt,
+		UpdatedAt:        config.UpdatedAt,
+	}
+}
+
+// applyWebhookConfigRequest 校验请求并写入配置，返回错误信息
+func applyWebhookConfigRequest(config *model.WebhookConfig, req WebhookConfigRequest) string {
+	provider := strings.TrimSpace(req.Provider)
+	if provider == "" {
+		provider = model.WebhookProviderGitHub
+	}
+	if _, ok := pushEventHeaders[provider]; !ok {
+		return "不支持的推送来源: " + provider
+	}
+	if !isValidURL(req.RepoUrl) {
+		return "仓库URL格式无效，必须是有效的HTTP/HTTPS URL"
+	}
+	if strings.TrimSpace(req.Branch) == "" {
+		return "分支名称不能为空"
+	}
+	symediaUrl := strings.TrimSpace(req.SymediaUrl)
+	if symediaUrl != "" && !isValidURL(symediaUrl) {
+		return "Symedia地址格式无效，必须是有效的HTTP/HTTPS URL"
+	}
+
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" && config.Secret == "" {
+		return "Webhook密钥不能为空"
+	}
+	if secret != "" {
+		config.Secret = secret
+	}
+
+	filePath := strings.TrimSpace(req.FilePath)
+	if filePath == "" {
+		filePath = "*"
+	}
+
+	config.Name = strings.TrimSpace(req.Name)
+	config.Provider = provider
+	config.RepoUrl = strings.TrimSpace(req.RepoUrl)
+	config.Branch = strings.TrimSpace(req.Branch)
+	config.FilePath = filePath
+	config.SymediaUrl = symediaUrl
+	if symediaUrl == "" {
+		config.AuthToken = ""
+	} else if token := strings.TrimSpace(req.AuthToken); token != "" {
+		config.AuthToken = token
+	}
+	return ""
+}
+
+// findWebhookConfigByID 按 ID 查询配置，失败时直接写入错误响应
+func (h *WebhookHandler) findWebhookConfigByID(c *gin.Context) (*model.WebhookConfig, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的配置 ID"})
+		return nil, false
+	}
+	var config model.WebhookConfig
+	if err := h.DB.First(&config, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "Webhook 配置不存在"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 Webhook 配置失败"})
+		return nil, false
+	}
+	return &config, true
+}
+
+// ListWebhookConfigs 获取所有 Webhook 配置
+// GET /api/webhook-configs
+func (h *WebhookHandler) ListWebhookConfigs(c *gin.Context) {
+	var configs []model.WebhookConfig
+	if err := h.DB.Order("id").Find(&configs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 Webhook 配置失败"})
+		return
+	}
+	data := make([]WebhookConfigResponse, 0, len(configs))
+	for _, config := range configs {
+		data = append(data, toWebhookConfigResponse(config))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// CreateWebhookConfig 创建 Webhook 配置并生成独立的 Webhook URL
+// POST /api/webhook-configs
+func (h *WebhookHandler) CreateWebhookConfig(c *gin.Context) {
+	var req WebhookConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	config := model.WebhookConfig{WebhookUrl: generateWebhookUrl()}
+	if msg := applyWebhookConfigRequest(&config, req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+	after := toWebhookConfigResponse(config) // 保存时加密钩子会改写令牌字段，先记录快照
+	if err := h.DB.Create(&config).Error; err != nil {
+		log.Printf("❌ [Webhook] 创建配置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存 Webhook 配置失败"})
+		return
+	}
+	recordAudit(h.DB, c, "create", auditTargetWebhookConfig, strconv.Itoa(int(config.ID)), nil, after)
+	h.respondWebhookConfig(c, config.ID)
+	log.Printf("✅ [Webhook] 创建配置成功: id=%d, provider=%s, repo=%s", config.ID, config.Provider, config.RepoUrl)
+}
+
+// UpdateWebhookConfig 更新 Webhook 配置，Webhook URL 保持不变
+// PUT /api/webhook-configs/:id
+func (h *WebhookHandler) UpdateWebhookConfig(c *gin.Context) {
+	config, ok := h.findWebhookConfigByID(c)
+	if !ok {
+		return
+	}
+	var req WebhookConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误"})
+		return
+	}
+	before := toWebhookConfigResponse(*config)
+	if msg := applyWebhookConfigRequest(config, req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+	after := toWebhookConfigResponse(*config) // 保存时加密钩子会改写令牌字段，先记录快照
+	if err := h.DB.Save(config).Error; err != nil {
+		log.Printf("❌ [Webhook] 更新配置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存 Webhook 配置失败"})
+		return
+	}
+	recordAudit(h.DB, c, "update", auditTargetWebhookConfig, strconv.Itoa(int(config.ID)), before, after)
+	h.respondWebhookConfig(c, config.ID)
+}
+
+// RegenerateWebhookUrl 重新生成配置的 Webhook URL，旧地址立即失效
+// POST /api/webhook-configs/:id/regenerate-url
+func (h *WebhookHandler) RegenerateWebhookUrl(c *gin.Context) {
+	config, ok := h.findWebhookConfigByID(c)
+	if !ok {
+		return
+	}
+	// 使用 UpdateColumns 跳过加密钩子，避免重复加密已解密的令牌字段
+	webhookUrl := generateWebhookUrl()
+	if err := h.DB.Model(&model.WebhookConfig{}).Where("id = ?", config.ID).UpdateColumns(map[string]interface{}{
+		"webhook_url": webhookUrl,
+		"updated_at":  time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存 Webhook 配置失败"})
+		return
+	}
+	recordAudit(h.DB, c, "regenerate_url", auditTargetWebhookConfig, strconv.Itoa(int(config.ID)),
+		gin.H{"webhook_url": config.WebhookUrl}, gin.H{"webhook_url": webhookUrl})
+	h.respondWebhookConfig(c, config.ID)
+}
+
+// DeleteWebhookConfig 删除 Webhook 配置
+// DELETE /api/webhook-configs/:id
+func (h *WebhookHandler) DeleteWebhookConfig(c *gin.Context) {
+	config, ok := h.findWebhookConfigByID(c)
+	if !ok {
+		return
+	}
+	if err := h.DB.Delete(&model.WebhookConfig{}, config.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除 Webhook 配置失败"})
+		return
+	}
+	recordAudit(h.DB, c, "delete", auditTargetWebhookConfig, strconv.Itoa(int(config.ID)), toWebhookConfigResponse(*config), nil)
+	log.Printf("🗑️ [Webhook] 删除配置: id=%d, repo=%s", config.ID, config.RepoUrl)
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// respondWebhookConfig 重新读取配置并返回脱敏后的结果
+func (h *WebhookHandler) respondWebhookConfig(c *gin.Context, id uint) {
+	var config model.WebhookConfig
+	if err := h.DB.First(&config, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 Webhook 配置失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok", "data": toWebhookConfigResponse(config)})
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultWebhookLogRetentionDays 默认 Webhook 日志保留天数
+	defaultWebhookLogRetentionDays = 30
+	// defaultWebhookDedupWindow 默认重复投递判定窗口
+	defaultWebhookDedupWindow = 24 * time.Hour
+	// webhookLogPruneInterval 日志清理间隔
+	webhookLogPruneInterval = 24 * time.Hour
+)
+
+// deliveryIDHeaders 各推送来源携带投递ID的请求头
+var deliveryIDHeaders = map[string]string{
+	model.WebhookProviderGitHub: "X-GitHub-Delivery",
+	model.WebhookProviderGitea:  "X-Gitea-Delivery",
+	model.WebhookProviderGitLab: "X-Gitlab-Event-UUID",
+}
+
+// webhookDeliveryID 读取请求的投递ID，未携带时返回空字符串
+func webhookDeliveryID(c *gin.Context, provider string) string {
+	header, ok := deliveryIDHeaders[provider]
+	if !ok {
+		return ""
+	}
+	id := c.GetHeader(header)
+	if len(id) > 100 {
+		id = id[:100]
+	}
+	return id
+}
+
+// dedupWindow 读取重复投递判定窗口，配置为 0 时只拒绝正在处理中的重复投递
+func (h *WebhookHandler) dedupWindow() time.Duration {
+	hours, err := strconv.Atoi(getSystemConfigValue(h.DB, "webhook_dedup_window_hours"))
+	if err != nil || hours < 0 {
+		return defaultWebhookDedupWindow
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// isDuplicateDelivery 判断同一配置的投递是否已在判定窗口内处理过
+func (h *WebhookHandler) isDuplicateDelivery(configID uint, deliveryID string, now time.Time) bool {
+	window := h.dedupWindow()
+	if window == 0 {
+		return false
+	}
+	var count int64
+	h.DB.Model(&model.WebhookLog{}).
+		Where("webhook_config_id = ? AND delivery_id = ? AND created_at >= ?", configID, deliveryID, now.Add(-window)).
+		Count(&count)
+	return count > 0
+}
+
+// WebhookLogResponse Webhook 日志响应
+type WebhookLogResponse struct {
+	model.WebhookLog
+	MatchedFiles []string `json:"matched_files"`
+}
+
+func toWebhookLogResponse(entry model.WebhookLog) WebhookLogResponse {
+	files := []string{}
+	if entry.MatchedFiles != "" {
+		if err := json.Unmarshal([]byte(entry.MatchedFiles), &files); err != nil {
+			files = []string{}
+		}
+	}
+	return WebhookLogResponse{WebhookLog: entry, MatchedFiles: files}
+}
+
+// ListWebhookLogs 分页查询 Webhook 日志
+// 查询参数: page, pageSize, source, success(true/false), config_id, repo（模糊匹配）, commit（前缀匹配）,
+// delivery_id, since/until（RFC3339 时间）
+// GET /api/webhook/logs
+func (h *WebhookHandler) ListWebhookLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := h.DB.Model(&model.WebhookLog{})
+	if source := c.Query("source"); source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if success := c.Query("success"); success != "" {
+		ok, err := strconv.ParseBool(success)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "success 参数必须为 true 或 false"})
+			return
+		}
+		query = query.Where("success = ?", ok)
+	}
+	if configID := c.Query("config_id"); configID != "" {
+		id, err := strconv.ParseUint(configID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的配置 ID"})
+			return
+		}
+		query = query.Where("webhook_config_id = ?", id)
+	}
+	if repo := c.Query("repo"); repo != "" {
+		query = query.Where("repo_name LIKE ?", "%"+repo+"%")
+	}
+	if commit := c.Query("commit"); commit != "" {
+		query = query.Where("commit_sha LIKE ?", commit+"%")
+	}
+	if deliveryID := c.Query("delivery_id"); deliveryID != "" {
+		query = query.Where("delivery_id = ?", deliveryID)
+	}
+	for param, cond := range map[string]string{"since": "created_at >= ?", "until": "created_at < ?"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": param + " 必须为 RFC3339 时间"})
+			return
+		}
+		query = query.Where(cond, t)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 Webhook 日志失败"})
+		return
+	}
+	var entries []model.WebhookLog
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 Webhook 日志失败"})
+		return
+	}
+
+	data := make([]WebhookLogResponse, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, toWebhookLogResponse(entry))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": data, "total": total, "page": page, "page_size": pageSize,
+	})
+}
+
+// GetWebhookLog 获取单条 Webhook 日志
+// GET /api/webhook/logs/:id
+func (h *WebhookHandler) GetWebhookLog(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的日志 ID"})
+		return
+	}
+	var entry model.WebhookLog
+	if err := h.DB.First(&entry, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "日志不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询 Webhook 日志失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": toWebhookLogResponse(entry)})
+}
+
+// PruneWebhookLogs 删除超过保留天数的 Webhook 日志和已完成的投递记录，返回删除的日志数量
+func (h *WebhookHandler) PruneWebhookLogs(now time.Time) (int64, error) {
+	days, err := strconv.Atoi(getSystemConfigValue(h.DB, "webhook_log_retention_days"))
+	if err != nil || days < 0 {
+		days = defaultWebhookLogRetentionDays
+	}
+	if days == 0 {
+		return 0, nil
+	}
+	cutoff := now.AddDate(0, 0, -days)
+
+	result := h.DB.Where("created_at < ?", cutoff).Delete(&model.WebhookLog{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	// 成功的投递记录只用于追溯，随日志一起清理；待重试和死信投递保留
+	if err := h.DB.Where("status = ? AND updated_at < ?", model.DeliveryStatusSucceeded, cutoff).
+		Delete(&model.SymediaDelivery{}).Error; err != nil {
+		return result.RowsAffected, err
+	}
+	return result.RowsAffected, nil
+}
+
+// StartLogRetention 启动 Webhook 日志定期清理（非阻塞），启动时立即清理一次
+func (h *WebhookHandler) StartLogRetention() {
+	go func() {
+		ticker := time.NewTicker(webhookLogPruneInterval)
+		defer ticker.Stop()
+		for {
+			if n, err := h.PruneWebhookLogs(time.Now()); err != nil {
+				log.Printf("⚠️  [Webhook] 清理过期日志失败: %v", err)
+			} else if n > 0 {
+				log.Printf("🗑️  [Webhook] 已清理 %d 条过期日志", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWebhookRejectsDuplicateDelivery(t *testing.T) {
+	r, h := setupWebhookTest(t)
+	r.GET("/api/webhook/logs", h.ListWebhookLogs)
+
+	symedia := &fakeSymedia{}
+	server := httptest.NewServer(symedia)
+	defer server.Close()
+	h.DB.Create(&model.WebhookConfig{
+		SymediaUrl: server.URL, AuthToken: "token", Secret: "secret", RepoUrl: "https://github.com/owner/words",
+		Branch: "main", FilePath: "words/*.txt", WebhookUrl: webhookPathPrefix + "aaa",
+	})
+
+	body := []byte(`{"ref":"refs/heads/main","repository":{"full_name":"owner/words"},"head_commit":{"id":"abc","modified":["words/a.txt"]}}`)
+	send := func(delivery string) int {
+		return doWebhookRequest(r, "/api/webhook/github/aaa", body, map[string]string{
+			"X-Hub-Signature-256": "sha256=" + hmacHex("secret", body),
+			"X-GitHub-Delivery":   delivery,
+		}).Code
+	}
+	if code := send("d-1"); code != http.StatusOK {
+		t.Fatalf("首次投递期望 200，实际 %d", code)
+	}
+	if code := send("d-1"); code != http.StatusConflict {
+		t.Errorf("重复投递期望 409，实际 %d", code)
+	}
+	if code := send("d-2"); code != http.StatusOK {
+		t.Errorf("新的投递期望 200，实际 %d", code)
+	}
+	if symedia.calls != 2 {
+		t.Errorf("重复投递不应刷新 Symedia，实际调用 %d 次", symedia.calls)
+	}
+
+	// 超出判定窗口后允许再次处理
+	h.DB.Model(&model.WebhookLog{}).Where("delivery_id = ?", "d-1").Update("created_at", time.Now().Add(-25*time.Hour))
+	if code := send("d-1"); code != http.StatusOK {
+		t.Errorf("超出判定窗口的投递期望 200，实际 %d", code)
+	}
+
+	w := doRuleSetRequest(r, http.MethodGet, "/api/webhook/logs?delivery_id=d-2&success=true", nil)
+	var resp struct {
+		Data  []WebhookLogResponse `json:"data"`
+		Total int64                `json:"total"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Total != 1 || len(resp.Data[0].MatchedFiles) != 1 || resp.Data[0].MatchedFiles[0] != "words/a.txt" {
+		t.Errorf("按投递 ID 查询日志不正确: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestListWebhookLogsFiltersAndPagination(t *testing.T) {
+	_, h := setupWebhookTest(t)
+	r := setupWebhookLogRoutes(h)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		h.DB.Create(&model.WebhookLog{
+			Source: model.WebhookProviderGitHub, RepoName: "owner/words", Success: i%2 == 0,
+			CommitSHA: "sha" + string(rune('a'+i)), CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	h.DB.Create(&model.WebhookLog{Source: "publish", RepoName: "other/repo", Success: true, CreatedAt: base})
+
+	var resp struct {
+		Data     []WebhookLogResponse `json:"data"`
+		Total    int64                `json:"total"`
+		PageSize int                  `json:"page_size"`
+	}
+	w := doRuleSetRequest(r, http.MethodGet, "/api/webhook/logs?source=github&page=2&pageSize=2", nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Total != 5 || len(resp.Data) != 2 || resp.Data[0].CommitSHA != "shac" {
+		t.Errorf("分页结果不正确（按时间倒序）: %s", w.Body.String())
+	}
+
+	w = doRuleSetRequest(r, http.MethodGet, "/api/webhook/logs?success=false&since=2026-01-01T02:00:00Z", nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Total != 1 || resp.Data[0].CommitSHA != "shad" {
+		t.Errorf("按结果和时间过滤不正确: %s", w.Body.String())
+	}
+
+	w = doRuleSetRequest(r, http.MethodGet, "/api/webhook/logs?repo=other", nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Total != 1 || resp.Data[0].Source != "publish" || resp.Data[0].MatchedFiles == nil {
+		t.Errorf("按仓库过滤不正确: %s", w.Body.String())
+	}
+
+	if w := doRuleSetRequest(r, http.MethodGet, "/api/webhook/logs/1", nil); w.Code != http.StatusOK {
+		t.Errorf("查询单条日志期望 200，实际 %d", w.Code)
+	}
+	if w := doRuleSetRequest(r, http.MethodGet, "/api/webhook/logs/99", nil); w.Code != http.StatusNotFound {
+		t.Errorf("不存在的日志期望 404，实际 %d", w.Code)
+	}
+	if w := doRuleSetRequest(r, http.MethodGet, "/api/webhook/logs?since=yesterday", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("无效时间期望 400，实际 %d", w.Code)
+	}
+}
+
+func TestPruneWebhookLogs(t *testing.T) {
+	_, h := setupWebhookTest(t)
+	now := time.Now()
+	h.DB.Create(&model.WebhookLog{Source: "github", Success: true, CreatedAt: now.AddDate(0, 0, -31)})
+	h.DB.Create(&model.WebhookLog{Source: "github", Success: true, CreatedAt: now.AddDate(0, 0, -29)})
+	h.DB.Create(&model.SymediaDelivery{Status: model.DeliveryStatusDead, MaxAttempts: 1, UpdatedAt: now.AddDate(0, 0, -40)})
+
+	n, err := h.PruneWebhookLogs(now)
+	if err != nil || n != 1 {
+		t.Fatalf("应清理 1 条过期日志: n=%d err=%v", n, err)
+	}
+	var deliveries int64
+	h.DB.Model(&model.SymediaDelivery{}).Count(&deliveries)
+	if deliveries != 1 {
+		t.Errorf("死信投递不应随日志清理")
+	}
+
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "webhook_log_retention_days").Update("value", "0")
+	if n, _ := h.PruneWebhookLogs(now.AddDate(1, 0, 0)); n != 0 {
+		t.Errorf("保留天数为 0 时不应清理，实际清理 %d 条", n)
+	}
+}
+
+func setupWebhookLogRoutes(h *WebhookHandler) *gin.Engine {
+	r := gin.New()
+	r.GET("/api/webhook/logs", h.ListWebhookLogs)
+	r.GET("/api/webhook/logs/:id", h.GetWebhookLog)
+	return r
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSymedia 记录刷新调用次数的 Symedia 替身
+type fakeSymedia struct {
+	mu    sync.Mutex
+	calls int
+	auth  string
+	fail  bool // 为 true 时返回刷新失败
+}
+
+func (f *fakeSymedia) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	f.auth = r.Header.Get("Authorization")
+	if f.fail {
+		w.Write([]byte(`{"success":false,"message":"busy"}`))
+		return
+	}
+	w.Write([]byte(`{"success":true,"message":"ok"}`))
+}
+
+func setupWebhookTest(t *testing.T) (*gin.Engine, *WebhookHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "webhook.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	h := NewWebhookHandler(db, NewSymediaHandler(db, "secret"))
+	r := gin.New()
+	r.POST("/api/webhook/github", h.HandleGitHubWebhook)
+	r.POST("/api/webhook/github/:id", h.HandleGitHubWebhook)
+	return r, h
+}
+
+func doWebhookRequest(r *gin.Engine, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func hmacHex(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandleWebhookRoutesByConfig(t *testing.T) {
+	r, h := setupWebhookTest(t)
+
+	githubSymedia, gitlabSymedia := &fakeSymedia{}, &fakeSymedia{}
+	githubServer, gitlabServer := httptest.NewServer(githubSymedia), httptest.NewServer(gitlabSymedia)
+	defer githubServer.Close()
+	defer gitlabServer.Close()
+
+	h.DB.Create(&model.WebhookConfig{
+		Provider: model.WebhookProviderGitHub, SymediaUrl: githubServer.URL, AuthToken: "gh-token", Secret: "gh-secret",
+		RepoUrl: "https://github.com/owner/words", Branch: "main", FilePath: "*", WebhookUrl: webhookPathPrefix + "aaa",
+	})
+	h.DB.Create(&model.WebhookConfig{
+		Provider: model.WebhookProviderGitLab, SymediaUrl: gitlabServer.URL, AuthToken: "gl-token", Secret: "gl-secret",
+		RepoUrl: "https://gitlab.com/group/words", Branch: "main", FilePath: "words.txt", WebhookUrl: webhookPathPrefix + "bbb",
+	})
+
+	githubBody := []byte(`{"ref":"refs/heads/main","repository":{"full_name":"owner/words"},"head_commit":{"id":"abc1234567","modified":["a.txt"]}}`)
+	w := doWebhookRequest(r, "/api/webhook/github/aaa", githubBody, map[string]string{
+		"X-Hub-Signature-256": "sha256=" + hmacHex("gh-secret", githubBody),
+		"X-GitHub-Event":      "push",
+	})
+	if w.Code != http.StatusOK || githubSymedia.calls != 1 || gitlabSymedia.calls != 0 {
+		t.Fatalf("GitHub 推送应刷新对应的 Symedia: %d %s calls=%d/%d", w.Code, w.Body.String(), githubSymedia.calls, gitlabSymedia.calls)
+	}
+	if githubSymedia.auth != "Bearer gh-token" {
+		t.Errorf("应使用配置自己的令牌: %q", githubSymedia.auth)
+	}
+
+	// GitLab 事件没有 head_commit，使用与 after 一致的提交
+	gitlabBody := []byte(`{"ref":"refs/heads/main","after":"def","project":{"path_with_namespace":"group/words"},` +
+		`"commits":[{"id":"def","modified":["words.txt"]},{"id":"old","added":["other.txt"]}]}`)
+	w = doWebhookRequest(r, "/api/webhook/github/bbb", gitlabBody, map[string]string{
+		"X-Gitlab-Token": "gl-secret",
+		"X-Gitlab-Event": "Push Hook",
+	})
+	if w.Code != http.StatusOK || gitlabSymedia.calls != 1 {
+		t.Fatalf("GitLab 推送应刷新对应的 Symedia: %d %s calls=%d", w.Code, w.Body.String(), gitlabSymedia.calls)
+	}
+	var logEntry model.WebhookLog
+	h.DB.Where("source = ?", model.WebhookProviderGitLab).First(&logEntry)
+	if logEntry.RepoName != "group/words" || logEntry.CommitSHA != "def" || logEntry.WebhookConfigID != 2 ||
+		logEntry.MatchedFiles != `["words.txt"]` {
+		t.Errorf("GitLab 日志不正确: %+v", logEntry)
+	}
+
+	// 使用其他配置的密钥签名时拒绝
+	w = doWebhookRequest(r, "/api/webhook/github/bbb", gitlabBody, map[string]string{"X-Gitlab-Token": "gh-secret"})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("错误令牌期望 401，实际 %d", w.Code)
+	}
+
+	// 非推送事件只确认接收
+	w = doWebhookRequest(r, "/api/webhook/github/bbb", gitlabBody, map[string]string{
+		"X-Gitlab-Token": "gl-secret",
+		"X-Gitlab-Event": "Tag Push Hook",
+	})
+	if w.Code != http.StatusOK || gitlabSymedia.calls != 1 {
+		t.Errorf("非推送事件不应触发刷新: %d calls=%d", w.Code, gitlabSymedia.calls)
+	}
+
+	if w := doWebhookRequest(r, "/api/webhook/github/unknown", githubBody, nil); w.Code != http.StatusNotFound {
+		t.Errorf("未知路由标识期望 404，实际 %d", w.Code)
+	}
+	if w := doWebhookRequest(r, "/api/webhook/github", githubBody, nil); w.Code != http.StatusNotFound {
+		t.Errorf("多个配置时不带标识的旧路由期望 404，实际 %d", w.Code)
+	}
+}
+
+func TestHandleWebhookGiteaFallsBackToGlobalSymedia(t *testing.T) {
+	r, h := setupWebhookTest(t)
+
+	symedia := &fakeSymedia{}
+	server := httptest.NewServer(symedia)
+	defer server.Close()
+	h.DB.Model(&model.SystemConfig{}).Where("key = ?", "symedia_url").Update("value", server.URL)
+	h.DB.Where("key = ?", "symedia_auth_token").Assign(model.SystemConfig{Key: "symedia_auth_token", Value: "global"}).
+		FirstOrCreate(&model.SystemConfig{})
+
+	h.DB.Create(&model.WebhookConfig{
+		Provider: model.WebhookProviderGitea, Secret: "gt-secret", RepoUrl: "https://gitea.local/owner/words",
+		Branch: "main", FilePath: "*", WebhookUrl: webhookPathPrefix + "ccc",
+	})
+
+	// 旧版本 Gitea 没有 head_commit
+	body, _ := json.Marshal(map[string]interface{}{
+		"ref": "refs/heads/main", "after": "123",
+		"repository": map[string]string{"full_name": "owner/words"},
+		"commits":    []map[string]interface{}{{"id": "123", "added": []string{"new.txt"}}},
+	})
+	if w := doWebhookRequest(r, "/api/webhook/github/ccc", body, map[string]string{"X-Gitea-Signature": "00"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("错误签名期望 401，实际 %d", w.Code)
+	}
+
+	// 只有一个配置时旧路由仍然可用
+	w := doWebhookRequest(r, "/api/webhook/github", body, map[string]string{
+		"X-Gitea-Signature": hmacHex("gt-secret", body),
+		"X-Gitea-Event":     "push",
+	})
+	if w.Code != http.StatusOK || symedia.calls != 1 || symedia.auth != "Bearer global" {
+		t.Fatalf("Gitea 推送应刷新全局 Symedia: %d %s calls=%d auth=%q", w.Code, w.Body.String(), symedia.calls, symedia.auth)
+	}
+}
+
+func TestWebhookConfigCRUD(t *testing.T) {
+	_, h := setupWebhookTest(t)
+	r := gin.New()
+	r.GET("/api/webhook-configs", h.ListWebhookConfigs)
+	r.POST("/api/webhook-configs", h.CreateWebhookConfig)
+	r.PUT("/api/webhook-configs/:id", h.UpdateWebhookConfig)
+
+	w := doRuleSetRequest(r, http.MethodPost, "/api/webhook-configs", WebhookConfigRequest{
+		Provider: "bitbucket", RepoUrl: "https://example.com/r", Branch: "main", Secret: "s",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("不支持的推送来源期望 400，实际 %d", w.Code)
+	}
+
+	w = doRuleSetRequest(r, http.MethodPost, "/api/webhook-configs", WebhookConfigRequest{
+		Name: "GitLab", Provider: model.WebhookProviderGitLab, SymediaUrl: "http://symedia-2", AuthToken: "token-0123456789",
+		RepoUrl: "https://gitlab.com/group/words", Branch: "main", Secret: "secret-0123456789",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("创建失败: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data WebhookConfigResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.AuthToken != "toke********6789" || created.Data.FilePath != "*" || len(created.Data.WebhookUrl) <= len(webhookPathPrefix) {
+		t.Errorf("创建结果不正确: %+v", created.Data)
+	}
+
+	// 令牌和密钥为空时保持不变
+	w = doRuleSetRequest(r, http.MethodPut, "/api/webhook-configs/1", WebhookConfigRequest{
+		Name: "改名", Provider: model.WebhookProviderGitLab, SymediaUrl: "http://symedia-2",
+		RepoUrl: "https://gitlab.com/group/words", Branch: "dev",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("更新失败: %d %s", w.Code, w.Body.String())
+	}
+	var config model.WebhookConfig
+	h.DB.First(&config, 1)
+	if config.Name != "改名" || config.Branch != "dev" || config.AuthToken != "token-0123456789" || config.Secret != "secret-0123456789" {
+		t.Errorf("更新结果不正确: %+v", config)
+	}
+	if config.WebhookUrl != created.Data.WebhookUrl {
+		t.Errorf("更新不应改变 Webhook URL: %s != %s", config.WebhookUrl, created.Data.WebhookUrl)
+	}
+}
+
+func TestMatchFilePattern(t *testing.T) {
+	cases := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"symedia/custom_words.txt", "symedia/custom_words.txt", true},
+		{"custom_words.txt", "symedia/custom_words.txt", true}, // 不含 "/" 的路径匹配任意目录下的同名文件
+		{"custom_words.txt", "custom_words.txt", true},
+		{"custom_words.txt", "symedia/old_custom_words.txt", false},
+		{"*.txt", "a/b/words.txt", true},
+		{"symedia/custom_words.txt", "symedia/custom_words.txt.bak", false},
+		{"symedia/*.txt", "symedia/a.txt", true},
+		{"symedia/*.txt", "symedia/sub/a.txt", false},
+		{"symedia/**/*.txt", "symedia/a.txt", true},
+		{"symedia/**/*.txt", "symedia/sub/deep/a.txt", true},
+		{"**/words.txt", "a/b/words.txt", true},
+		{"symedia/", "symedia/sub/a.txt", true},
+		{"symedia/", "other/a.txt", false},
+		{"[", "[", false},
+	}
+	for _, tc := range cases {
+		if got := matchFilePattern(tc.pattern, tc.file); got != tc.want {
+			t.Errorf("matchFilePattern(%q, %q) = %v, 期望 %v", tc.pattern, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestShouldTriggerRefreshAllCommits(t *testing.T) {
+	h := &WebhookHandler{}
+	event := &GitHubPushEvent{
+		Ref:        "refs/heads/main",
+		HeadCommit: PushCommit{ID: "c2", Modified: []string{"README.md"}},
+		Commits: []PushCommit{
+			{ID: "c1", Removed: []string{"words/old.txt"}, Added: []string{"docs/words.txt"}},
+			{ID: "c2", Modified: []string{"README.md", "words/old.txt"}},
+		},
+	}
+
+	config := &model.WebhookConfig{Branch: "main", FilePath: "words/*.txt, other.txt"}
+	matched, ok := h.shouldTriggerRefresh(event, config)
+	if !ok || len(matched) != 1 || matched[0] != "words/old.txt" {
+		t.Errorf("应匹配较早提交中删除的文件: %v %v", matched, ok)
+	}
+
+	config.FilePath = "*"
+	if matched, ok := h.shouldTriggerRefresh(event, config); !ok || len(matched) != 3 {
+		t.Errorf("监听所有文件时应返回所有去重后的变更文件: %v", matched)
+	}
+
+	config.FilePath = "ords.txt"
+	if _, ok := h.shouldTriggerRefresh(event, config); ok {
+		t.Error("路径片段不应再按子串匹配")
+	}
+
+	config.FilePath = "words.txt"
+	if matched, ok := h.shouldTriggerRefresh(event, config); !ok || len(matched) != 1 || matched[0] != "docs/words.txt" {
+		t.Errorf("不含 \"/\" 的路径应匹配任意目录下的同名文件: %v %v", matched, ok)
+	}
+
+	config.FilePath, config.Branch = "*", "dev"
+	if _, ok := h.shouldTriggerRefresh(event, config); ok {
+		t.Error("分支不匹配时不应触发")
+	}
+}
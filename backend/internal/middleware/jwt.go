@@ -5,24 +5,40 @@ import (
 	"strings"
 	"time"
 
+	"embyforge/internal/model"
+	"embyforge/internal/util"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+// AccessTokenTTL 访问令牌有效期，过期后使用刷新令牌换取新的访问令牌
+const AccessTokenTTL = 15 * time.Minute
+
 // Claims 自定义 JWT 声明
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID uint   `json:"sid,omitempty"` // 登录会话 ID，会话撤销后令牌随之失效
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成 JWT 令牌（不设过期时间，密码修改时通过更换 secret 使旧 token 失效）
+// GenerateToken 生成不绑定会话的短期访问令牌
 func GenerateToken(userID uint, username string, secret string) (string, error) {
+	return GenerateSessionToken(userID, username, 0, secret)
+}
+
+// GenerateSessionToken 生成绑定登录会话的短期访问令牌
+func GenerateSessionToken(userID uint, username string, sessionID uint, secret string) (string, error) {
+	now := time.Now()
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt: jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 		},
 	}
 
@@ -31,7 +47,8 @@ func GenerateToken(userID uint, username string, secret string) (string, error)
 }
 
 // JWTAuth 返回 JWT 认证中间件
-func JWTAuth(secret string) gin.HandlerFunc {
+// db 不为空时同时接受个人 API 令牌（ef_ 前缀），令牌的授权范围存入上下文的 apiTokenScopes
+func JWTAuth(secret string, db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -49,11 +66,16 @@ func JWTAuth(secret string) gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
+		if db != nil && strings.HasPrefix(tokenString, model.APITokenPrefix) {
+			authenticateAPIToken(c, db, tokenString)
+			return
+		}
+
 		claims := &Claims{}
 
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 			return []byte(secret), nil
-		})
+		}, jwt.WithExpirationRequired()) // 拒绝旧版本签发的永不过期令牌
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "认证令牌无效或已过期"})
@@ -64,6 +86,33 @@ func JWTAuth(secret string) gin.HandlerFunc {
 		// 将用户信息存入上下文
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		if claims.SessionID != 0 {
+			c.Set("sessionID", claims.SessionID)
+		}
 		c.Next()
 	}
 }
+
+// authenticateAPIToken 校验个人 API 令牌并以令牌所属用户身份继续处理请求
+func authenticateAPIToken(c *gin.Context, db *gorm.DB, tokenString string) {
+	now := time.Now()
+	var token model.APIToken
+	var user model.User
+	if err := db.Where("token_hash = ?", util.HashToken(tokenString)).First(&token).Error; err != nil ||
+		!token.Active(now) || db.Select("id", "username").First(&user, token.UserID).Error != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "API 令牌无效、已撤销或已过期"})
+		c.Abort()
+		return
+	}
+
+	// 最近使用时间精确到分钟即可，避免每次请求都写库
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= time.Minute {
+		db.Model(&model.APIToken{}).Where("id = ?", token.ID).UpdateColumn("last_used_at", now)
+	}
+
+	c.Set("userID", user.ID)
+	c.Set("username", user.Username)
+	c.Set("apiTokenID", token.ID)
+	c.Set("apiTokenScopes", token.ScopeList())
+	c.Next()
+}
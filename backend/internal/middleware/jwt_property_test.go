@@ -17,7 +17,7 @@ func setupJWTTest(secret string) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	protected := r.Group("/api")
-	protected.Use(JWTAuth(secret))
+	protected.Use(JWTAuth(secret, nil))
 	protected.GET("/protected", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "ok"})
 	})
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordChanged 返回强制修改初始密码的中间件，需在 UserRole 之后使用
+// 用户被标记为必须修改密码时，除 allowed 中列出的接口（"METHOD /完整路由"）外一律返回 403，
+// 响应中 password_change_required 为 true，前端据此跳转到修改密码页面
+func PasswordChanged(allowed map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("mustChangePassword") || allowed[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":                     403,
+			"message":                  "请先修改初始密码",
+			"password_change_required": true,
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UserRole 返回加载当前用户角色的中间件，需在 JWTAuth 之后使用
+// 角色每次请求从数据库读取，修改角色或删除用户后立即生效
+func UserRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+		var user model.User
+		if err := db.Select("id", "username", "role", "must_change_password").First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户不存在或已被删除"})
+			c.Abort()
+			return
+		}
+		c.Set("username", user.Username)
+		c.Set("role", user.Role)
+		c.Set("mustChangePassword", user.MustChangePassword)
+		c.Next()
+	}
+}
+
+// RequireRole 返回路由级别的角色校验中间件，当前用户角色低于 role 时返回 403
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !model.RoleAtLeast(c.GetString("role"), role) {
+			c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": "权限不足"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
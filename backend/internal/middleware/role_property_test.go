@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"pgregory.net/rapid"
+)
+
+// Feature: multi-user, Property: route-level role authorization
+// 对于任意用户角色和任意路由要求的角色，只有角色等级不低于要求时请求才能通过，否则返回 403；
+// 用户被删除后即使令牌有效也返回 401。
+func TestProperty_RequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "role.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	secret := "role-secret"
+	roles := []string{model.RoleViewer, model.RoleOperator, model.RoleAdmin}
+	level := map[string]int{model.RoleViewer: 1, model.RoleOperator: 2, model.RoleAdmin: 3}
+
+	r := gin.New()
+	protected := r.Group("/api", JWTAuth(secret, db), UserRole(db))
+	for _, role := range roles {
+		protected.GET("/"+role, RequireRole(role), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"role": c.GetString("role")})
+		})
+	}
+
+	users := make(map[string]model.User)
+	for _, role := range roles {
+		user := model.User{Username: "user-" + role, Password: "x", Role: role}
+		db.Create(&user)
+		users[role] = user
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		userRole := rapid.SampledFrom(roles).Draw(t, "userRole")
+		required := rapid.SampledFrom(roles).Draw(t, "required")
+
+		user := users[userRole]
+		token, _ := GenerateToken(user.ID, user.Username, secret)
+		req := httptest.NewRequest(http.MethodGet, "/api/"+required, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		want := http.StatusForbidden
+		if level[userRole] >= level[required] {
+			want = http.StatusOK
+		}
+		if w.Code != want {
+			t.Fatalf("角色 %s 访问要求 %s 的路由: 期望 %d，实际 %d", userRole, required, want, w.Code)
+		}
+	})
+
+	deleted := model.User{Username: "deleted", Password: "x", Role: model.RoleAdmin}
+	db.Create(&deleted)
+	token, _ := GenerateToken(deleted.ID, deleted.Username, secret)
+	db.Delete(&deleted)
+	req := httptest.NewRequest(http.MethodGet, "/api/viewer", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("已删除用户的令牌期望 401，实际 %d", w.Code)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenScope 返回 API 令牌授权范围校验中间件，需在 JWTAuth 之后使用
+// scopes 以 "METHOD /完整路由" 为键指定接口需要的授权范围，未列出或值为空的接口 API 令牌不可访问，
+// 新增接口默认只能通过登录会话访问。登录会话不受授权范围限制
+func TokenScope(scopes map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, ok := c.Get("apiTokenScopes")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		required := scopes[c.Request.Method+" "+c.FullPath()]
+		if required == "" {
+			c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": "API 令牌不能访问该接口"})
+			c.Abort()
+			return
+		}
+		for _, scope := range granted.([]string) {
+			if scope == required {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": "API 令牌缺少授权范围: " + required})
+		c.Abort()
+	}
+}
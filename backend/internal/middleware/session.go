@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"embyforge/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ActiveSession 返回校验登录会话的中间件，需在 JWTAuth 之后使用
+// 会话被撤销或过期后，其访问令牌即使尚未过期也立即失效；API 令牌不绑定会话，直接放行
+func ActiveSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("apiTokenID"); ok {
+			c.Next()
+			return
+		}
+		sessionID, ok := c.Get("sessionID")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "会话已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+		var session model.UserSession
+		err := db.Select("id", "user_id", "expires_at", "revoked_at").First(&session, sessionID).Error
+		if err != nil || session.UserID != c.GetUint("userID") || !session.Active(time.Now()) {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "会话已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"embyforge/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// StreamTicketTTL 流票据有效期，客户端应在获取后立即建立连接
+	StreamTicketTTL = 30 * time.Second
+
+	// StreamCacheSync 媒体缓存同步进度流
+	StreamCacheSync = "cache-sync"
+)
+
+// StreamTicket 已签发的流票据
+type StreamTicket struct {
+	UserID    uint
+	Username  string
+	Stream    string
+	ExpiresAt time.Time
+}
+
+// StreamTickets 流票据存储
+// EventSource 无法携带请求头，SSE 接口改用一次性短期票据认证，避免访问令牌出现在 URL 和访问日志中。
+// 票据只保存在内存中（按哈希索引），服务重启后全部失效
+type StreamTickets struct {
+	mu      sync.Mutex
+	tickets map[string]StreamTicket // 票据哈希 -> 票据
+	ttl     time.Duration
+}
+
+// NewStreamTickets 创建流票据存储
+func NewStreamTickets(ttl time.Duration) *StreamTickets {
+	return &StreamTickets{tickets: make(map[string]StreamTicket), ttl: ttl}
+}
+
+// Issue 为指定用户和流签发票据，返回票据明文和过期时间
+func (s *StreamTickets) Issue(userID uint, username, stream string, now time.Time) (string, time.Time, error) {
+	raw, err := util.RandomToken(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(s.ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, ticket := range s.tickets {
+		if !now.Before(ticket.ExpiresAt) {
+			delete(s.tickets, hash)
+		}
+	}
+	s.tickets[util.HashToken(raw)] = StreamTicket{
+		UserID: userID, Username: username, Stream: stream, ExpiresAt: expiresAt,
+	}
+	return raw, expiresAt, nil
+}
+
+// Redeem 兑换票据，每张票据只能使用一次；票据不存在、已过期或不属于该流时返回 false
+func (s *StreamTickets) Redeem(raw, stream string, now time.Time) (StreamTicket, bool) {
+	if raw == "" {
+		return StreamTicket{}, false
+	}
+	hash := util.HashToken(raw)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ticket, ok := s.tickets[hash]
+	if !ok {
+		return StreamTicket{}, false
+	}
+	delete(s.tickets, hash)
+	if ticket.Stream != stream || !now.Before(ticket.ExpiresAt) {
+		return StreamTicket{}, false
+	}
+	return ticket, true
+}
+
+// StreamTicketAuth 返回 SSE 接口的认证中间件，只接受 query 参数 ticket 中为该流签发的票据
+// 通过后设置 userID 和 username，角色校验由后续的 UserRole/RequireRole 完成
+func StreamTicketAuth(tickets *StreamTickets, stream string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket, ok := tickets.Redeem(c.Query("ticket"), stream, time.Now())
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "流票据无效或已过期"})
+			c.Abort()
+			return
+		}
+		c.Set("userID", ticket.UserID)
+		c.Set("username", ticket.Username)
+		c.Next()
+	}
+}
@@ -57,6 +57,15 @@ func TestMigration_CreatesAllTables(t *testing.T) {
 		"media_caches",
 		"season_caches",
 		"scan_logs",
+		"series_preferences",
+		"rendering_word_rule_sets",
+		"rendering_word_rule_set_versions",
+		"symedia_deliveries",
+		"user_sessions",
+		"api_tokens",
+		"user_recovery_codes",
+		"login_failures",
+		"audit_logs",
 	}
 
 	for _, table := range expectedTables {
@@ -106,8 +115,8 @@ func TestMigration_Idempotent(t *testing.T) {
 	if err != nil {
 		t.Fatalf("获取版本失败: %v", err)
 	}
-	if ver != 7 {
-		t.Errorf("版本号不匹配: got %d, want 7", ver)
+	if ver != 28 {
+		t.Errorf("版本号不匹配: got %d, want 28", ver)
 	}
 }
 
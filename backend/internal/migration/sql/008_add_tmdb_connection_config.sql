@@ -0,0 +1,13 @@
+-- 008_add_tmdb_connection_config.sql
+-- 添加 TMDB 连接相关配置项：v4 读访问令牌、自定义基础地址、代理地址
+
+-- +goose Up
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES
+    ('tmdb_access_token', '', 'TMDB v4 读访问令牌（加密存储，配置后优先于 API Key 使用）', datetime('now'), datetime('now')),
+    ('tmdb_base_url', '', 'TMDB API 基础地址，可填写反向代理地址，留空使用 https://api.themoviedb.org', datetime('now'), datetime('now')),
+    ('tmdb_proxy_url', '', 'TMDB 请求代理地址，支持 http:// 和 socks5://，留空不使用代理', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key IN ('tmdb_access_token', 'tmdb_base_url', 'tmdb_proxy_url');
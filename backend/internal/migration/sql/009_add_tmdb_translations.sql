@@ -0,0 +1,19 @@
+-- 009_add_tmdb_translations.sql
+-- TMDB 本地化：缓存多语言名称，异常映射记录 TMDB 本地化名称，添加元数据语言配置项
+
+-- +goose Up
+ALTER TABLE tmdb_caches ADD COLUMN translations TEXT NOT NULL DEFAULT '';
+ALTER TABLE episode_mapping_anomalies ADD COLUMN tmdb_name VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE episode_mapping_anomalies ADD COLUMN tmdb_season_name VARCHAR(500) NOT NULL DEFAULT '';
+
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES
+    ('tmdb_languages', 'zh-CN,en-US', 'TMDB 元数据语言优先级，逗号分隔，靠前的语言缺少翻译时依次回退', datetime('now'), datetime('now')),
+    ('tmdb_image_languages', 'zh,en', 'TMDB 图片语言偏好，逗号分隔，无文字图片始终作为兜底', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key IN ('tmdb_languages', 'tmdb_image_languages');
+ALTER TABLE episode_mapping_anomalies DROP COLUMN tmdb_season_name;
+ALTER TABLE episode_mapping_anomalies DROP COLUMN tmdb_name;
+ALTER TABLE tmdb_caches DROP COLUMN translations;
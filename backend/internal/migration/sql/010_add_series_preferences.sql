@@ -0,0 +1,20 @@
+-- 010_add_series_preferences.sql
+-- 剧集偏好表：按剧集固定 TMDB 剧集组，异常映射分析按固定的剧集组对比
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS series_preferences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    emby_item_id VARCHAR(50) NOT NULL,
+    tmdb_id INTEGER NOT NULL DEFAULT 0,
+    episode_group_id VARCHAR(50) NOT NULL DEFAULT '',
+    episode_group_name VARCHAR(500) NOT NULL DEFAULT '',
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_series_preferences_emby_item_id ON series_preferences(emby_item_id);
+
+ALTER TABLE episode_mapping_anomalies ADD COLUMN episode_group_id VARCHAR(50) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE episode_mapping_anomalies DROP COLUMN episode_group_id;
+DROP TABLE IF EXISTS series_preferences;
@@ -0,0 +1,21 @@
+-- 011_add_tvdb_reference_source.sql
+-- TVDB 作为异常映射分析的可选参考数据源：全局/按剧集选择数据源，异常记录标明对比所用的数据源
+
+-- +goose Up
+ALTER TABLE series_preferences ADD COLUMN reference_source VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE episode_mapping_anomalies ADD COLUMN reference_source VARCHAR(10) NOT NULL DEFAULT 'tmdb';
+ALTER TABLE episode_mapping_anomalies ADD COLUMN tvdb_id INTEGER NOT NULL DEFAULT 0;
+
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES
+    ('tvdb_api_key', '', 'TVDB v4 API Key（加密存储）', datetime('now'), datetime('now')),
+    ('tvdb_pin', '', 'TVDB 订阅 PIN（加密存储），使用项目级 API Key 时留空', datetime('now'), datetime('now')),
+    ('tvdb_base_url', '', 'TVDB API 基础地址，留空使用 https://api4.thetvdb.com/v4', datetime('now'), datetime('now')),
+    ('episode_mapping_reference_source', 'tmdb', '异常映射分析的默认参考数据源：tmdb 或 tvdb（可按剧集单独设置）', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key IN ('tvdb_api_key', 'tvdb_pin', 'tvdb_base_url', 'episode_mapping_reference_source');
+ALTER TABLE episode_mapping_anomalies DROP COLUMN tvdb_id;
+ALTER TABLE episode_mapping_anomalies DROP COLUMN reference_source;
+ALTER TABLE series_preferences DROP COLUMN reference_source;
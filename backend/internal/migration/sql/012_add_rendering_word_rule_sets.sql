@@ -0,0 +1,37 @@
+-- 012_add_rendering_word_rule_sets.sql
+-- 渲染词规则集：按剧集保存渲染词规则，并记录每次修改的版本快照用于对比和回滚
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS rendering_word_rule_sets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(500) NOT NULL,
+    tmdb_id INTEGER NOT NULL DEFAULT 0,
+    media_type VARCHAR(10) NOT NULL DEFAULT 'tv',
+    rules TEXT NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    comment TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_rendering_word_rule_sets_tmdb_id ON rendering_word_rule_sets(tmdb_id);
+
+CREATE TABLE IF NOT EXISTS rendering_word_rule_set_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_set_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    name VARCHAR(500) NOT NULL,
+    tmdb_id INTEGER NOT NULL DEFAULT 0,
+    media_type VARCHAR(10) NOT NULL DEFAULT 'tv',
+    rules TEXT NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    comment TEXT NOT NULL DEFAULT '',
+    change_type VARCHAR(20) NOT NULL DEFAULT '',
+    changed_by VARCHAR(50) NOT NULL DEFAULT '',
+    created_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rule_set_versions_set_version ON rendering_word_rule_set_versions(rule_set_id, version);
+
+-- +goose Down
+DROP TABLE IF EXISTS rendering_word_rule_set_versions;
+DROP TABLE IF EXISTS rendering_word_rule_sets;
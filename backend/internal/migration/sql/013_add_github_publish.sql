@@ -0,0 +1,17 @@
+-- 013_add_github_publish.sql
+-- 从 EmbyForge 直接发布渲染词到 GitHub 仓库：访问令牌与 API 地址配置，记录最近一次发布的提交
+
+-- +goose Up
+ALTER TABLE webhook_configs ADD COLUMN last_published_sha VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE webhook_configs ADD COLUMN last_published_at DATETIME;
+
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES
+    ('github_token', '', 'GitHub 个人访问令牌（加密存储），需要仓库 Contents 读写权限', datetime('now'), datetime('now')),
+    ('github_api_base_url', '', 'GitHub API 基础地址，留空使用 https://api.github.com', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key IN ('github_token', 'github_api_base_url');
+ALTER TABLE webhook_configs DROP COLUMN last_published_at;
+ALTER TABLE webhook_configs DROP COLUMN last_published_sha;
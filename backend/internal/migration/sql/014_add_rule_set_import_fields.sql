@@ -0,0 +1,12 @@
+-- 014_add_rule_set_import_fields.sql
+-- 导入已有渲染词文件：保留原文件中规则集的顺序，以及没有名称注释行的块
+
+-- +goose Up
+ALTER TABLE rendering_word_rule_sets ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rendering_word_rule_sets ADD COLUMN omit_header BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE rendering_word_rule_set_versions ADD COLUMN omit_header BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE rendering_word_rule_set_versions DROP COLUMN omit_header;
+ALTER TABLE rendering_word_rule_sets DROP COLUMN omit_header;
+ALTER TABLE rendering_word_rule_sets DROP COLUMN sort_order;
@@ -0,0 +1,15 @@
+-- 015_add_webhook_providers.sql
+-- 支持多个 Webhook 配置：配置名称、推送来源（GitHub / Gitea / GitLab），日志记录触发的配置
+
+-- +goose Up
+ALTER TABLE webhook_configs ADD COLUMN name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE webhook_configs ADD COLUMN provider VARCHAR(20) NOT NULL DEFAULT 'github';
+CREATE INDEX IF NOT EXISTS idx_webhook_configs_webhook_url ON webhook_configs(webhook_url);
+
+ALTER TABLE webhook_logs ADD COLUMN webhook_config_id INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE webhook_logs DROP COLUMN webhook_config_id;
+DROP INDEX IF EXISTS idx_webhook_configs_webhook_url;
+ALTER TABLE webhook_configs DROP COLUMN provider;
+ALTER TABLE webhook_configs DROP COLUMN name;
@@ -0,0 +1,8 @@
+-- 016_add_webhook_log_matched_files.sql
+-- Webhook 日志记录推送中匹配监听路径的文件
+
+-- +goose Up
+ALTER TABLE webhook_logs ADD COLUMN matched_files TEXT NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE webhook_logs DROP COLUMN matched_files;
@@ -0,0 +1,31 @@
+-- 017_add_symedia_deliveries.sql
+-- Symedia 刷新投递队列：Webhook 触发的刷新失败后按指数退避重试，超过最大次数进入死信列表等待手动重放
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS symedia_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_config_id INTEGER NOT NULL DEFAULT 0,
+    webhook_log_id INTEGER NOT NULL DEFAULT 0,
+    source VARCHAR(50) NOT NULL DEFAULT '',
+    repo_name VARCHAR(500) NOT NULL DEFAULT '',
+    branch VARCHAR(100) NOT NULL DEFAULT '',
+    commit_sha VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 6,
+    next_attempt_at DATETIME,
+    last_attempt_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_symedia_deliveries_status_next ON symedia_deliveries(status, next_attempt_at);
+
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES ('symedia_delivery_max_attempts', '6', 'Symedia 刷新失败后的最大尝试次数（含首次），超过后进入死信列表', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key = 'symedia_delivery_max_attempts';
+DROP TABLE IF EXISTS symedia_deliveries;
@@ -0,0 +1,17 @@
+-- 018_add_webhook_delivery_id.sql
+-- Webhook 日志记录推送投递 ID 用于拒绝重复投递；日志保留天数与重复投递判定窗口配置
+
+-- +goose Up
+ALTER TABLE webhook_logs ADD COLUMN delivery_id VARCHAR(100) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_webhook_logs_delivery_id ON webhook_logs(delivery_id);
+
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES
+    ('webhook_log_retention_days', '30', 'Webhook 日志保留天数，超过后自动清理（0 表示不清理）', datetime('now'), datetime('now')),
+    ('webhook_dedup_window_hours', '24', '相同投递 ID 的 Webhook 在该时间窗口（小时）内重复到达时拒绝处理', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key IN ('webhook_log_retention_days', 'webhook_dedup_window_hours');
+DROP INDEX IF EXISTS idx_webhook_logs_delivery_id;
+ALTER TABLE webhook_logs DROP COLUMN delivery_id;
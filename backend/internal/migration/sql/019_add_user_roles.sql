@@ -0,0 +1,9 @@
+-- 019_add_user_roles.sql
+-- 多用户角色：admin（管理员）、operator（操作员）、viewer（只读），已有用户均为管理员
+
+-- +goose Up
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'viewer';
+UPDATE users SET role = 'admin';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN role;
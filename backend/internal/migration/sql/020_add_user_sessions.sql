@@ -0,0 +1,26 @@
+-- 020_add_user_sessions.sql
+-- 登录会话：访问令牌短期有效，刷新令牌哈希后存储，每次刷新时轮换，可按会话撤销
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    refresh_token_hash VARCHAR(64) NOT NULL,
+    previous_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(500) NOT NULL DEFAULT '',
+    ip VARCHAR(100) NOT NULL DEFAULT '',
+    last_used_at DATETIME,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_refresh_token_hash ON user_sessions(refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token_hash ON user_sessions(previous_token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+
+-- 改为按会话撤销后不再需要在修改密码时轮换全局 JWT 密钥
+UPDATE system_configs SET description = 'JWT 签名密钥（自动生成）' WHERE key = 'jwt_secret';
+
+-- +goose Down
+DROP TABLE IF EXISTS user_sessions;
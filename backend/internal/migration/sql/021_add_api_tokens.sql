@@ -0,0 +1,22 @@
+-- 021_add_api_tokens.sql
+-- 个人 API 令牌：供脚本调用接口，按授权范围限制可访问的接口，只保存令牌哈希
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    token_prefix VARCHAR(20) NOT NULL DEFAULT '',
+    token_hash VARCHAR(64) NOT NULL,
+    scopes VARCHAR(200) NOT NULL DEFAULT '',
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    revoked_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_tokens_token_hash ON api_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_tokens;
@@ -0,0 +1,27 @@
+-- 023_add_login_failures.sql
+-- 登录防爆破：记录失败的登录尝试，按用户名和 IP 统计失败次数实现递增延迟和临时锁定
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS login_failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(100) NOT NULL DEFAULT '',
+    ip VARCHAR(100) NOT NULL DEFAULT '',
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    user_agent VARCHAR(500) NOT NULL DEFAULT '',
+    cleared BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_login_failures_username ON login_failures(username, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_failures_ip ON login_failures(ip, created_at);
+
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES
+    ('login_max_failures_per_user', '5', '同一用户名在统计窗口内允许的登录失败次数，超过后临时锁定（0 表示不限制）', datetime('now'), datetime('now')),
+    ('login_max_failures_per_ip', '20', '同一 IP 在统计窗口内允许的登录失败次数，超过后临时锁定（0 表示不限制）', datetime('now'), datetime('now')),
+    ('login_failure_window_minutes', '15', '登录失败次数的统计窗口（分钟）', datetime('now'), datetime('now')),
+    ('login_lockout_minutes', '15', '登录失败次数超限后的锁定时长（分钟）', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key IN ('login_max_failures_per_user', 'login_max_failures_per_ip', 'login_failure_window_minutes', 'login_lockout_minutes');
+DROP TABLE IF EXISTS login_failures;
@@ -0,0 +1,27 @@
+-- 024_add_oidc.sql
+-- OpenID Connect 单点登录：用户绑定身份提供方的 subject，按声明或用户组映射角色
+
+-- +goose Up
+ALTER TABLE users ADD COLUMN oidc_subject VARCHAR(300) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_users_oidc_subject ON users(oidc_subject);
+
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES
+    ('oidc_enabled', 'false', '是否启用 OIDC 单点登录', datetime('now'), datetime('now')),
+    ('oidc_issuer_url', '', 'OIDC 身份提供方 Issuer 地址（通过 /.well-known/openid-configuration 自动发现端点）', datetime('now'), datetime('now')),
+    ('oidc_client_id', '', 'OIDC Client ID', datetime('now'), datetime('now')),
+    ('oidc_client_secret', '', 'OIDC Client Secret（公共客户端可留空，仅使用 PKCE）', datetime('now'), datetime('now')),
+    ('oidc_redirect_url', '', 'OIDC 回调地址，留空时按请求地址生成 {scheme}://{host}/api/auth/oidc/callback', datetime('now'), datetime('now')),
+    ('oidc_scopes', 'openid profile email groups', 'OIDC 请求的 scope，空格分隔', datetime('now'), datetime('now')),
+    ('oidc_username_claim', 'preferred_username', '作为 EmbyForge 用户名的声明，为空时依次使用 email 和 sub', datetime('now'), datetime('now')),
+    ('oidc_role_claim', 'groups', '用于映射角色的声明，支持点分隔的嵌套路径（如 realm_access.roles）', datetime('now'), datetime('now')),
+    ('oidc_role_mapping', '', '声明值到角色的映射，每行或逗号分隔一条，如 embyforge-admins=admin,media=operator', datetime('now'), datetime('now')),
+    ('oidc_default_role', '', '声明未匹配任何映射时的角色（admin/operator/viewer），留空表示拒绝登录', datetime('now'), datetime('now')),
+    ('oidc_auto_provision', 'false', '首次单点登录时自动创建不存在的用户', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key IN ('oidc_enabled', 'oidc_issuer_url', 'oidc_client_id', 'oidc_client_secret', 'oidc_redirect_url',
+    'oidc_scopes', 'oidc_username_claim', 'oidc_role_claim', 'oidc_role_mapping', 'oidc_default_role', 'oidc_auto_provision');
+DROP INDEX IF EXISTS idx_users_oidc_subject;
+ALTER TABLE users DROP COLUMN oidc_subject;
@@ -0,0 +1,22 @@
+-- 025_add_audit_logs.sql
+-- 管理操作审计日志：记录配置修改、缓存编辑、账号变更等操作的操作人、目标和修改前后的值（敏感字段脱敏）
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    username VARCHAR(100) NOT NULL DEFAULT '',
+    action VARCHAR(100) NOT NULL DEFAULT '',
+    target_type VARCHAR(50) NOT NULL DEFAULT '',
+    target_id VARCHAR(200) NOT NULL DEFAULT '',
+    before_value TEXT NOT NULL DEFAULT '',
+    after_value TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(100) NOT NULL DEFAULT '',
+    created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_username ON audit_logs(username);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_logs;
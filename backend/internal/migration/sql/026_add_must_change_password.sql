@@ -0,0 +1,8 @@
+-- 026_add_must_change_password.sql
+-- 强制修改初始密码：使用默认密码的管理员登录后必须先修改密码才能访问其他接口
+
+-- +goose Up
+ALTER TABLE users ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN must_change_password;
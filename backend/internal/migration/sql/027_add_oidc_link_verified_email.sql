@@ -0,0 +1,10 @@
+-- 027_add_oidc_link_verified_email.sql
+-- OIDC 不再按用户名声明绑定已有本地用户，改为由管理员绑定或显式开启按已验证 email 绑定
+
+-- +goose Up
+INSERT INTO system_configs (key, value, description, created_at, updated_at)
+VALUES ('oidc_link_verified_email', 'false', '首次单点登录时按已验证的 email 绑定用户名相同的本地用户（不绑定已启用两步验证的用户），关闭时需由管理员在用户管理中绑定', datetime('now'), datetime('now'))
+ON CONFLICT(key) DO NOTHING;
+
+-- +goose Down
+DELETE FROM system_configs WHERE key = 'oidc_link_verified_email';
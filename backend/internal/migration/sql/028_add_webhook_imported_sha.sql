@@ -0,0 +1,8 @@
+-- 028_add_webhook_imported_sha.sql
+-- 记录从 GitHub 导入的无标记渲染词文件版本：整个文件已导入为规则集，下次发布时由自动生成区域整体替换
+
+-- +goose Up
+ALTER TABLE webhook_configs ADD COLUMN imported_sha VARCHAR(100) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE webhook_configs DROP COLUMN imported_sha;
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APITokenPrefix 个人 API 令牌前缀，用于和 JWT 区分
+const APITokenPrefix = "ef_"
+
+// API 令牌授权范围
+const (
+	ScopeRead    = "read"    // 查看数据
+	ScopeSync    = "sync"    // 同步媒体库、刷新缓存
+	ScopeAnalyze = "analyze" // 执行异常分析
+	ScopeDelete  = "delete"  // 删除媒体
+)
+
+// validScopes 所有授权范围，按固定顺序存储
+var validScopes = []string{ScopeRead, ScopeSync, ScopeAnalyze, ScopeDelete}
+
+// IsValidScope 判断授权范围是否有效
+func IsValidScope(scope string) bool {
+	for _, s := range validScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken 个人 API 令牌
+// 令牌只保存 SHA-256 哈希，TokenPrefix 保存明文开头几位用于识别
+type APIToken struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Name        string     `gorm:"size:100;not null;default:''" json:"name"`
+	TokenPrefix string     `gorm:"size:20;not null;default:''" json:"token_prefix"`
+	TokenHash   string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes      string     `gorm:"size:200;not null;default:''" json:"-"` // 逗号分隔
+	ExpiresAt   *time.Time `json:"expires_at"`                            // 为空表示永不过期
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ScopeList 返回授权范围列表
+func (t *APIToken) ScopeList() []string {
+	scopes := []string{}
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// SetScopes 去重并按固定顺序保存授权范围
+func (t *APIToken) SetScopes(scopes []string) {
+	var ordered []string
+	for _, valid := range validScopes {
+		for _, s := range scopes {
+			if s == valid {
+				ordered = append(ordered, s)
+				break
+			}
+		}
+	}
+	t.Scopes = strings.Join(ordered, ",")
+}
+
+// HasScope 判断令牌是否拥有指定授权范围
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active 令牌未撤销且未过期
+func (t *APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// AuditLog 管理操作审计日志
+// BeforeValue/AfterValue 为操作前后目标的 JSON 快照（敏感字段已脱敏），创建时 before 为空，删除时 after 为空
+type AuditLog struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;default:0" json:"user_id"`
+	Username    string    `gorm:"size:100;not null;default:'';index:idx_audit_logs_username" json:"username"`
+	Action      string    `gorm:"size:100;not null;default:''" json:"action"`
+	TargetType  string    `gorm:"size:50;not null;default:'';index:idx_audit_logs_target" json:"target_type"`
+	TargetID    string    `gorm:"size:200;not null;default:'';index:idx_audit_logs_target" json:"target_id"`
+	BeforeValue string    `gorm:"type:text;not null;default:''" json:"-"`
+	AfterValue  string    `gorm:"type:text;not null;default:''" json:"-"`
+	IP          string    `gorm:"size:100;not null;default:''" json:"ip"`
+	CreatedAt   time.Time `gorm:"index:idx_audit_logs_created_at" json:"created_at"`
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"embyforge/internal/migration"
 	"embyforge/internal/util"
@@ -16,6 +17,11 @@ import (
 
 // InitDB 初始化数据库连接，执行自动迁移和种子数据
 func InitDB(dbPath string) (*gorm.DB, error) {
+	// 加密密钥配置错误时拒绝启动，避免用错误的密钥读写敏感数据
+	if err := util.CheckEncryptionKeys(); err != nil {
+		return nil, err
+	}
+
 	// 确保数据库目录存在
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -54,29 +60,57 @@ func InitDB(dbPath string) (*gorm.DB, error) {
 	// 创建初始管理员账户（如果不存在）
 	seedAdmin(db)
 
-	// 自动加密明文敏感数据
-	encryptPlaintextData(db)
+	// 加密明文敏感数据，并把旧密钥加密的数据重新加密到当前密钥版本
+	if err := reencryptProtectedData(db); err != nil {
+		return nil, err
+	}
 
 	return db, nil
 }
 
-// seedAdmin 创建默认管理员账户
+const (
+	// defaultAdminUsername 默认管理员用户名
+	defaultAdminUsername = "admin"
+	// defaultAdminPassword 默认管理员密码，使用该密码的管理员登录后必须先修改密码
+	defaultAdminPassword = "admin"
+)
+
+// seedAdmin 创建初始管理员账户
+// 可通过环境变量 EMBYFORGE_ADMIN_USERNAME / EMBYFORGE_ADMIN_PASSWORD 指定初始账户，仅在没有任何用户时生效；
+// 未指定密码时使用默认密码 admin，并要求首次登录后修改
 func seedAdmin(db *gorm.DB) {
 	var count int64
 	db.Model(&User{}).Count(&count)
 	if count > 0 {
+		flagDefaultPasswordAdmins(db)
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.DefaultCost)
+	username := strings.TrimSpace(os.Getenv("EMBYFORGE_ADMIN_USERNAME"))
+	if username == "" {
+		username = defaultAdminUsername
+	}
+	password := os.Getenv("EMBYFORGE_ADMIN_PASSWORD")
+	if password != "" && len(password) < 4 {
+		log.Println("⚠️  EMBYFORGE_ADMIN_PASSWORD 少于 4 个字符，已忽略并使用默认密码")
+		password = ""
+	}
+	mustChange := password == ""
+	if mustChange {
+		password = defaultAdminPassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("❌ 创建默认管理员失败: %v", err)
 		return
 	}
 
 	admin := User{
-		Username: "admin",
-		Password: string(hashedPassword),
+		Username:           username,
+		Password:           string(hashedPassword),
+		Role:               RoleAdmin,
+		MustChangePassword: mustChange,
 	}
 
 	if err := db.Create(&admin).Error; err != nil {
@@ -84,75 +118,26 @@ func seedAdmin(db *gorm.DB) {
 		return
 	}
 
-	log.Println("👤 已创建默认管理员账户 (admin/admin)")
-}
-
-// encryptPlaintextData 自动加密所有明文存储的敏感数据
-func encryptPlaintextData(db *gorm.DB) {
-	// 加密 SystemConfig 中的敏感配置
-	var configs []SystemConfig
-	if err := db.Find(&configs).Error; err != nil {
-		log.Printf("⚠️  查询系统配置失败: %v", err)
-		return
-	}
-
-	encryptedCount := 0
-	for _, config := range configs {
-		// 检查是否是需要加密的键
-		if encryptedKeys[config.Key] && config.Value != "" {
-			// 尝试解密，如果失败说明是明文
-			if _, err := util.Decrypt(config.Value); err != nil {
-				// 是明文，需要加密
-				// 直接保存会触发 BeforeSave 钩子自动加密
-				if err := db.Save(&config).Error; err != nil {
-					log.Printf("⚠️  加密配置 %s 失败: %v", config.Key, err)
-				} else {
-					encryptedCount++
-				}
-			}
-		}
-	}
-
-	if encryptedCount > 0 {
-		log.Printf("🔐 已自动加密 %d 个明文配置", encryptedCount)
+	if mustChange {
+		log.Printf("👤 已创建默认管理员账户 (%s/%s)，首次登录后需修改密码", username, defaultAdminPassword)
+	} else {
+		log.Printf("👤 已按环境变量创建管理员账户 %s", username)
 	}
+}
 
-	// 加密 WebhookConfig 中的敏感字段
-	var webhookConfigs []WebhookConfig
-	if err := db.Find(&webhookConfigs).Error; err != nil {
-		log.Printf("⚠️  查询 Webhook 配置失败: %v", err)
+// flagDefaultPasswordAdmins 升级前创建、仍在使用默认密码的管理员标记为必须修改密码
+func flagDefaultPasswordAdmins(db *gorm.DB) {
+	var admins []User
+	if err := db.Select("id", "username", "password").
+		Where("role = ? AND must_change_password = ?", RoleAdmin, false).Find(&admins).Error; err != nil {
 		return
 	}
-
-	webhookEncryptedCount := 0
-	for _, config := range webhookConfigs {
-		needSave := false
-
-		// 检查 AuthToken 是否需要加密
-		if config.AuthToken != "" {
-			if _, err := util.Decrypt(config.AuthToken); err != nil {
-				needSave = true
-			}
-		}
-
-		// 检查 Secret 是否需要加密
-		if config.Secret != "" {
-			if _, err := util.Decrypt(config.Secret); err != nil {
-				needSave = true
-			}
+	for _, admin := range admins {
+		if bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(defaultAdminPassword)) != nil {
+			continue
 		}
-
-		if needSave {
-			// 直接保存会触发 BeforeSave 钩子自动加密
-			if err := db.Save(&config).Error; err != nil {
-				log.Printf("⚠️  加密 Webhook 配置失败: %v", err)
-			} else {
-				webhookEncryptedCount++
-			}
+		if err := db.Model(&User{}).Where("id = ?", admin.ID).Update("must_change_password", true).Error; err == nil {
+			log.Printf("⚠️  管理员 %s 仍在使用默认密码，登录后需先修改密码", admin.Username)
 		}
 	}
-
-	if webhookEncryptedCount > 0 {
-		log.Printf("🔐 已自动加密 %d 个 Webhook 配置", webhookEncryptedCount)
-	}
 }
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestInitDB(t *testing.T) {
@@ -51,6 +53,54 @@ func TestInitDB(t *testing.T) {
 	if user.Password == "" || user.Password == "admin" {
 		t.Error("管理员密码未正确哈希")
 	}
+	if user.Role != RoleAdmin {
+		t.Errorf("默认管理员角色不正确: got %s, want %s", user.Role, RoleAdmin)
+	}
+	if !user.MustChangePassword {
+		t.Error("默认管理员应被要求修改初始密码")
+	}
+}
+
+func TestInitDB_SeedAdminFromEnv(t *testing.T) {
+	t.Setenv("EMBYFORGE_ADMIN_USERNAME", "root")
+	t.Setenv("EMBYFORGE_ADMIN_PASSWORD", "s3cret-pass")
+
+	db, err := InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("InitDB 失败: %v", err)
+	}
+	var user User
+	if err := db.First(&user, "username = ?", "root").Error; err != nil {
+		t.Fatalf("查询环境变量指定的管理员失败: %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("s3cret-pass")) != nil {
+		t.Error("管理员密码应为环境变量指定的密码")
+	}
+	if user.Role != RoleAdmin || user.MustChangePassword {
+		t.Errorf("指定密码的管理员不需要修改密码: role=%s must_change=%v", user.Role, user.MustChangePassword)
+	}
+}
+
+func TestInitDB_FlagsDefaultPasswordOnUpgrade(t *testing.T) {
+	// 升级前创建的管理员没有强制修改标记，仍使用默认密码时启动后补上标记
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db1, err := InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("第一次 InitDB 失败: %v", err)
+	}
+	db1.Model(&User{}).Where("username = ?", "admin").Update("must_change_password", false)
+	sqlDB1, _ := db1.DB()
+	sqlDB1.Close()
+
+	db2, err := InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("第二次 InitDB 失败: %v", err)
+	}
+	var user User
+	db2.First(&user, "username = ?", "admin")
+	if !user.MustChangePassword {
+		t.Error("仍使用默认密码的管理员应被要求修改密码")
+	}
 }
 
 func TestInitDB_SeedAdminIdempotent(t *testing.T) {
@@ -1,13 +1,47 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"embyforge/internal/util"
+
+	"gorm.io/gorm"
+)
 
 // EmbyConfig Emby 服务器配置模型
 type EmbyConfig struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	Host      string    `gorm:"size:255;not null" json:"host"`   // 如 http://192.168.1.100
-	Port      int       `gorm:"not null" json:"port"`            // 如 8096
-	APIKey    string    `gorm:"size:255;not null" json:"api_key"` // Emby API Key
+	Host      string    `gorm:"size:255;not null" json:"host"`    // 如 http://192.168.1.100
+	Port      int       `gorm:"not null" json:"port"`             // 如 8096
+	APIKey    string    `gorm:"size:255;not null" json:"api_key"` // Emby API Key，加密存储
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// BeforeSave GORM钩子：保存前加密 API Key
+func (e *EmbyConfig) BeforeSave(tx *gorm.DB) error {
+	if e.APIKey != "" {
+		encrypted, err := util.Encrypt(e.APIKey)
+		if err != nil {
+			return err
+		}
+		e.APIKey = encrypted
+	}
+	return nil
+}
+
+// AfterSave GORM钩子：保存后恢复明文，保存后的结构体可继续使用
+func (e *EmbyConfig) AfterSave(tx *gorm.DB) error {
+	return e.AfterFind(tx)
+}
+
+// AfterFind GORM钩子：查询后解密 API Key
+func (e *EmbyConfig) AfterFind(tx *gorm.DB) error {
+	if e.APIKey != "" {
+		// 解密失败说明是尚未加密的明文，启动时会自动加密
+		if decrypted, err := util.Decrypt(e.APIKey); err == nil {
+			e.APIKey = decrypted
+		}
+	}
+	return nil
+}
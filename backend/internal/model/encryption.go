@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"embyforge/internal/util"
+
+	"gorm.io/gorm"
+)
+
+// protectedColumn 加密存储的敏感字段
+type protectedColumn struct {
+	table  string
+	column string
+	where  string
+	args   []interface{}
+}
+
+// protectedColumns 所有加密存储的敏感字段，新增加密字段时需同步添加，以便密钥轮换时一并重新加密
+func protectedColumns() []protectedColumn {
+	keys := make([]string, 0, len(encryptedKeys))
+	for key := range encryptedKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return []protectedColumn{
+		{table: "system_configs", column: "value", where: "key IN ?", args: []interface{}{keys}},
+		{table: "webhook_configs", column: "auth_token"},
+		{table: "webhook_configs", column: "secret"},
+		{table: "emby_configs", column: "api_key"},
+		{table: "users", column: "totp_secret"},
+	}
+}
+
+// reencryptProtectedData 把所有敏感字段重新加密到当前密钥版本
+//   - 明文数据（升级前未加密的字段）直接加密
+//   - 旧版本密钥或无版本前缀的旧格式密文解密后用当前密钥重新加密
+//   - 密文使用的密钥版本未配置，或旧格式密文无法用已配置的任何密钥解密时返回错误（拒绝启动），
+//     避免数据被当作明文二次加密而永久损坏
+//
+// 直接读写字段值，不经过模型钩子
+func reencryptProtectedData(db *gorm.DB) error {
+	version := util.EncryptionKeyVersion()
+	total := 0
+	for _, col := range protectedColumns() {
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		query := db.Table(col.table).Select("id, " + col.column + " AS value").Where(col.column + " <> ''")
+		if col.where != "" {
+			query = query.Where(col.where, col.args...)
+		}
+		if err := query.Scan(&rows).Error; err != nil {
+			return fmt.Errorf("查询 %s.%s 失败: %w", col.table, col.column, err)
+		}
+
+		for _, row := range rows {
+			if util.IsCurrentCiphertext(row.Value) {
+				continue
+			}
+			plaintext, err := util.Decrypt(row.Value)
+			if err != nil {
+				if v, ok := util.CiphertextVersion(row.Value); ok {
+					return fmt.Errorf("%s.%s (id=%d) 使用密钥版本 %d 加密，但未配置该版本的密钥，请在 ENCRYPTION_PREVIOUS_KEYS 中提供: %w",
+						col.table, col.column, row.ID, v, err)
+				}
+				if util.LooksLikeLegacyCiphertext(row.Value) {
+					return fmt.Errorf("%s.%s (id=%d) 无法使用已配置的密钥解密，如果更换过 ENCRYPTION_KEY，请在 ENCRYPTION_PREVIOUS_KEYS 中提供旧密钥: %w",
+						col.table, col.column, row.ID, err)
+				}
+				// 无法解密且不是密文，视为明文
+				plaintext = row.Value
+			}
+			encrypted, err := util.Encrypt(plaintext)
+			if err != nil {
+				return fmt.Errorf("加密 %s.%s (id=%d) 失败: %w", col.table, col.column, row.ID, err)
+			}
+			if err := db.Table(col.table).Where("id = ?", row.ID).UpdateColumn(col.column, encrypted).Error; err != nil {
+				return fmt.Errorf("更新 %s.%s (id=%d) 失败: %w", col.table, col.column, row.ID, err)
+			}
+			total++
+		}
+	}
+
+	if total > 0 {
+		log.Printf("🔐 已使用密钥版本 v%d 重新加密 %d 个敏感字段", version, total)
+	}
+	return nil
+}
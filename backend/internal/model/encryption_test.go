@@ -0,0 +1,144 @@
+package model
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"embyforge/internal/util"
+)
+
+func TestReencryptProtectedData_KeyRotation(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	t.Setenv("ENCRYPTION_KEY", "first-encryption-key-0123456789")
+	t.Setenv("ENCRYPTION_KEY_VERSION", "1")
+
+	db, err := InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("InitDB 失败: %v", err)
+	}
+	const apiKey = "0123456789abcdef0123456789abcdef"
+	const tmdbKey = "fedcba9876543210fedcba9876543210"
+	db.Create(&EmbyConfig{Host: "http://emby.local", Port: 8096, APIKey: apiKey})
+	var tmdb SystemConfig
+	db.Where("key = ?", "tmdb_api_key").First(&tmdb)
+	tmdb.Value = tmdbKey
+	db.Save(&tmdb)
+	// 升级前的明文数据
+	db.Exec("UPDATE users SET totp_secret = ? WHERE username = ?", "JBSWY3DPEHPK3PXP", "admin")
+
+	var raw string
+	db.Raw("SELECT api_key FROM emby_configs").Scan(&raw)
+	if raw == apiKey || !strings.HasPrefix(raw, "v1:") {
+		t.Fatalf("Emby API Key 应使用 v1 密钥加密存储，实际 %q", raw)
+	}
+	db.Raw("SELECT value FROM system_configs WHERE key = ?", "tmdb_api_key").Scan(&raw)
+	if raw == tmdbKey || !strings.HasPrefix(raw, "v1:") {
+		t.Fatalf("TMDB API Key 应使用 v1 密钥加密存储，实际 %q", raw)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	// 轮换到 v2：启动时把 v1 密文和明文重新加密到 v2
+	t.Setenv("ENCRYPTION_KEY", "second-encryption-key-0123456789")
+	t.Setenv("ENCRYPTION_KEY_VERSION", "2")
+	t.Setenv("ENCRYPTION_PREVIOUS_KEYS", "1:first-encryption-key-0123456789")
+	db, err = InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("轮换密钥后 InitDB 失败: %v", err)
+	}
+	for _, query := range []string{
+		"SELECT api_key FROM emby_configs",
+		"SELECT value FROM system_configs WHERE key = 'tmdb_api_key'",
+		"SELECT totp_secret FROM users WHERE username = 'admin'",
+	} {
+		db.Raw(query).Scan(&raw)
+		if !strings.HasPrefix(raw, "v2:") {
+			t.Errorf("%s 应重新加密到 v2，实际 %q", query, raw)
+		}
+	}
+	var emby EmbyConfig
+	db.First(&emby)
+	if emby.APIKey != apiKey {
+		t.Errorf("轮换后 Emby API Key 解密错误: %q", emby.APIKey)
+	}
+	tmdb = SystemConfig{}
+	db.Where("key = ?", "tmdb_api_key").First(&tmdb)
+	if tmdb.Value != tmdbKey {
+		t.Errorf("轮换后 TMDB API Key 解密错误: %q", tmdb.Value)
+	}
+	db.Raw("SELECT totp_secret FROM users WHERE username = 'admin'").Scan(&raw)
+	if secret, err := util.Decrypt(raw); err != nil || secret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("轮换后两步验证密钥解密错误: %q %v", secret, err)
+	}
+	sqlDB, _ = db.DB()
+	sqlDB.Close()
+
+	// 缺少旧密钥时拒绝启动，避免把密文当作明文二次加密
+	t.Setenv("ENCRYPTION_KEY", "third-encryption-key-0123456789")
+	t.Setenv("ENCRYPTION_KEY_VERSION", "3")
+	t.Setenv("ENCRYPTION_PREVIOUS_KEYS", "")
+	if _, err := InitDB(dbPath); err == nil {
+		t.Error("缺少 v2 密钥时 InitDB 应返回错误")
+	}
+
+	// 密钥配置错误时拒绝启动
+	t.Setenv("ENCRYPTION_PREVIOUS_KEYS", "not-a-version-key")
+	if _, err := InitDB(dbPath); err == nil {
+		t.Error("ENCRYPTION_PREVIOUS_KEYS 格式错误时 InitDB 应返回错误")
+	}
+}
+
+// legacyEncrypt 按升级前的格式加密：密钥补零到 32 字节，无版本前缀
+func legacyEncrypt(t *testing.T, key, plaintext string) string {
+	t.Helper()
+	keyBytes := make([]byte, 32)
+	copy(keyBytes, key)
+	block, _ := aes.NewCipher(keyBytes)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestReencryptProtectedData_UndecryptableLegacyCiphertext(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("InitDB 失败: %v", err)
+	}
+	const apiKey = "0123456789abcdef0123456789abcdef"
+	legacy := legacyEncrypt(t, "old-legacy-key", apiKey)
+	db.Exec("INSERT INTO emby_configs (host, port, api_key, created_at, updated_at) VALUES (?, ?, ?, datetime('now'), datetime('now'))",
+		"http://emby.local", 8096, legacy)
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	// 更换密钥但未提供旧密钥：拒绝启动，不把密文当作明文二次加密
+	t.Setenv("ENCRYPTION_KEY", "new-encryption-key-0123456789")
+	if _, err := InitDB(dbPath); err == nil {
+		t.Fatal("旧格式密文无法解密时 InitDB 应返回错误")
+	}
+
+	// 提供旧密钥后重新加密到当前版本，原值未被损坏
+	t.Setenv("ENCRYPTION_KEY_VERSION", "2")
+	t.Setenv("ENCRYPTION_PREVIOUS_KEYS", "1:old-legacy-key")
+	db, err = InitDB(dbPath)
+	if err != nil {
+		t.Fatalf("提供旧密钥后 InitDB 失败: %v", err)
+	}
+	var raw string
+	db.Raw("SELECT api_key FROM emby_configs").Scan(&raw)
+	if !strings.HasPrefix(raw, "v2:") {
+		t.Errorf("旧格式密文应重新加密到 v2，实际 %q", raw)
+	}
+	var emby EmbyConfig
+	db.First(&emby)
+	if emby.APIKey != apiKey {
+		t.Errorf("重新加密后 Emby API Key 解密错误: %q", emby.APIKey)
+	}
+}
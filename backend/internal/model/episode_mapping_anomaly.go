@@ -14,5 +14,10 @@ type EpisodeMappingAnomaly struct {
 	Difference       int       `gorm:"not null" json:"difference"`        // 差异数
 	LocalSeasonCount int       `gorm:"not null;default:0" json:"local_season_count"` // 本地季数
 	TmdbSeasonCount  int       `gorm:"not null;default:0" json:"tmdb_season_count"`  // TMDB 季数
+	TmdbName         string    `gorm:"size:500;not null;default:''" json:"tmdb_name"`        // TMDB 本地化节目名称
+	TmdbSeasonName   string    `gorm:"size:500;not null;default:''" json:"tmdb_season_name"` // TMDB 本地化季名称
+	EpisodeGroupID   string    `gorm:"size:50;not null;default:''" json:"episode_group_id"`  // 对比所用的 TMDB 剧集组，为空表示默认季结构
+	ReferenceSource  string    `gorm:"size:10;not null;default:'tmdb'" json:"reference_source"` // 对比所用的参考数据源（tmdb/tvdb）
+	TvdbID           int       `gorm:"not null;default:0" json:"tvdb_id"`
 	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// 登录失败原因
+const (
+	LoginFailureUnknownUser = "unknown_user" // 用户不存在
+	LoginFailureBadPassword = "bad_password" // 密码错误
+	LoginFailureBadTOTP     = "bad_totp"     // 两步验证码错误
+)
+
+// LoginFailure 登录失败记录
+// Cleared 表示该用户名随后登录成功，失败次数不再计入锁定判断，但记录保留供管理员查看
+type LoginFailure struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Username  string    `gorm:"size:100;not null;default:'';index:idx_login_failures_username" json:"username"`
+	IP        string    `gorm:"size:100;not null;default:'';index:idx_login_failures_ip" json:"ip"`
+	Reason    string    `gorm:"size:50;not null;default:''" json:"reason"`
+	UserAgent string    `gorm:"size:500;not null;default:''" json:"user_agent"`
+	Cleared   bool      `gorm:"not null" json:"cleared"`
+	CreatedAt time.Time `gorm:"index:idx_login_failures_username;index:idx_login_failures_ip" json:"created_at"`
+}
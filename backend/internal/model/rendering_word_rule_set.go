@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// 规则集版本的变更类型
+const (
+	RuleSetChangeCreate   = "create"
+	RuleSetChangeUpdate   = "update"
+	RuleSetChangeRollback = "rollback"
+	RuleSetChangeImport   = "import"
+)
+
+// RenderingWordRuleSet 渲染词规则集（按剧集保存）
+type RenderingWordRuleSet struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Name      string `gorm:"size:500;not null" json:"name"`
+	TmdbID    int    `gorm:"not null;default:0;index" json:"tmdb_id"`
+	MediaType string `gorm:"size:10;not null;default:'tv'" json:"media_type"`
+	Rules     string `gorm:"type:text;not null;default:'[]'" json:"-"` // JSON: []service.RenderingWordRule
+	Enabled   bool   `gorm:"not null" json:"enabled"`
+	Comment   string `gorm:"type:text;not null;default:''" json:"comment"`
+	Version   int    `gorm:"not null;default:1" json:"version"`    // 当前版本号，每次修改递增
+	SortOrder int    `gorm:"not null;default:0" json:"sort_order"` // 导出顺序，导入时保持原文件中的顺序
+
+	OmitHeader bool      `gorm:"not null" json:"omit_header"` // 导出时不输出 "# 名称" 注释行
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RenderingWordRuleSetVersion 规则集的版本快照
+type RenderingWordRuleSetVersion struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RuleSetID  uint      `gorm:"not null;uniqueIndex:idx_rule_set_versions_set_version" json:"rule_set_id"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_rule_set_versions_set_version" json:"version"`
+	Name       string    `gorm:"size:500;not null" json:"name"`
+	TmdbID     int       `gorm:"not null;default:0" json:"tmdb_id"`
+	MediaType  string    `gorm:"size:10;not null;default:'tv'" json:"media_type"`
+	Rules      string    `gorm:"type:text;not null;default:'[]'" json:"-"`
+	Enabled    bool      `gorm:"not null" json:"enabled"`
+	Comment    string    `gorm:"type:text;not null;default:''" json:"comment"`
+	OmitHeader bool      `gorm:"not null" json:"omit_header"`
+	ChangeType string    `gorm:"size:20;not null;default:''" json:"change_type"` // create / update / rollback / import
+	ChangedBy  string    `gorm:"size:50;not null;default:''" json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Snapshot 生成规则集当前内容的版本快照
+func (rs *RenderingWordRuleSet) Snapshot(changeType, changedBy string) RenderingWordRuleSetVersion {
+	return RenderingWordRuleSetVersion{
+		RuleSetID:  rs.ID,
+		Version:    rs.Version,
+		Name:       rs.Name,
+		TmdbID:     rs.TmdbID,
+		MediaType:  rs.MediaType,
+		Rules:      rs.Rules,
+		Enabled:    rs.Enabled,
+		Comment:    rs.Comment,
+		OmitHeader: rs.OmitHeader,
+		ChangeType: changeType,
+		ChangedBy:  changedBy,
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// 异常映射分析的参考数据源
+const (
+	ReferenceSourceTMDB = "tmdb"
+	ReferenceSourceTVDB = "tvdb"
+)
+
+// SeriesPreference 剧集偏好（按 Emby 剧集设置）
+type SeriesPreference struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	EmbyItemID       string    `gorm:"size:50;not null;uniqueIndex" json:"emby_item_id"`
+	TmdbID           int       `gorm:"not null;default:0" json:"tmdb_id"`
+	EpisodeGroupID   string    `gorm:"size:50;not null;default:''" json:"episode_group_id"`    // 固定的 TMDB 剧集组，为空时使用默认季结构
+	EpisodeGroupName string    `gorm:"size:500;not null;default:''" json:"episode_group_name"` // 剧集组名称（便于展示）
+	ReferenceSource  string    `gorm:"size:10;not null;default:''" json:"reference_source"`    // 参考数据源（tmdb/tvdb），为空时使用全局设置
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
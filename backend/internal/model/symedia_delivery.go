@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Symedia 刷新投递状态
+const (
+	DeliveryStatusPending   = "pending"   // 等待重试
+	DeliveryStatusSucceeded = "succeeded" // 重试成功
+	DeliveryStatusDead      = "dead"      // 超过最大尝试次数，进入死信列表
+)
+
+// SymediaDelivery Symedia 刷新投递记录
+// Webhook 触发的刷新失败后写入，按指数退避重试；刷新目标在每次尝试时按 Webhook 配置重新解析
+type SymediaDelivery struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	WebhookConfigID uint       `gorm:"not null;default:0" json:"webhook_config_id"`
+	WebhookLogID    uint       `gorm:"not null;default:0" json:"webhook_log_id"` // 首次失败的 Webhook 日志
+	Source          string     `gorm:"size:50;not null;default:''" json:"source"`
+	RepoName        string     `gorm:"size:500;not null;default:''" json:"repo_name"`
+	Branch          string     `gorm:"size:100;not null;default:''" json:"branch"`
+	CommitSHA       string     `gorm:"size:100;not null;default:''" json:"commit_sha"`
+	Status          string     `gorm:"size:20;not null;default:'pending';index:idx_symedia_deliveries_status_next" json:"status"`
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"` // 已尝试次数（含首次）
+	MaxAttempts     int        `gorm:"not null;default:6" json:"max_attempts"`
+	NextAttemptAt   *time.Time `gorm:"index:idx_symedia_deliveries_status_next" json:"next_attempt_at"`
+	LastAttemptAt   *time.Time `json:"last_attempt_at"`
+	LastError       string     `gorm:"type:text;not null;default:''" json:"last_error"`
+	DeliveredAt     *time.Time `json:"delivered_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
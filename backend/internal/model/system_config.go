@@ -21,6 +21,17 @@ type SystemConfig struct {
 // 需要加密的配置键列表
 var encryptedKeys = map[string]bool{
 	"symedia_auth_token": true,
+	"tmdb_access_token":  true,
+	"tmdb_api_key":       true,
+	"tvdb_api_key":       true,
+	"tvdb_pin":           true,
+	"github_token":       true,
+	"oidc_client_secret": true,
+}
+
+// IsEncryptedConfigKey 判断配置项是否为加密存储的敏感配置
+func IsEncryptedConfigKey(key string) bool {
+	return encryptedKeys[key]
 }
 
 // BeforeSave GORM钩子：保存前加密敏感字段
@@ -1,15 +1,105 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"embyforge/internal/tmdb"
+)
 
 // TmdbCache TMDB 季集数据缓存模型
 type TmdbCache struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
 	TmdbID       int       `gorm:"not null;uniqueIndex:idx_tmdb_cache_tmdb_season" json:"tmdb_id"`
-	Name         string    `gorm:"size:500;not null;default:''" json:"name"`           // 节目名称
+	Name         string    `gorm:"size:500;not null;default:''" json:"name"` // 节目名称（按语言优先级选取）
 	SeasonNumber int       `gorm:"not null;uniqueIndex:idx_tmdb_cache_tmdb_season" json:"season_number"`
 	EpisodeCount int       `gorm:"not null;default:0" json:"episode_count"`
-	SeasonName   string    `gorm:"size:500;not null;default:''" json:"season_name"`    // 季名称
+	SeasonName   string    `gorm:"size:500;not null;default:''" json:"season_name"`   // 季名称
+	Translations string    `gorm:"type:text;not null;default:''" json:"translations"` // 多语言名称 JSON: 语言 -> TmdbTranslation
 	CachedAt     time.Time `gorm:"not null" json:"cached_at"`
 	UpdatedAt    time.Time `gorm:"not null" json:"updated_at"`
 }
+
+// TmdbTranslation 某一语言下的节目名称和季名称
+type TmdbTranslation struct {
+	Name       string `json:"name,omitempty"`
+	SeasonName string `json:"season_name,omitempty"`
+}
+
+// NewTmdbCachesFromDetails 从 TMDB 电视节目详情创建每季一条的缓存记录
+// langs 为请求时使用的语言优先级，首个语言即详情请求的 language 参数
+func NewTmdbCachesFromDetails(details *tmdb.TVShowDetails, langs []string) []TmdbCache {
+	now := time.Now()
+	caches := make([]TmdbCache, 0, len(details.Seasons))
+	for _, season := range details.Seasons {
+		tc := TmdbCache{
+			TmdbID:       details.ID,
+			Name:         details.LocalizedName(langs),
+			SeasonNumber: season.SeasonNumber,
+			EpisodeCount: season.EpisodeCount,
+			SeasonName:   season.Name,
+			CachedAt:     now,
+			UpdatedAt:    now,
+		}
+
+		translations := make(map[string]TmdbTranslation)
+		for i, lang := range langs {
+			t := TmdbTranslation{Name: details.TranslatedName(lang)}
+			if i == 0 {
+				// 季名称只有请求语言的版本；未附带 translations 时节目名称也取请求语言的结果
+				t.SeasonName = season.Name
+				if details.Translations == nil {
+					t.Name = details.Name
+				}
+			}
+			if t.Name != "" || t.SeasonName != "" {
+				translations[lang] = t
+			}
+		}
+		tc.SetTranslations(translations)
+		caches = append(caches, tc)
+	}
+	return caches
+}
+
+// GetTranslations 解析多语言名称，数据为空或格式错误时返回空 map
+func (tc *TmdbCache) GetTranslations() map[string]TmdbTranslation {
+	translations := make(map[string]TmdbTranslation)
+	if tc.Translations != "" {
+		_ = json.Unmarshal([]byte(tc.Translations), &translations)
+	}
+	return translations
+}
+
+// SetTranslations 序列化多语言名称
+func (tc *TmdbCache) SetTranslations(translations map[string]TmdbTranslation) {
+	if len(translations) == 0 {
+		tc.Translations = ""
+		return
+	}
+	if data, err := json.Marshal(translations); err == nil {
+		tc.Translations = string(data)
+	}
+}
+
+// LocalizedNames 按语言优先级返回节目名称和季名称
+// 依次尝试各语言的翻译，均缺失时回退到 Name / SeasonName 字段
+func (tc *TmdbCache) LocalizedNames(langs []string) (name, seasonName string) {
+	translations := tc.GetTranslations()
+	for _, lang := range langs {
+		t := translations[lang]
+		if name == "" {
+			name = t.Name
+		}
+		if seasonName == "" {
+			seasonName = t.SeasonName
+		}
+	}
+	if name == "" {
+		name = tc.Name
+	}
+	if seasonName == "" {
+		seasonName = tc.SeasonName
+	}
+	return name, seasonName
+}
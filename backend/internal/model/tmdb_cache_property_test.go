@@ -0,0 +1,95 @@
+package model
+
+import (
+	"testing"
+
+	"embyforge/internal/tmdb"
+
+	"pgregory.net/rapid"
+)
+
+// Feature: tmdb-localization, Property 1: 本地化名称按语言优先级回退
+// 对于任意多语言名称和语言优先级，LocalizedNames 返回第一个有翻译的语言的名称，均缺失时回退到默认字段。
+func TestProperty_TmdbCacheLocalizedNamesFallback(t *testing.T) {
+	allLangs := []string{"zh-CN", "zh-TW", "en-US", "ja-JP"}
+
+	rapid.Check(t, func(t *rapid.T) {
+		translations := make(map[string]TmdbTranslation)
+		for _, lang := range allLangs {
+			if rapid.Bool().Draw(t, "has_"+lang) {
+				translations[lang] = TmdbTranslation{
+					Name:       rapid.StringMatching(`[a-z]{0,5}`).Draw(t, "name_"+lang),
+					SeasonName: rapid.StringMatching(`[a-z]{0,5}`).Draw(t, "season_"+lang),
+				}
+			}
+		}
+		langs := rapid.SliceOfDistinct(rapid.SampledFrom(allLangs), func(s string) string { return s }).Draw(t, "langs")
+
+		tc := TmdbCache{Name: "default", SeasonName: "Season 1"}
+		tc.SetTranslations(translations)
+
+		// round-trip
+		if got := tc.GetTranslations(); len(got) != len(translations) {
+			t.Fatalf("多语言名称 round-trip 失败: 期望 %d 项，实际 %d 项", len(translations), len(got))
+		}
+
+		wantName, wantSeason := "", ""
+		for _, lang := range langs {
+			if wantName == "" {
+				wantName = translations[lang].Name
+			}
+			if wantSeason == "" {
+				wantSeason = translations[lang].SeasonName
+			}
+		}
+		if wantName == "" {
+			wantName = tc.Name
+		}
+		if wantSeason == "" {
+			wantSeason = tc.SeasonName
+		}
+
+		name, seasonName := tc.LocalizedNames(langs)
+		if name != wantName || seasonName != wantSeason {
+			t.Fatalf("LocalizedNames(%v) = (%q, %q), 期望 (%q, %q)", langs, name, seasonName, wantName, wantSeason)
+		}
+	})
+}
+
+// TestNewTmdbCachesFromDetails 从多语言详情生成的缓存记录包含各语言名称
+func TestNewTmdbCachesFromDetails(t *testing.T) {
+	details := &tmdb.TVShowDetails{
+		ID:   42,
+		Name: "Kimetsu no Yaiba",
+		Seasons: []tmdb.Season{
+			{SeasonNumber: 1, EpisodeCount: 26, Name: "第 1 季"},
+		},
+		Translations: &tmdb.TranslationList{Translations: []tmdb.Translation{
+			{ISO639: "zh", ISO3166: "CN", Data: tmdb.TranslationData{Name: "鬼灭之刃"}},
+			{ISO639: "en", ISO3166: "US", Data: tmdb.TranslationData{Name: "Demon Slayer"}},
+		}},
+	}
+
+	caches := NewTmdbCachesFromDetails(details, []string{"zh-CN", "en-US"})
+	if len(caches) != 1 {
+		t.Fatalf("期望 1 条缓存记录，实际 %d 条", len(caches))
+	}
+	tc := caches[0]
+	if tc.TmdbID != 42 || tc.Name != "鬼灭之刃" || tc.SeasonName != "第 1 季" {
+		t.Errorf("缓存记录不正确: %+v", tc)
+	}
+
+	translations := tc.GetTranslations()
+	if translations["zh-CN"] != (TmdbTranslation{Name: "鬼灭之刃", SeasonName: "第 1 季"}) {
+		t.Errorf("zh-CN 翻译不正确: %+v", translations["zh-CN"])
+	}
+	if translations["en-US"] != (TmdbTranslation{Name: "Demon Slayer"}) {
+		t.Errorf("en-US 翻译不正确: %+v", translations["en-US"])
+	}
+
+	// 英文优先时节目名称取英文翻译，季名称回退到请求语言的结果
+	name, seasonName := tc.LocalizedNames([]string{"en-US", "zh-CN"})
+	if name != "Demon Slayer" || seasonName != "第 1 季" {
+		t.Errorf("LocalizedNames 结果不正确: (%q, %q)", name, seasonName)
+	}
+}
@@ -2,12 +2,53 @@ package model
 
 import "time"
 
+// 用户角色
+const (
+	RoleAdmin    = "admin"    // 管理员：全部权限，包括删除媒体、修改 Emby/TMDB/Symedia/Webhook 配置和用户管理
+	RoleOperator = "operator" // 操作员：可执行同步、分析和规则维护
+	RoleViewer   = "viewer"   // 只读：查看仪表盘和异常列表
+)
+
+// roleLevels 角色权限等级，等级高的角色拥有等级低的角色的全部权限
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// IsValidRole 判断角色名是否有效
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAtLeast 判断角色是否拥有 required 角色的权限，无效角色没有任何权限
+func RoleAtLeast(role, required string) bool {
+	level, ok := roleLevels[role]
+	return ok && level >= roleLevels[required]
+}
+
 // User 用户模型
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Username  string    `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	Password  string    `gorm:"size:255;not null" json:"-"` // bcrypt 哈希，JSON 序列化时隐藏
-	Avatar    string    `gorm:"size:500" json:"avatar"`     // 头像文件路径
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	Username           string    `gorm:"uniqueIndex;size:50;not null" json:"username"`
+	Password           string    `gorm:"size:255;not null" json:"-"` // bcrypt 哈希，JSON 序列化时隐藏
+	Avatar             string    `gorm:"size:500" json:"avatar"`     // 头像文件路径
+	Role               string    `gorm:"size:20;not null;default:'viewer'" json:"role"`
+	TOTPSecret         string    `gorm:"column:totp_secret;type:text;not null;default:''" json:"-"` // 加密后的两步验证密钥，启用前为待确认的密钥
+	TOTPEnabled        bool      `gorm:"column:totp_enabled;not null" json:"totp_enabled"`
+	TOTPLastStep       int64     `gorm:"column:totp_last_step;not null;default:0" json:"-"`                // 最近一次通过验证的时间步，防止验证码重复使用
+	OIDCSubject        string    `gorm:"column:oidc_subject;size:300;not null;default:'';index" json:"-"`  // 绑定的单点登录身份（issuer|sub）
+	MustChangePassword bool      `gorm:"column:must_change_password;not null" json:"must_change_password"` // 使用初始密码，修改密码前不能访问其他接口
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// UserRecoveryCode 两步验证恢复码，只保存哈希，使用后作废
+type UserRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// UserSession 登录会话
+// 刷新令牌只保存 SHA-256 哈希，每次刷新时轮换；PreviousTokenHash 用于识别被重放的旧刷新令牌
+type UserSession struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"not null;index" json:"user_id"`
+	RefreshTokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	PreviousTokenHash string     `gorm:"size:64;not null;default:'';index" json:"-"`
+	UserAgent         string     `gorm:"size:500;not null;default:''" json:"user_agent"`
+	IP                string     `gorm:"size:100;not null;default:''" json:"ip"`
+	LastUsedAt        *time.Time `json:"last_used_at"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// Active 会话未撤销且未过期
+func (s *UserSession) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -8,22 +8,38 @@ import (
 	"gorm.io/gorm"
 )
 
-// WebhookConfig GitHub Webhook配置模型
+// Webhook 推送来源
+const (
+	WebhookProviderGitHub = "github"
+	WebhookProviderGitea  = "gitea"
+	WebhookProviderGitLab = "gitlab"
+)
+
+// WebhookConfig Git仓库 Webhook配置模型，每个配置通过自己的 Webhook URL 触发对应的 Symedia 刷新
 type WebhookConfig struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
+	Name       string    `gorm:"size:100;not null;default:''" json:"name"`           // 配置名称
+	Provider   string    `gorm:"size:20;not null;default:'github'" json:"provider"` // 推送来源：github / gitea / gitlab
 	SymediaUrl string    `gorm:"size:500;not null" json:"symedia_url"`       // Symedia服务地址
 	AuthToken  string    `gorm:"type:text;not null" json:"auth_token"`       // Authorization令牌（加密存储）
 	RepoUrl    string    `gorm:"size:500;not null" json:"repo_url"`          // GitHub仓库URL
 	Branch     string    `gorm:"size:100;not null;default:'main'" json:"branch"` // 监听的分支
-	FilePath   string    `gorm:"size:500" json:"file_path"`                  // 监听的文件路径（可选，为空或"*"表示监听所有文件）
+	FilePath   string    `gorm:"size:500" json:"file_path"`                  // 监听的文件路径（可选，为空或"*"表示监听所有文件；支持通配符，多个路径用逗号或换行分隔）
 	Secret     string    `gorm:"size:500;not null" json:"secret"`            // Webhook密钥（加密存储）
 	WebhookUrl string    `gorm:"size:500;not null" json:"webhook_url"`       // 生成的Webhook URL
+	LastPublishedSHA string     `gorm:"size:100;not null;default:''" json:"last_published_sha"` // 最近一次从 EmbyForge 发布渲染词的提交SHA
+	LastPublishedAt  *time.Time `json:"last_published_at"`                                      // 最近一次发布时间
+	ImportedSHA      string     `gorm:"size:100;not null;default:''" json:"imported_sha"`       // 从仓库导入的无标记文件版本，下次发布时整体替换该文件
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // BeforeSave GORM钩子：保存前加密敏感字段
 func (w *WebhookConfig) BeforeSave(tx *gorm.DB) error {
+	if w.Provider == "" {
+		w.Provider = WebhookProviderGitHub
+	}
+
 	// 加密 AuthToken
 	if w.AuthToken != "" {
 		encrypted, err := util.Encrypt(w.AuthToken)
@@ -5,10 +5,13 @@ import "time"
 // WebhookLog Webhook触发的配置刷新日志模型
 type WebhookLog struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	Source    string    `gorm:"size:50;not null" json:"source"`     // 触发来源："github" 或 "manual"
+	Source    string    `gorm:"size:50;not null" json:"source"`     // 触发来源："github"、"gitea"、"gitlab"、"publish" 或 "manual"
+	WebhookConfigID uint `gorm:"not null;default:0" json:"webhook_config_id"` // 触发的Webhook配置，0 表示非Webhook触发
+	DeliveryID string  `gorm:"size:100;not null;default:'';index" json:"delivery_id"` // 推送投递ID（X-GitHub-Delivery 等），用于拒绝重复投递
 	RepoName  string    `gorm:"size:500" json:"repo_name"`          // 仓库名称
 	Branch    string    `gorm:"size:100" json:"branch"`             // 分支名称
 	CommitSHA string    `gorm:"size:100" json:"commit_sha"`         // 提交SHA
+	MatchedFiles string `gorm:"type:text;not null;default:'[]'" json:"-"` // JSON: 匹配监听路径的文件列表
 	Success   bool      `gorm:"not null" json:"success"`            // 是否成功
 	ErrorMsg  string    `gorm:"type:text" json:"error_msg"`         // 错误消息
 	CreatedAt time.Time `gorm:"index" json:"created_at"`            // 创建时间（带索引）
@@ -0,0 +1,71 @@
+// Package respcache 提供元数据 API 客户端（TMDB、TVDB）共用的响应内存缓存
+package respcache
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTTL 响应缓存默认有效期
+const DefaultTTL = time.Hour
+
+// Cache 响应内存缓存
+// 以「基础地址 + 路径 + 查询参数」为键缓存成功响应
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+// cacheEntry 缓存条目
+type cacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// New 创建响应缓存
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Get 读取未过期的缓存响应
+func (rc *Cache) Get(key string) ([]byte, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(rc.entries, key)
+		return nil, false
+	}
+	return entry.body, true
+}
+
+// Set 写入缓存响应，条目数每增长 256 条顺带清理一次已过期的条目
+func (rc *Cache) Set(key string, body []byte) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := time.Now()
+	if len(rc.entries)%256 == 255 {
+		for k, e := range rc.entries {
+			if now.After(e.expiresAt) {
+				delete(rc.entries, k)
+			}
+		}
+	}
+	rc.entries[key] = cacheEntry{body: body, expiresAt: now.Add(rc.ttl)}
+}
+
+// Clear 清空所有缓存条目
+func (rc *Cache) Clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries = make(map[string]cacheEntry)
+}
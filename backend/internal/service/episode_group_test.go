@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/tmdb"
+)
+
+// TestAnalyzeEpisodeMapping_PinnedEpisodeGroup 固定剧集组后按剧集组的分组对比，不再误报
+func TestAnalyzeEpisodeMapping_PinnedEpisodeGroup(t *testing.T) {
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "episode_group.db"))
+	if err != nil {
+		t.Fatalf("InitDB 失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// 本地按两季（各 12 集）整理，TMDB 默认季结构只有一季 24 集
+	db.Create(&model.MediaCache{
+		EmbyItemID: "series-1", Name: "Show", Type: "Series",
+		ProviderIDs: `{"Tmdb":"500"}`, CachedAt: time.Now(),
+	})
+	for i, n := range []int{1, 2} {
+		db.Create(&model.SeasonCache{
+			SeriesEmbyItemID: "series-1",
+			SeasonEmbyItemID: "season-" + string(rune('a'+i)),
+			SeasonNumber:     n,
+			EpisodeCount:     12,
+			CachedAt:         time.Now(),
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/3/tv/500", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tmdb.TVShowDetails{
+			ID: 500, Name: "Show",
+			Seasons: []tmdb.Season{{SeasonNumber: 1, EpisodeCount: 24}},
+		})
+	})
+	mux.HandleFunc("/3/tv/episode_group/grp-1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tmdb.EpisodeGroupDetails{
+			ID: "grp-1", Name: "Production Order",
+			Groups: []tmdb.EpisodeGroup{
+				{Name: "Cour 1", Order: 0, Episodes: make([]tmdb.EpisodeGroupEntry, 12)},
+				{Name: "Cour 2", Order: 1, Episodes: make([]tmdb.EpisodeGroupEntry, 12)},
+			},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tmdbClient := &tmdb.Client{APIKey: "test-key", BaseURL: server.URL, HTTPClient: server.Client()}
+	scanService := NewScanService(db)
+
+	// 未固定剧集组：S2 在 TMDB 中不存在，S1 集数不一致
+	result, err := scanService.AnalyzeEpisodeMappingFromCacheWithContext(context.Background(), tmdbClient)
+	if err != nil {
+		t.Fatalf("分析失败: %v", err)
+	}
+	if result.AnomalyCount != 2 {
+		t.Fatalf("未固定剧集组时期望 2 条异常，实际 %d 条", result.AnomalyCount)
+	}
+
+	// 固定剧集组后两季集数一致
+	db.Create(&model.SeriesPreference{EmbyItemID: "series-1", TmdbID: 500, EpisodeGroupID: "grp-1"})
+	result, err = scanService.AnalyzeEpisodeMappingFromCacheWithContext(context.Background(), tmdbClient)
+	if err != nil {
+		t.Fatalf("分析失败: %v", err)
+	}
+	if result.AnomalyCount != 0 || result.ErrorCount != 0 {
+		t.Fatalf("固定剧集组后不应有异常: %+v", result)
+	}
+
+	// 剧集组集数与本地不一致时，异常记录标明对比所用的剧集组
+	db.Model(&model.SeasonCache{}).Where("season_number = 2").Update("episode_count", 13)
+	if _, err := scanService.AnalyzeEpisodeMappingFromCacheWithContext(context.Background(), tmdbClient); err != nil {
+		t.Fatalf("分析失败: %v", err)
+	}
+	var anomalies []model.EpisodeMappingAnomaly
+	db.Find(&anomalies)
+	if len(anomalies) != 1 || anomalies[0].EpisodeGroupID != "grp-1" || anomalies[0].TmdbSeasonName != "Cour 2" {
+		t.Errorf("异常记录不正确: %+v", anomalies)
+	}
+}
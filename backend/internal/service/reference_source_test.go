@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"embyforge/internal/model"
+	"embyforge/internal/tmdb"
+	"embyforge/internal/tvdb"
+)
+
+// TestAnalyzeEpisodeMapping_TVDBReferenceSource 按全局或剧集设置选择 TVDB 作为参考数据源
+func TestAnalyzeEpisodeMapping_TVDBReferenceSource(t *testing.T) {
+	db, err := model.InitDB(filepath.Join(t.TempDir(), "reference_source.db"))
+	if err != nil {
+		t.Fatalf("InitDB 失败: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// 本地按 TVDB 的两季整理（各 10 集），TMDB 合并为一季 20 集
+	db.Create(&model.MediaCache{
+		EmbyItemID: "series-1", Name: "Show", Type: "Series",
+		ProviderIDs: `{"Tmdb":"500","Tvdb":"700"}`, CachedAt: time.Now(),
+	})
+	db.Create(&model.SeasonCache{SeriesEmbyItemID: "series-1", SeasonEmbyItemID: "s1", SeasonNumber: 1, EpisodeCount: 10, CachedAt: time.Now()})
+	db.Create(&model.SeasonCache{SeriesEmbyItemID: "series-1", SeasonEmbyItemID: "s2", SeasonNumber: 2, EpisodeCount: 10, CachedAt: time.Now()})
+
+	tmdbServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tmdb.TVShowDetails{ID: 500, Name: "Show", Seasons: []tmdb.Season{{SeasonNumber: 1, EpisodeCount: 20}}})
+	}))
+	defer tmdbServer.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"token":"t"}}`))
+	})
+	mux.HandleFunc("/v4/series/700/episodes/default", func(w http.ResponseWriter, r *http.Request) {
+		type episode struct {
+			SeasonNumber int `json:"seasonNumber"`
+			Number       int `json:"number"`
+		}
+		var episodes []episode
+		for season := 1; season <= 2; season++ {
+			for n := 1; n <= 10; n++ {
+				episodes = append(episodes, episode{season, n})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":  map[string]interface{}{"series": map[string]interface{}{"id": 700, "name": "Show"}, "episodes": episodes},
+			"links": map[string]interface{}{"next": nil},
+		})
+	})
+	tvdbServer := httptest.NewServer(mux)
+	defer tvdbServer.Close()
+
+	tmdbClient := &tmdb.Client{APIKey: "test-key", BaseURL: tmdbServer.URL, HTTPClient: tmdbServer.Client()}
+	tvdbClient, err := tvdb.NewClientWithOptions(tvdb.Options{APIKey: "key", BaseURL: tvdbServer.URL})
+	if err != nil {
+		t.Fatalf("创建 TVDB 客户端失败: %v", err)
+	}
+	tvdbClient.Cache = nil
+
+	scanService := NewScanService(db)
+	analyze := func(sources EpisodeMappingSources) []model.EpisodeMappingAnomaly {
+		t.Helper()
+		if _, err := scanService.AnalyzeEpisodeMappingFromCacheWithSources(context.Background(), sources); err != nil {
+			t.Fatalf("分析失败: %v", err)
+		}
+		var anomalies []model.EpisodeMappingAnomaly
+		db.Find(&anomalies)
+		return anomalies
+	}
+
+	// 默认以 TMDB 为参考：两季都不一致
+	if anomalies := analyze(EpisodeMappingSources{TMDB: tmdbClient, TVDB: tvdbClient}); len(anomalies) != 2 || anomalies[0].ReferenceSource != model.ReferenceSourceTMDB {
+		t.Fatalf("TMDB 参考下期望 2 条异常: %+v", anomalies)
+	}
+
+	// 全局选择 TVDB：季结构一致
+	if anomalies := analyze(EpisodeMappingSources{TMDB: tmdbClient, TVDB: tvdbClient, DefaultSource: model.ReferenceSourceTVDB}); len(anomalies) != 0 {
+		t.Fatalf("TVDB 参考下不应有异常: %+v", anomalies)
+	}
+
+	// 未配置 TVDB 时回退到 TMDB
+	if anomalies := analyze(EpisodeMappingSources{TMDB: tmdbClient, DefaultSource: model.ReferenceSourceTVDB}); len(anomalies) != 2 {
+		t.Fatalf("未配置 TVDB 时应回退到 TMDB: %+v", anomalies)
+	}
+
+	// 剧集单独选择 TVDB 优先于全局设置；集数不一致时异常记录标明数据源
+	db.Create(&model.SeriesPreference{EmbyItemID: "series-1", ReferenceSource: model.ReferenceSourceTVDB})
+	db.Model(&model.SeasonCache{}).Where("season_number = 2").Update("episode_count", 11)
+	anomalies := analyze(EpisodeMappingSources{TMDB: tmdbClient, TVDB: tvdbClient})
+	if len(anomalies) != 1 || anomalies[0].ReferenceSource != model.ReferenceSourceTVDB || anomalies[0].TvdbID != 700 || anomalies[0].TmdbEpisodes != 10 {
+		t.Fatalf("剧集设置 TVDB 参考时异常记录不正确: %+v", anomalies)
+	}
+}
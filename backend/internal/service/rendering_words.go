@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 渲染词规则类型
+const (
+	RuleTypeMapping = "mapping" // 季集映射：@?{[tmdbid=..;type=tv;s=..;e=..]} => {[s=..;e=EP±n]}
+	RuleTypeReplace = "replace" // 替换词：被替换词 => 替换词（可附带集偏移）
+	RuleTypeBlock   = "block"   // 屏蔽词：整行只有一个词
+	RuleTypeOffset  = "offset"  // 集偏移：前定位词 <> 后定位词 >> EP±n
+	RuleTypeRaw     = "raw"     // 原样保留的行：导入时无法识别的行或注释，生成时按 Pattern 原样输出
+)
+
+// Symedia 自定义渲染词的分隔符
+const (
+	replaceSeparator = " => "
+	offsetSeparator  = " >> "
+	anchorSeparator  = " <> "
+	comboSeparator   = " && "
+)
+
+var (
+	// offsetPattern 集偏移表达式：EP、EP+n、EP-n
+	offsetPattern = regexp.MustCompile(`^EP([+-]\d+)?$`)
+	// episodeRangePattern 集数范围：n 或 n-m
+	episodeRangePattern = regexp.MustCompile(`^(\d+)(?:-(\d+))?$`)
+)
+
+// RenderingWordRule 渲染词规则
+type RenderingWordRule struct {
+	Type string `json:"type"`
+
+	// 季集映射
+	SourceSeason   int    `json:"source_season,omitempty"`
+	SourceEpisodes string `json:"source_episodes,omitempty"`
+	TargetSeason   int    `json:"target_season,omitempty"`
+
+	// 替换词 / 屏蔽词 / 集偏移
+	Pattern     string `json:"pattern,omitempty"`     // 被替换词或屏蔽词
+	Replacement string `json:"replacement,omitempty"` // 替换词
+	Prefix      string `json:"prefix,omitempty"`      // 集偏移前定位词
+	Suffix      string `json:"suffix,omitempty"`      // 集偏移后定位词
+
+	Offset  string `json:"offset,omitempty"`  // 集偏移表达式 EP / EP+n / EP-n
+	Comment string `json:"comment,omitempty"` // 规则说明，生成时作为注释行放在规则前
+}
+
+// RenderingWordSeries 一部剧集的渲染词规则
+type RenderingWordSeries struct {
+	Name      string              `json:"name"`
+	TmdbID    int                 `json:"tmdb_id"`
+	MediaType string              `json:"media_type"` // tv / movie，为空时视为 tv
+	Rules     []RenderingWordRule `json:"rules"`
+
+	// OmitHeader 生成时不输出 "# 剧集名" 注释行（导入时原文没有名称注释的块）
+	OmitHeader bool `json:"omit_header,omitempty"`
+}
+
+// RuleValidationError 规则校验错误，指明出错的剧集和规则
+type RuleValidationError struct {
+	SeriesIndex int    `json:"series_index"` // 从 0 开始
+	SeriesName  string `json:"series_name"`
+	RuleIndex   int    `json:"rule_index"` // 从 0 开始，-1 表示剧集本身的错误
+	Field       string `json:"field"`
+	Message     string `json:"message"`
+}
+
+func (e RuleValidationError) Error() string {
+	if e.RuleIndex < 0 {
+		return fmt.Sprintf("剧集 %q: %s", e.SeriesName, e.Message)
+	}
+	return fmt.Sprintf("剧集 %q 第 %d 条规则: %s", e.SeriesName, e.RuleIndex+1, e.Message)
+}
+
+// GenerateRenderingWords 将规则生成为 Symedia 自定义渲染词文本
+// 每部剧集以 "# 剧集名" 注释开头（OmitHeader 时省略），剧集之间空一行；存在校验错误时不生成文本
+func GenerateRenderingWords(seriesList []RenderingWordSeries) (string, []RuleValidationError) {
+	var errs []RuleValidationError
+	for i, series := range seriesList {
+		errs = append(errs, ValidateRenderingWordSeries(i, series)...)
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	var blocks []string
+	for _, series := range seriesList {
+		if len(series.Rules) == 0 {
+			continue
+		}
+		lines := RenderingWordLines(series)
+		if !series.OmitHeader {
+			lines = append([]string{"# " + series.Name}, lines...)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+// FormatRenderingWordRule 生成单条规则的渲染词文本（调用前应已通过校验）
+func FormatRenderingWordRule(series RenderingWordSeries, rule RenderingWordRule) string {
+	switch rule.Type {
+	case RuleTypeMapping:
+		return fmt.Sprintf("@?{[tmdbid=%d;type=%s;s=%d;e=%s]} => {[s=%d;e=%s]}",
+			series.TmdbID, mediaTypeOrDefault(series.MediaType), rule.SourceSeason, rule.SourceEpisodes,
+			rule.TargetSeason, rule.Offset)
+	case RuleTypeReplace:
+		line := rule.Pattern + replaceSeparator + rule.Replacement
+		if rule.Offset != "" {
+			line += comboSeparator + formatOffset(rule)
+		}
+		return line
+	case RuleTypeOffset:
+		return formatOffset(rule)
+	default:
+		return rule.Pattern
+	}
+}
+
+// formatOffset 生成集偏移部分：前定位词 <> 后定位词 >> EP±n
+func formatOffset(rule RenderingWordRule) string {
+	return rule.Prefix + anchorSeparator + rule.Suffix + offsetSeparator + rule.Offset
+}
+
+// mediaTypeOrDefault 媒体类型为空时视为电视剧
+func mediaTypeOrDefault(mediaType string) string {
+	if mediaType == "" {
+		return "tv"
+	}
+	return mediaType
+}
+
+// ValidateRenderingWordSeries 校验一部剧集的全部规则
+func ValidateRenderingWordSeries(seriesIndex int, series RenderingWordSeries) []RuleValidationError {
+	var errs []RuleValidationError
+	fail := func(ruleIndex int, field, format string, args ...interface{}) {
+		errs = append(errs, RuleValidationError{
+			SeriesIndex: seriesIndex,
+			SeriesName:  series.Name,
+			RuleIndex:   ruleIndex,
+			Field:       field,
+			Message:     fmt.Sprintf(format, args...),
+		})
+	}
+
+	if strings.TrimSpace(series.Name) == "" {
+		fail(-1, "name", "剧集名称不能为空")
+	}
+	if strings.ContainsAny(series.Name, "\r\n") {
+		fail(-1, "name", "剧集名称不能包含换行")
+	}
+	if mt := mediaTypeOrDefault(series.MediaType); mt != "tv" && mt != "movie" {
+		fail(-1, "media_type", "媒体类型只能是 tv 或 movie")
+	}
+
+	for i, rule := range series.Rules {
+		if strings.ContainsAny(rule.Comment, "\r") {
+			fail(i, "comment", "说明不能包含回车符")
+		}
+
+		switch rule.Type {
+		case RuleTypeMapping:
+			if series.TmdbID <= 0 {
+				fail(i, "tmdb_id", "季集映射规则需要有效的 TMDB ID")
+			}
+			if rule.SourceSeason < 0 {
+				fail(i, "source_season", "源季号不能为负数")
+			}
+			if rule.TargetSeason < 0 {
+				fail(i, "target_season", "目标季号不能为负数")
+			}
+			if msg := validateEpisodeRange(rule.SourceEpisodes); msg != "" {
+				fail(i, "source_episodes", "%s", msg)
+			}
+			if !offsetPattern.MatchString(rule.Offset) {
+				fail(i, "offset", "集偏移 %q 格式错误，应为 EP、EP+n 或 EP-n", rule.Offset)
+			}
+
+		case RuleTypeReplace:
+			if msg := validateWord(rule.Pattern, true); msg != "" {
+				fail(i, "pattern", "被替换词%s", msg)
+			}
+			if msg := validateWord(rule.Replacement, false); msg != "" {
+				fail(i, "replacement", "替换词%s", msg)
+			}
+			if rule.Offset != "" || rule.Prefix != "" || rule.Suffix != "" {
+				for _, e := range validateOffsetFields(rule) {
+					fail(i, e[0], "%s", e[1])
+				}
+			}
+
+		case RuleTypeOffset:
+			for _, e := range validateOffsetFields(rule) {
+				fail(i, e[0], "%s", e[1])
+			}
+
+		case RuleTypeBlock:
+			if msg := validateWord(rule.Pattern, true); msg != "" {
+				fail(i, "pattern", "屏蔽词%s", msg)
+			} else if strings.HasPrefix(strings.TrimSpace(rule.Pattern), "#") {
+				fail(i, "pattern", "屏蔽词不能以 # 开头（会被识别为注释）")
+			}
+
+		case RuleTypeRaw:
+			if strings.TrimSpace(rule.Pattern) == "" {
+				fail(i, "pattern", "原样保留的行不能为空")
+			} else if strings.ContainsAny(rule.Pattern, "\r\n") {
+				fail(i, "pattern", "原样保留的行不能包含换行")
+			}
+
+		default:
+			fail(i, "type", "未知的规则类型 %q", rule.Type)
+		}
+	}
+	return errs
+}
+
+// validateOffsetFields 校验集偏移的定位词和偏移量，返回 [字段, 错误信息] 列表
+func validateOffsetFields(rule RenderingWordRule) [][2]string {
+	var errs [][2]string
+	if strings.TrimSpace(rule.Prefix) == "" && strings.TrimSpace(rule.Suffix) == "" {
+		errs = append(errs, [2]string{"prefix", "集偏移至少需要前定位词或后定位词之一"})
+	}
+	if msg := validateWord(rule.Prefix, false); msg != "" {
+		errs = append(errs, [2]string{"prefix", "前定位词" + msg})
+	}
+	if msg := validateWord(rule.Suffix, false); msg != "" {
+		errs = append(errs, [2]string{"suffix", "后定位词" + msg})
+	}
+	if !offsetPattern.MatchString(rule.Offset) {
+		errs = append(errs, [2]string{"offset", fmt.Sprintf("集偏移 %q 格式错误，应为 EP、EP+n 或 EP-n", rule.Offset)})
+	}
+	return errs
+}
+
+// validateWord 校验词语不包含换行和渲染词分隔符，返回错误描述（不含主语）
+func validateWord(word string, required bool) string {
+	if required && strings.TrimSpace(word) == "" {
+		return "不能为空"
+	}
+	if strings.ContainsAny(word, "\r\n") {
+		return "不能包含换行"
+	}
+	for _, sep := range []string{replaceSeparator, offsetSeparator, anchorSeparator, comboSeparator} {
+		if strings.Contains(word, sep) {
+			return fmt.Sprintf("不能包含分隔符 %q", strings.TrimSpace(sep))
+		}
+	}
+	return ""
+}
+
+// validateEpisodeRange 校验集数范围 n 或 n-m，返回错误描述
+func validateEpisodeRange(episodes string) string {
+	m := episodeRangePattern.FindStringSubmatch(episodes)
+	if m == nil {
+		return fmt.Sprintf("集数范围 %q 格式错误，应为 n 或 n-m", episodes)
+	}
+	start, _ := strconv.Atoi(m[1])
+	if start < 1 {
+		return "集数范围必须从 1 开始"
+	}
+	if m[2] != "" {
+		end, _ := strconv.Atoi(m[2])
+		if end < start {
+			return fmt.Sprintf("集数范围 %q 的结束集小于起始集", episodes)
+		}
+	}
+	return ""
+}
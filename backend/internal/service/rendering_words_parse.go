@@ -0,0 +1,220 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mappingLinePattern 季集映射行：@?{[tmdbid=..;type=..;s=..;e=..]} => {[s=..;e=EP±n]}
+var mappingLinePattern = regexp.MustCompile(`^@\?\{\[tmdbid=(\d+);type=(tv|movie);s=(\d+);e=([^;\]]+)\]\} => \{\[s=(\d+);e=([^\]]+)\]\}$`)
+
+// ParseRenderingWords 将 Symedia 自定义渲染词文本解析为按剧集分组的规则
+//
+// 解析约定与 GenerateRenderingWords 的输出一致：空行分隔剧集块，块首的 "# 名称" 为剧集名，
+// 其后的 "# 说明" 注释行归入下一条规则的说明。无法识别或无法原样重新生成的行、
+// 块末尾没有后续规则的注释，都以 RuleTypeRaw 原样保留，因此再次生成时内容保持稳定。
+// 没有名称注释的块以 "未命名规则 <内容摘要>" 命名并设置 OmitHeader，生成时同样不输出名称注释行，
+// 名称由块内容决定，同一内容重复导入时对应同一规则集，不同文件的无名称块不会互相覆盖；
+// 只有一行注释的块以该注释为名称，同时把注释作为原样保留的行，保证不丢失。
+//
+// 文件中包含 EmbyForge 自动生成标记时只解析标记区域内的内容，标记外的手写内容发布时原样保留，不重复导入。
+func ParseRenderingWords(content string) []RenderingWordSeries {
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(content, "\n")
+	lines = managedBlockLines(lines)
+
+	var seriesList []RenderingWordSeries
+	var block []string
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		series := parseRenderingWordBlock(block)
+		if series.Name == "" {
+			sum := sha256.Sum256([]byte(strings.Join(block, "\n")))
+			series.Name = "未命名规则 " + hex.EncodeToString(sum[:4])
+			series.OmitHeader = true
+		}
+		seriesList = append(seriesList, series)
+		block = nil
+	}
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+	return seriesList
+}
+
+// HasManagedBlock 判断文件中是否存在完整的自动生成标记
+func HasManagedBlock(content string) bool {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	return len(managedBlockLines(lines)) != len(lines)
+}
+
+// managedBlockLines 存在完整的自动生成标记时只返回标记之间的行
+func managedBlockLines(lines []string) []string {
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case ManagedBlockBegin:
+			if begin < 0 {
+				begin = i
+			}
+		case ManagedBlockEnd:
+			end = i
+		}
+	}
+	if begin >= 0 && end > begin {
+		return lines[begin+1 : end]
+	}
+	return lines
+}
+
+// parseRenderingWordBlock 解析一个剧集块
+func parseRenderingWordBlock(block []string) RenderingWordSeries {
+	var series RenderingWordSeries
+	if name, ok := parseCommentLine(block[0]); ok {
+		series.Name = name
+		if len(block) == 1 {
+			series.OmitHeader = true
+			series.Rules = []RenderingWordRule{{Type: RuleTypeRaw, Pattern: block[0]}}
+			return series
+		}
+		block = block[1:]
+	}
+
+	var comments []string
+	for _, line := range block {
+		if comment, ok := parseCommentLine(line); ok {
+			comments = append(comments, comment)
+			continue
+		}
+
+		rule := parseRenderingWordLine(&series, line)
+		if len(comments) > 0 {
+			if rule.Type == RuleTypeRaw {
+				// 注释后跟无法识别的行：注释同样原样保留，避免说明与原文错位
+				for _, comment := range comments {
+					series.Rules = append(series.Rules, RenderingWordRule{Type: RuleTypeRaw, Pattern: "# " + comment})
+				}
+			} else {
+				rule.Comment = strings.Join(comments, "\n")
+			}
+			comments = nil
+		}
+		series.Rules = append(series.Rules, rule)
+	}
+	for _, comment := range comments {
+		series.Rules = append(series.Rules, RenderingWordRule{Type: RuleTypeRaw, Pattern: "# " + comment})
+	}
+	return series
+}
+
+// parseCommentLine 识别可原样重新生成的注释行 "# 内容"（内容无首尾空白）
+func parseCommentLine(line string) (string, bool) {
+	if !strings.HasPrefix(line, "# ") {
+		return "", false
+	}
+	text := line[2:]
+	if text == "" || text != strings.TrimSpace(text) {
+		return "", false
+	}
+	return text, true
+}
+
+// parseRenderingWordLine 解析单行规则；结果无法通过校验或重新生成后与原文不同时作为原样保留的行
+func parseRenderingWordLine(series *RenderingWordSeries, line string) RenderingWordRule {
+	raw := RenderingWordRule{Type: RuleTypeRaw, Pattern: line}
+
+	rule, tmdbID, mediaType, ok := recognizeRenderingWordLine(line)
+	if !ok {
+		return raw
+	}
+
+	// 季集映射行携带 TMDB 条目：剧集块取第一条映射行的条目，其他条目的映射行原样保留
+	candidate := RenderingWordSeries{Name: "-", TmdbID: series.TmdbID, MediaType: series.MediaType, Rules: []RenderingWordRule{rule}}
+	if rule.Type == RuleTypeMapping {
+		if series.TmdbID == 0 {
+			candidate.TmdbID, candidate.MediaType = tmdbID, mediaType
+		} else if tmdbID != series.TmdbID || mediaType != mediaTypeOrDefault(series.MediaType) {
+			return raw
+		}
+	}
+
+	if len(ValidateRenderingWordSeries(0, candidate)) > 0 || FormatRenderingWordRule(candidate, rule) != line {
+		return raw
+	}
+	if rule.Type == RuleTypeMapping && series.TmdbID == 0 {
+		series.TmdbID, series.MediaType = candidate.TmdbID, candidate.MediaType
+	}
+	return rule
+}
+
+// recognizeRenderingWordLine 按分隔符识别规则类型，季集映射行同时返回其 TMDB 条目
+func recognizeRenderingWordLine(line string) (RenderingWordRule, int, string, bool) {
+	if m := mappingLinePattern.FindStringSubmatch(line); m != nil {
+		tmdbID, _ := strconv.Atoi(m[1])
+		sourceSeason, _ := strconv.Atoi(m[3])
+		targetSeason, _ := strconv.Atoi(m[5])
+		return RenderingWordRule{
+			Type:           RuleTypeMapping,
+			SourceSeason:   sourceSeason,
+			SourceEpisodes: m[4],
+			TargetSeason:   targetSeason,
+			Offset:         m[6],
+		}, tmdbID, m[2], true
+	}
+
+	if strings.HasPrefix(line, "#") {
+		return RenderingWordRule{}, 0, "", false
+	}
+
+	if i := strings.Index(line, replaceSeparator); i >= 0 {
+		rule := RenderingWordRule{Type: RuleTypeReplace, Pattern: line[:i]}
+		rest := line[i+len(replaceSeparator):]
+		if j := strings.Index(rest, comboSeparator); j >= 0 {
+			offsetRule, ok := parseOffsetPart(rest[j+len(comboSeparator):])
+			if !ok {
+				return RenderingWordRule{}, 0, "", false
+			}
+			rule.Prefix, rule.Suffix, rule.Offset = offsetRule.Prefix, offsetRule.Suffix, offsetRule.Offset
+			rest = rest[:j]
+		}
+		rule.Replacement = rest
+		return rule, 0, "", true
+	}
+
+	if strings.Contains(line, offsetSeparator) {
+		rule, ok := parseOffsetPart(line)
+		return rule, 0, "", ok
+	}
+
+	return RenderingWordRule{Type: RuleTypeBlock, Pattern: line}, 0, "", true
+}
+
+// parseOffsetPart 解析集偏移部分：前定位词 <> 后定位词 >> EP±n
+func parseOffsetPart(s string) (RenderingWordRule, bool) {
+	i := strings.LastIndex(s, offsetSeparator)
+	if i < 0 {
+		return RenderingWordRule{}, false
+	}
+	anchors := s[:i]
+	j := strings.Index(anchors, anchorSeparator)
+	if j < 0 {
+		return RenderingWordRule{}, false
+	}
+	return RenderingWordRule{
+		Type:   RuleTypeOffset,
+		Prefix: anchors[:j],
+		Suffix: anchors[j+len(anchorSeparator):],
+		Offset: s[i+len(offsetSeparator):],
+	}, true
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// genRuleWord 生成渲染词中的普通词语（不含分隔符）
+func genRuleWord(t *rapid.T, label string) string {
+	return rapid.StringMatching(`[A-Za-z0-9\p{Han}\[\]\\.]{1,8}`).Draw(t, label)
+}
+
+// genRenderingWordRule 生成一条合法的渲染词规则
+func genRenderingWordRule(t *rapid.T, label string) RenderingWordRule {
+	offset := rapid.SampledFrom([]string{"EP", "EP+12", "EP-3"}).Draw(t, label+"_offset")
+	var rule RenderingWordRule
+	switch rapid.SampledFrom([]string{RuleTypeMapping, RuleTypeReplace, RuleTypeBlock, RuleTypeOffset}).Draw(t, label+"_type") {
+	case RuleTypeMapping:
+		start := rapid.IntRange(1, 30).Draw(t, label+"_start")
+		rule = RenderingWordRule{
+			Type:           RuleTypeMapping,
+			SourceSeason:   rapid.IntRange(0, 5).Draw(t, label+"_ss"),
+			SourceEpisodes: fmt.Sprintf("%d-%d", start, start+rapid.IntRange(0, 20).Draw(t, label+"_len")),
+			TargetSeason:   rapid.IntRange(0, 5).Draw(t, label+"_ts"),
+			Offset:         offset,
+		}
+	case RuleTypeReplace:
+		rule = RenderingWordRule{Type: RuleTypeReplace, Pattern: genRuleWord(t, label+"_p"), Replacement: genRuleWord(t, label+"_r")}
+		if rapid.Bool().Draw(t, label+"_combo") {
+			rule.Prefix, rule.Suffix, rule.Offset = genRuleWord(t, label+"_pre"), genRuleWord(t, label+"_suf"), offset
+		}
+	case RuleTypeBlock:
+		rule = RenderingWordRule{Type: RuleTypeBlock, Pattern: genRuleWord(t, label+"_p")}
+	default:
+		rule = RenderingWordRule{Type: RuleTypeOffset, Prefix: genRuleWord(t, label+"_pre"), Suffix: genRuleWord(t, label+"_suf"), Offset: offset}
+	}
+	if rapid.Bool().Draw(t, label+"_has_comment") {
+		rule.Comment = "说明 " + genRuleWord(t, label+"_comment")
+	}
+	return rule
+}
+
+// Feature: rendering-words, Property: generated custom words parse back to the same rules
+// 对于任意一组合法规则，生成的渲染词文本解析后应得到相同的剧集和规则，再次生成的文本与原文一致。
+func TestProperty_RenderingWordsRoundTrip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		seriesCount := rapid.IntRange(1, 4).Draw(t, "seriesCount")
+		seriesList := make([]RenderingWordSeries, seriesCount)
+		for i := range seriesList {
+			series := RenderingWordSeries{
+				Name:      fmt.Sprintf("剧集%d %s", i, genRuleWord(t, fmt.Sprintf("name_%d", i))),
+				TmdbID:    rapid.IntRange(1, 999999).Draw(t, fmt.Sprintf("tmdb_%d", i)),
+				MediaType: "tv",
+			}
+			ruleCount := rapid.IntRange(1, 6).Draw(t, fmt.Sprintf("rules_%d", i))
+			for j := 0; j < ruleCount; j++ {
+				series.Rules = append(series.Rules, genRenderingWordRule(t, fmt.Sprintf("r_%d_%d", i, j)))
+			}
+			seriesList[i] = series
+		}
+
+		content, errs := GenerateRenderingWords(seriesList)
+		if len(errs) > 0 {
+			t.Fatalf("生成的规则不应有校验错误: %v", errs)
+		}
+
+		parsed := ParseRenderingWords(content)
+		if len(parsed) != len(seriesList) {
+			t.Fatalf("解析出 %d 部剧集，期望 %d", len(parsed), len(seriesList))
+		}
+		for i := range parsed {
+			want, got := seriesList[i], parsed[i]
+			if !hasMapping(want.Rules) {
+				// 没有季集映射行时 TMDB 条目不出现在文本中
+				want.TmdbID, want.MediaType = 0, ""
+			}
+			if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", want) {
+				t.Fatalf("第 %d 部剧集解析结果不一致:\n got %+v\nwant %+v", i, got, want)
+			}
+		}
+
+		again, errs := GenerateRenderingWords(parsed)
+		if len(errs) > 0 || again != content {
+			t.Fatalf("再次生成的文本不一致 (errs=%v):\n%s\n---\n%s", errs, again, content)
+		}
+	})
+}
+
+// Feature: rendering-words, Property: importing arbitrary files is stable
+// 对于任意文本（包含无法识别的行），解析后生成的文本再次解析生成应保持不变；
+// 不含连续空行和首尾空行的文本导出后与原文完全一致。
+func TestProperty_ParseRenderingWordsStable(t *testing.T) {
+	lineGen := rapid.OneOf(
+		rapid.SampledFrom([]string{
+			"", "# 注释", "#无空格注释", "#", "  缩进行", "AAA => BBB", "A => B && 第 <> 话 >> EP+1",
+			"第 <> 话 >> EP-2", "广告", "坏 => ", " => 空", "X <> Y >> EP+x", "@?{[tmdbid=1;type=tv;s=1;e=1-2]} => {[s=2;e=EP]}",
+			"@?{[tmdbid=2;type=tv;s=1;e=3]} => {[s=1;e=EP+1]}", "@?{[tmdbid=0;type=tv;s=1;e=1]} => {[s=1;e=EP]}",
+			"# 尾部空格 ", "A =>  B", "a && b", "x\ty",
+		}),
+		rapid.StringMatching(`[ -~\p{Han}]{0,20}`),
+	)
+
+	rapid.Check(t, func(t *rapid.T) {
+		lines := rapid.SliceOfN(lineGen, 0, 30).Draw(t, "lines")
+		content := strings.Join(lines, "\n")
+
+		first, errs := GenerateRenderingWords(ParseRenderingWords(content))
+		if len(errs) > 0 {
+			t.Fatalf("解析结果应始终可生成: %v", errs)
+		}
+		second, errs := GenerateRenderingWords(ParseRenderingWords(first))
+		if len(errs) > 0 || second != first {
+			t.Fatalf("导入导出不稳定 (errs=%v):\n%q\n---\n%q", errs, first, second)
+		}
+
+		if canonical := canonicalCustomWords(lines); canonical != "" && first != canonical {
+			t.Fatalf("规范文本导出后应与原文一致:\n%q\n---\n%q", canonical, first)
+		}
+	})
+}
+
+// canonicalCustomWords 去掉空白行连续、首尾的空白行后的文本
+func canonicalCustomWords(lines []string) string {
+	var blocks []string
+	var block []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, strings.Join(block, "\n"))
+			}
+			block = nil
+			continue
+		}
+		block = append(block, line)
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, strings.Join(block, "\n"))
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func hasMapping(rules []RenderingWordRule) bool {
+	for _, r := range rules {
+		if r.Type == RuleTypeMapping {
+			return true
+		}
+	}
+	return false
+}
+
+// TestParseRenderingWords 解析剧集名、规则说明，无法识别的行原样保留
+func TestParseRenderingWords(t *testing.T) {
+	content := "# 葬送的芙莉莲\n" +
+		"# 第二季\n" +
+		"@?{[tmdbid=209867;type=tv;s=1;e=29-40]} => {[s=2;e=EP-28]}\n" +
+		"前 <> 后 >> 第三集\n" +
+		"# 末尾注释\n" +
+		"\n" +
+		"没有名称的屏蔽词\n" +
+		"\n" +
+		"# 只有一行注释\n"
+
+	parsed := ParseRenderingWords(content)
+	if len(parsed) != 3 {
+		t.Fatalf("期望 3 部剧集，实际 %d: %+v", len(parsed), parsed)
+	}
+
+	first := parsed[0]
+	if first.Name != "葬送的芙莉莲" || first.TmdbID != 209867 || len(first.Rules) != 3 {
+		t.Fatalf("第一部剧集解析不正确: %+v", first)
+	}
+	if r := first.Rules[0]; r.Type != RuleTypeMapping || r.Comment != "第二季" || r.Offset != "EP-28" {
+		t.Errorf("映射规则解析不正确: %+v", r)
+	}
+	if r := first.Rules[1]; r.Type != RuleTypeRaw || r.Pattern != "前 <> 后 >> 第三集" {
+		t.Errorf("无法识别的行应原样保留: %+v", r)
+	}
+	if r := first.Rules[2]; r.Type != RuleTypeRaw || r.Pattern != "# 末尾注释" {
+		t.Errorf("末尾注释应原样保留: %+v", r)
+	}
+	if !strings.HasPrefix(parsed[1].Name, "未命名规则 ") || !parsed[1].OmitHeader || parsed[1].Rules[0].Type != RuleTypeBlock {
+		t.Errorf("无名称块解析不正确: %+v", parsed[1])
+	}
+
+	exported, errs := GenerateRenderingWords(parsed)
+	if len(errs) > 0 || exported != content {
+		t.Errorf("导出内容应与原文一致 (errs=%v):\n%q", errs, exported)
+	}
+
+	managed := "手写 => 内容\n\n" + ManagedBlockBegin + "\n# 托管\nAAA\n" + ManagedBlockEnd + "\n"
+	if parsed := ParseRenderingWords(managed); len(parsed) != 1 || parsed[0].Name != "托管" {
+		t.Errorf("存在自动生成标记时只解析标记区域: %+v", parsed)
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// LocalEpisode 本地（Emby 缓存）的一集
+type LocalEpisode struct {
+	EmbyItemID    string `json:"emby_item_id"`
+	Name          string `json:"name"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+}
+
+// TmdbSeasonEpisodes TMDB 某一季的集数和每集标题
+type TmdbSeasonEpisodes struct {
+	EpisodeCount int
+	Titles       map[int]string // 集号 -> 标题，未获取到标题时为空
+}
+
+// EpisodePreview 单集的规则模拟结果
+type EpisodePreview struct {
+	EmbyItemID    string `json:"emby_item_id"`
+	Name          string `json:"name"`
+	Source        string `json:"source"` // SxxEyy
+	SourceSeason  int    `json:"source_season"`
+	SourceEpisode int    `json:"source_episode"`
+	Target        string `json:"target"` // SxxEyy
+	TargetSeason  int    `json:"target_season"`
+	TargetEpisode int    `json:"target_episode"`
+	TargetTitle   string `json:"target_title"`
+	RuleIndex     int    `json:"rule_index"`   // 命中的规则下标，-1 表示没有规则覆盖
+	Uncovered     bool   `json:"uncovered"`    // 没有规则覆盖（保持原季集号）
+	Collision     bool   `json:"collision"`    // 与其他本地集映射到同一目标
+	OutOfRange    bool   `json:"out_of_range"` // 目标超出 TMDB 季集范围
+}
+
+// PreviewSummary 模拟结果统计
+type PreviewSummary struct {
+	Total      int `json:"total"`
+	Covered    int `json:"covered"`
+	Uncovered  int `json:"uncovered"`
+	Collision  int `json:"collision"`
+	OutOfRange int `json:"out_of_range"`
+}
+
+// SimulateRenderingWordRules 对本地每一集应用季集映射规则，得到映射后的 TMDB 季集
+// 规则按顺序匹配，第一条命中的规则生效；替换词、屏蔽词等作用于文件名的规则不参与模拟，
+// 其中可能改变识别结果的规则由 NotSimulatedRuleIndexes 列出。
+// 结果按源季集排序，并标记冲突、越界和未覆盖的集。
+func SimulateRenderingWordRules(rules []RenderingWordRule, episodes []LocalEpisode, tmdbSeasons map[int]TmdbSeasonEpisodes) ([]EpisodePreview, PreviewSummary) {
+	previews := make([]EpisodePreview, 0, len(episodes))
+	targets := make(map[[2]int][]int) // 目标季集 -> previews 下标
+
+	for _, ep := range episodes {
+		p := EpisodePreview{
+			EmbyItemID:    ep.EmbyItemID,
+			Name:          ep.Name,
+			Source:        FormatSeasonEpisode(ep.SeasonNumber, ep.EpisodeNumber),
+			SourceSeason:  ep.SeasonNumber,
+			SourceEpisode: ep.EpisodeNumber,
+			TargetSeason:  ep.SeasonNumber,
+			TargetEpisode: ep.EpisodeNumber,
+			RuleIndex:     -1,
+			Uncovered:     true,
+		}
+		for i, rule := range rules {
+			if season, episode, ok := applyMappingRule(rule, ep.SeasonNumber, ep.EpisodeNumber); ok {
+				p.TargetSeason, p.TargetEpisode = season, episode
+				p.RuleIndex = i
+				p.Uncovered = false
+				break
+			}
+		}
+		p.Target = FormatSeasonEpisode(p.TargetSeason, p.TargetEpisode)
+
+		season, exists := tmdbSeasons[p.TargetSeason]
+		if !exists || p.TargetEpisode < 1 || p.TargetEpisode > season.EpisodeCount {
+			p.OutOfRange = true
+		} else {
+			p.TargetTitle = season.Titles[p.TargetEpisode]
+		}
+
+		key := [2]int{p.TargetSeason, p.TargetEpisode}
+		targets[key] = append(targets[key], len(previews))
+		previews = append(previews, p)
+	}
+
+	for _, indexes := range targets {
+		if len(indexes) > 1 {
+			for _, i := range indexes {
+				previews[i].Collision = true
+			}
+		}
+	}
+
+	sort.SliceStable(previews, func(i, j int) bool {
+		if previews[i].SourceSeason != previews[j].SourceSeason {
+			return previews[i].SourceSeason < previews[j].SourceSeason
+		}
+		return previews[i].SourceEpisode < previews[j].SourceEpisode
+	})
+
+	summary := PreviewSummary{Total: len(previews)}
+	for _, p := range previews {
+		if p.Uncovered {
+			summary.Uncovered++
+		} else {
+			summary.Covered++
+		}
+		if p.Collision {
+			summary.Collision++
+		}
+		if p.OutOfRange {
+			summary.OutOfRange++
+		}
+	}
+	return previews, summary
+}
+
+// NotSimulatedRuleIndexes 返回模拟时被忽略、但可能改变季集识别结果的规则下标
+// 集偏移和替换词作用于文件名，原样保留的行无法解析，模拟结果不包含它们的效果；屏蔽词只删除文字，不列出
+func NotSimulatedRuleIndexes(rules []RenderingWordRule) []int {
+	indexes := []int{}
+	for i, rule := range rules {
+		switch rule.Type {
+		case RuleTypeOffset, RuleTypeReplace, RuleTypeRaw:
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// applyMappingRule 对单集应用季集映射规则，规则不匹配或格式错误时返回 false
+func applyMappingRule(rule RenderingWordRule, season, episode int) (int, int, bool) {
+	if rule.Type != RuleTypeMapping || rule.SourceSeason != season {
+		return 0, 0, false
+	}
+	m := episodeRangePattern.FindStringSubmatch(rule.SourceEpisodes)
+	if m == nil {
+		return 0, 0, false
+	}
+	start, _ := strconv.Atoi(m[1])
+	end := start
+	if m[2] != "" {
+		end, _ = strconv.Atoi(m[2])
+	}
+	if episode < start || episode > end {
+		return 0, 0, false
+	}
+
+	om := offsetPattern.FindStringSubmatch(rule.Offset)
+	if om == nil {
+		return 0, 0, false
+	}
+	offset := 0
+	if om[1] != "" {
+		offset, _ = strconv.Atoi(om[1])
+	}
+	return rule.TargetSeason, episode + offset, true
+}
+
+// FormatSeasonEpisode 格式化为 SxxEyy
+func FormatSeasonEpisode(season, episode int) string {
+	return fmt.Sprintf("S%02dE%02d", season, episode)
+}
@@ -0,0 +1,70 @@
+package service
+
+import "testing"
+
+// TestSimulateRenderingWordRules 模拟映射规则并标记冲突、越界和未覆盖的集
+func TestSimulateRenderingWordRules(t *testing.T) {
+	rules := []RenderingWordRule{
+		{Type: RuleTypeBlock, Pattern: "广告"},
+		{Type: RuleTypeMapping, SourceSeason: 2, SourceEpisodes: "1-3", TargetSeason: 1, Offset: "EP+12"},
+		{Type: RuleTypeMapping, SourceSeason: 2, SourceEpisodes: "1-10", TargetSeason: 1, Offset: "EP"}, // 被上一条遮挡的部分不生效
+	}
+	episodes := []LocalEpisode{
+		{EmbyItemID: "e4", SeasonNumber: 2, EpisodeNumber: 4},  // 第三条规则 → S01E04，与本地 S01E04 冲突
+		{EmbyItemID: "e1", SeasonNumber: 2, EpisodeNumber: 1},  // 第二条规则 → S01E13
+		{EmbyItemID: "e3", SeasonNumber: 2, EpisodeNumber: 3},  // → S01E15，超出 TMDB 14 集
+		{EmbyItemID: "l4", SeasonNumber: 1, EpisodeNumber: 4},  // 未覆盖
+		{EmbyItemID: "x1", SeasonNumber: 3, EpisodeNumber: 11}, // 未覆盖且 TMDB 无第 3 季
+	}
+	tmdbSeasons := map[int]TmdbSeasonEpisodes{
+		1: {EpisodeCount: 14, Titles: map[int]string{13: "终章"}},
+	}
+
+	previews, summary := SimulateRenderingWordRules(rules, episodes, tmdbSeasons)
+
+	byID := make(map[string]EpisodePreview)
+	for _, p := range previews {
+		byID[p.EmbyItemID] = p
+	}
+	if previews[0].EmbyItemID != "l4" || previews[len(previews)-1].EmbyItemID != "x1" {
+		t.Errorf("结果应按源季集排序: %+v", previews)
+	}
+	if p := byID["e1"]; p.Target != "S01E13" || p.TargetTitle != "终章" || p.RuleIndex != 1 || p.Collision || p.OutOfRange {
+		t.Errorf("e1 结果不正确: %+v", p)
+	}
+	if p := byID["e3"]; p.Target != "S01E15" || !p.OutOfRange {
+		t.Errorf("e3 应越界: %+v", p)
+	}
+	if p := byID["e4"]; p.Target != "S01E04" || p.RuleIndex != 2 || !p.Collision {
+		t.Errorf("e4 应与本地 S01E04 冲突: %+v", p)
+	}
+	if p := byID["l4"]; !p.Uncovered || !p.Collision || p.RuleIndex != -1 {
+		t.Errorf("l4 应未覆盖且冲突: %+v", p)
+	}
+	if p := byID["x1"]; !p.Uncovered || !p.OutOfRange {
+		t.Errorf("x1 应未覆盖且越界: %+v", p)
+	}
+
+	want := PreviewSummary{Total: 5, Covered: 3, Uncovered: 2, Collision: 2, OutOfRange: 2}
+	if summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+}
+
+// TestNotSimulatedRuleIndexes 列出模拟时被忽略的集偏移、替换词和原样保留的规则
+func TestNotSimulatedRuleIndexes(t *testing.T) {
+	rules := []RenderingWordRule{
+		{Type: RuleTypeMapping, SourceSeason: 2, SourceEpisodes: "1-3", TargetSeason: 1, Offset: "EP+12"},
+		{Type: RuleTypeOffset, Prefix: "第", Suffix: "集", Offset: "EP-1"},
+		{Type: RuleTypeBlock, Pattern: "广告"},
+		{Type: RuleTypeReplace, Pattern: "第二季", Replacement: "S02"},
+		{Type: RuleTypeRaw, Pattern: "无法识别的行"},
+	}
+	got := NotSimulatedRuleIndexes(rules)
+	if len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 4 {
+		t.Errorf("NotSimulatedRuleIndexes = %v, want [1 3 4]", got)
+	}
+	if got := NotSimulatedRuleIndexes(rules[:1]); got == nil || len(got) != 0 {
+		t.Errorf("没有被忽略的规则时应返回空列表: %v", got)
+	}
+}
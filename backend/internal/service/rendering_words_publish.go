@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 发布到仓库时包裹自动生成内容的标记行，标记之外的内容保持原样
+const (
+	ManagedBlockBegin = "# >>> EmbyForge 自动生成开始（此区域由 EmbyForge 维护，手动修改会被覆盖）"
+	ManagedBlockEnd   = "# <<< EmbyForge 自动生成结束"
+)
+
+// MergeManagedBlock 将生成的渲染词合并到已有文件的标记区域
+// 文件中已有完整标记时替换标记之间的内容；没有标记时把标记区域追加到文件末尾（与原内容空一行）。
+// 只有开始或结束标记之一、标记重复或顺序颠倒时返回错误，避免误删手写内容。
+func MergeManagedBlock(existing, generated string) (string, error) {
+	// 统一换行符，避免 Windows 换行导致标记匹配失败
+	existing = strings.ReplaceAll(existing, "\r\n", "\n")
+
+	block := ManagedBlockBegin + "\n"
+	if generated = strings.TrimRight(generated, "\n"); generated != "" {
+		block += generated + "\n"
+	}
+	block += ManagedBlockEnd + "\n"
+
+	lines := strings.Split(existing, "\n")
+	begin, end := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case ManagedBlockBegin:
+			if begin >= 0 {
+				return "", fmt.Errorf("文件中存在多个自动生成开始标记（第 %d 行）", i+1)
+			}
+			begin = i
+		case ManagedBlockEnd:
+			if end >= 0 {
+				return "", fmt.Errorf("文件中存在多个自动生成结束标记（第 %d 行）", i+1)
+			}
+			end = i
+		}
+	}
+
+	switch {
+	case begin < 0 && end < 0:
+		trimmed := strings.TrimRight(existing, "\n")
+		if trimmed == "" {
+			return block, nil
+		}
+		return trimmed + "\n\n" + block, nil
+	case begin < 0 || end < 0:
+		return "", fmt.Errorf("文件中的自动生成标记不完整，请检查后手动修复")
+	case end < begin:
+		return "", fmt.Errorf("文件中的自动生成结束标记位于开始标记之前")
+	}
+
+	before := strings.Join(lines[:begin], "\n")
+	if before != "" {
+		before += "\n"
+	}
+	after := strings.Join(lines[end+1:], "\n")
+	return before + block + after, nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMergeManagedBlock 替换或追加自动生成区域，标记之外的内容保持不变
+func TestMergeManagedBlock(t *testing.T) {
+	block := ManagedBlockBegin + "\nNEW\n" + ManagedBlockEnd + "\n"
+
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{"空文件", "", block},
+		{"无标记时追加到末尾", "# 手写\nAAA\n", "# 手写\nAAA\n\n" + block},
+		{"替换已有区域", "HEAD\n" + ManagedBlockBegin + "\nOLD\n" + ManagedBlockEnd + "\nTAIL\n", "HEAD\n" + block + "TAIL\n"},
+		{"Windows 换行", "HEAD\r\n" + ManagedBlockBegin + "\r\nOLD\r\n" + ManagedBlockEnd + "\r\n", "HEAD\n" + block},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeManagedBlock(tt.existing, "NEW\n")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			// 再次合并相同内容应保持不变
+			if again, _ := MergeManagedBlock(got, "NEW\n"); again != got {
+				t.Errorf("合并不幂等: %q → %q", got, again)
+			}
+		})
+	}
+
+	for _, broken := range []string{
+		ManagedBlockBegin + "\nAAA\n",
+		ManagedBlockEnd + "\n" + ManagedBlockBegin + "\n",
+		ManagedBlockBegin + "\n" + ManagedBlockBegin + "\n" + ManagedBlockEnd + "\n",
+	} {
+		if _, err := MergeManagedBlock(broken, "NEW"); err == nil {
+			t.Errorf("标记异常时应返回错误: %q", broken)
+		}
+	}
+
+	if got, _ := MergeManagedBlock("", ""); !strings.HasPrefix(got, ManagedBlockBegin+"\n"+ManagedBlockEnd) {
+		t.Errorf("空内容应生成空区域: %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGenerateRenderingWords 生成季集映射、替换词、屏蔽词和集偏移的渲染词文本
+func TestGenerateRenderingWords(t *testing.T) {
+	content, errs := GenerateRenderingWords([]RenderingWordSeries{
+		{
+			Name:   "间谍过家家",
+			TmdbID: 120089,
+			Rules: []RenderingWordRule{
+				{Type: RuleTypeMapping, SourceSeason: 2, SourceEpisodes: "1-13", TargetSeason: 1, Offset: "EP+12", Comment: "第二季合并到 TMDB 第一季"},
+				{Type: RuleTypeReplace, Pattern: "SPYxFAMILY", Replacement: "间谍过家家"},
+				{Type: RuleTypeReplace, Pattern: "Part2", Replacement: "S01", Prefix: "\\[", Suffix: "\\]", Offset: "EP+12"},
+			},
+		},
+		{
+			Name: "通用",
+			Rules: []RenderingWordRule{
+				{Type: RuleTypeBlock, Pattern: "国语配音"},
+				{Type: RuleTypeOffset, Prefix: "第", Suffix: "话", Offset: "EP-1"},
+			},
+		},
+		{Name: "空剧集"},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("不应有校验错误: %v", errs)
+	}
+
+	want := "# 间谍过家家\n" +
+		"# 第二季合并到 TMDB 第一季\n" +
+		"@?{[tmdbid=120089;type=tv;s=2;e=1-13]} => {[s=1;e=EP+12]}\n" +
+		"SPYxFAMILY => 间谍过家家\n" +
+		"Part2 => S01 && \\[ <> \\] >> EP+12\n" +
+		"\n" +
+		"# 通用\n" +
+		"国语配音\n" +
+		"第 <> 话 >> EP-1\n"
+	if content != want {
+		t.Errorf("生成结果不正确:\n%s\n期望:\n%s", content, want)
+	}
+}
+
+// TestGenerateRenderingWords_ValidationErrors 校验错误指明出错的剧集、规则和字段
+func TestGenerateRenderingWords_ValidationErrors(t *testing.T) {
+	content, errs := GenerateRenderingWords([]RenderingWordSeries{
+		{
+			Name:   "剧集A",
+			TmdbID: 1,
+			Rules: []RenderingWordRule{
+				{Type: RuleTypeMapping, SourceSeason: 1, SourceEpisodes: "1-12", TargetSeason: 2, Offset: "EP-12"},
+				{Type: RuleTypeMapping, SourceSeason: 1, SourceEpisodes: "13-1", TargetSeason: 2, Offset: "+12"},
+			},
+		},
+		{
+			Name: "剧集B",
+			Rules: []RenderingWordRule{
+				{Type: RuleTypeReplace, Pattern: "a => b", Replacement: "c"},
+				{Type: RuleTypeBlock, Pattern: ""},
+				{Type: "unknown"},
+			},
+		},
+	})
+	if content != "" {
+		t.Errorf("存在校验错误时不应生成文本")
+	}
+
+	type key struct {
+		series, rule int
+		field        string
+	}
+	want := map[key]bool{
+		{0, 1, "source_episodes"}: true,
+		{0, 1, "offset"}:          true,
+		{1, 0, "pattern"}:         true,
+		{1, 1, "pattern"}:         true,
+		{1, 2, "type"}:            true,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("期望 %d 个错误，实际 %d 个: %v", len(want), len(errs), errs)
+	}
+	for _, e := range errs {
+		if !want[key{e.SeriesIndex, e.RuleIndex, e.Field}] {
+			t.Errorf("意外的错误: %+v", e)
+		}
+	}
+	if got := errs[0].Error(); got != `剧集 "剧集A" 第 2 条规则: 集数范围 "13-1" 的结束集小于起始集` {
+		t.Errorf("错误信息不正确: %s", got)
+	}
+}
+
+// TestDiffLines 行级差异：删除行排在同位置的新增行之前
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+	var got []string
+	for _, l := range diff {
+		got = append(got, l.Op+l.Text)
+	}
+	want := []string{" a", "-b", "+x", " c", "+d"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("DiffLines = %v, want %v", got, want)
+	}
+}
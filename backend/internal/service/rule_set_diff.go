@@ -0,0 +1,77 @@
+package service
+
+import "strings"
+
+// 差异行操作
+const (
+	DiffEqual  = " "
+	DiffAdd    = "+"
+	DiffRemove = "-"
+)
+
+// DiffLine 行级差异
+type DiffLine struct {
+	Op   string `json:"op"` // " " 未变 / "+" 新增 / "-" 删除
+	Text string `json:"text"`
+}
+
+// RenderingWordLines 将剧集规则展开为渲染词行（规则说明展开为注释行），用于版本对比
+func RenderingWordLines(series RenderingWordSeries) []string {
+	var lines []string
+	for _, rule := range series.Rules {
+		if rule.Comment != "" {
+			for _, comment := range strings.Split(rule.Comment, "\n") {
+				lines = append(lines, "# "+strings.TrimSpace(comment))
+			}
+		}
+		lines = append(lines, FormatRenderingWordRule(series, rule))
+	}
+	return lines
+}
+
+// DiffLines 基于最长公共子序列计算两组文本行的差异
+// 删除行排在同位置的新增行之前，与常见 diff 工具的输出顺序一致
+func DiffLines(oldLines, newLines []string) []DiffLine {
+	n, m := len(oldLines), len(newLines)
+
+	// lcs[i][j] 为 oldLines[i:] 与 newLines[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffRemove, Text: oldLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffAdd, Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		diff = append(diff, DiffLine{Op: DiffRemove, Text: oldLines[i]})
+	}
+	for ; j < m; j++ {
+		diff = append(diff, DiffLine{Op: DiffAdd, Text: newLines[j]})
+	}
+	return diff
+}
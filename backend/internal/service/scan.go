@@ -13,6 +13,7 @@ import (
 	"embyforge/internal/emby"
 	"embyforge/internal/model"
 	"embyforge/internal/tmdb"
+	"embyforge/internal/tvdb"
 	"embyforge/internal/workerpool"
 
 	"gorm.io/gorm"
@@ -327,8 +328,12 @@ type SeriesInfo struct {
 	EmbyItemID   string
 	Name         string
 	TmdbID       int
-	LocalSeasons []LocalSeasonInfo
-	TmdbSeasons  []tmdb.Season
+	TmdbName        string // TMDB 本地化节目名称
+	EpisodeGroupID  string // TmdbSeasons 来自固定的剧集组时记录剧集组 ID
+	ReferenceSource string // 参考数据源（tmdb/tvdb），为空视为 tmdb
+	TvdbID          int
+	LocalSeasons    []LocalSeasonInfo
+	TmdbSeasons     []tmdb.Season // 参考数据源的季结构，Name 为本地化季名称
 }
 
 // DetectEpisodeMappingAnomalies 纯逻辑函数：检测异常映射
@@ -338,6 +343,11 @@ func DetectEpisodeMappingAnomalies(seriesList []SeriesInfo) []model.EpisodeMappi
 	var anomalies []model.EpisodeMappingAnomaly
 
 	for _, series := range seriesList {
+		referenceSource := series.ReferenceSource
+		if referenceSource == "" {
+			referenceSource = model.ReferenceSourceTMDB
+		}
+
 		// 计算本地季数（排除特别篇 season_number=0）
 		localSeasonCount := 0
 		for _, local := range series.LocalSeasons {
@@ -350,7 +360,9 @@ func DetectEpisodeMappingAnomalies(seriesList []SeriesInfo) []model.EpisodeMappi
 		// 注意：只统计有集数的季，不统计空季
 		tmdbSeasonCount := 0
 		tmdbSeasonMap := make(map[int]int) // seasonNumber -> episodeCount
+		tmdbSeasonNames := make(map[int]string)
 		for _, s := range series.TmdbSeasons {
+			tmdbSeasonNames[s.SeasonNumber] = s.Name
 			if s.SeasonNumber > 0 && s.EpisodeCount > 0 {
 				tmdbSeasonCount++
 				tmdbSeasonMap[s.SeasonNumber] = s.EpisodeCount
@@ -376,6 +388,11 @@ func DetectEpisodeMappingAnomalies(seriesList []SeriesInfo) []model.EpisodeMappi
 					Difference:       local.EpisodeCount,
 					LocalSeasonCount: localSeasonCount,
 					TmdbSeasonCount:  tmdbSeasonCount,
+					TmdbName:         series.TmdbName,
+					TmdbSeasonName:   tmdbSeasonNames[local.SeasonNumber],
+					EpisodeGroupID:   series.EpisodeGroupID,
+					ReferenceSource:  referenceSource,
+					TvdbID:           series.TvdbID,
 				})
 				continue
 			}
@@ -395,6 +412,11 @@ func DetectEpisodeMappingAnomalies(seriesList []SeriesInfo) []model.EpisodeMappi
 					Difference:       diff,
 					LocalSeasonCount: localSeasonCount,
 					TmdbSeasonCount:  tmdbSeasonCount,
+					TmdbName:         series.TmdbName,
+					TmdbSeasonName:   tmdbSeasonNames[local.SeasonNumber],
+					EpisodeGroupID:   series.EpisodeGroupID,
+					ReferenceSource:  referenceSource,
+					TvdbID:           series.TvdbID,
 				})
 			}
 		}
@@ -403,6 +425,88 @@ func DetectEpisodeMappingAnomalies(seriesList []SeriesInfo) []model.EpisodeMappi
 	return anomalies
 }
 
+// loadPinnedEpisodeGroups 读取固定了 TMDB 剧集组的剧集：emby_item_id -> episode_group_id
+func (s *ScanService) loadPinnedEpisodeGroups() map[string]string {
+	var prefs []model.SeriesPreference
+	if err := s.DB.Where("episode_group_id != ''").Find(&prefs).Error; err != nil {
+		log.Printf("⚠️ 读取剧集偏好失败，按默认季结构分析: %v", err)
+	}
+	pinned := make(map[string]string, len(prefs))
+	for _, p := range prefs {
+		pinned[p.EmbyItemID] = p.EpisodeGroupID
+	}
+	return pinned
+}
+
+// applyPinnedEpisodeGroup 剧集固定了剧集组时，用剧集组的分组替换默认季结构作为对比基准
+func applyPinnedEpisodeGroup(ctx context.Context, tmdbClient *tmdb.Client, info *SeriesInfo, groupID string) error {
+	if groupID == "" {
+		return nil
+	}
+	group, err := tmdbClient.GetEpisodeGroupWithContext(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	info.TmdbSeasons = group.Seasons()
+	info.EpisodeGroupID = groupID
+	return nil
+}
+
+// loadReferenceSources 读取单独设置了参考数据源的剧集：emby_item_id -> reference_source
+func (s *ScanService) loadReferenceSources() map[string]string {
+	var prefs []model.SeriesPreference
+	if err := s.DB.Where("reference_source != ''").Find(&prefs).Error; err != nil {
+		log.Printf("⚠️ 读取剧集参考数据源失败，使用全局设置: %v", err)
+	}
+	sources := make(map[string]string, len(prefs))
+	for _, p := range prefs {
+		sources[p.EmbyItemID] = p.ReferenceSource
+	}
+	return sources
+}
+
+// loadLocalSeasons 从 season_cache 读取剧集的本地季信息
+func (s *ScanService) loadLocalSeasons(embyItemID string) ([]LocalSeasonInfo, error) {
+	var seasonCaches []model.SeasonCache
+	if err := s.DB.Where("series_emby_item_id = ?", embyItemID).Find(&seasonCaches).Error; err != nil {
+		return nil, err
+	}
+	localSeasons := make([]LocalSeasonInfo, 0, len(seasonCaches))
+	for _, season := range seasonCaches {
+		localSeasons = append(localSeasons, LocalSeasonInfo{
+			SeasonNumber: season.SeasonNumber,
+			EpisodeCount: season.EpisodeCount,
+		})
+	}
+	return localSeasons, nil
+}
+
+// buildSeriesInfoFromTVDB 以 TVDB 默认季结构为参考构建 SeriesInfo
+func buildSeriesInfoFromTVDB(ctx context.Context, tvdbClient *tvdb.Client, cache model.MediaCache, tmdbID, tvdbID int, localSeasons []LocalSeasonInfo) (SeriesInfo, error) {
+	series, err := tvdbClient.GetSeriesSeasonsWithContext(ctx, tvdbID, tvdb.SeasonTypeDefault)
+	if err != nil {
+		return SeriesInfo{}, err
+	}
+
+	seasons := make([]tmdb.Season, 0, len(series.Seasons))
+	for _, season := range series.Seasons {
+		seasons = append(seasons, tmdb.Season{
+			SeasonNumber: season.SeasonNumber,
+			EpisodeCount: season.EpisodeCount,
+		})
+	}
+
+	return SeriesInfo{
+		EmbyItemID:      cache.EmbyItemID,
+		Name:            cache.Name,
+		TmdbID:          tmdbID,
+		ReferenceSource: model.ReferenceSourceTVDB,
+		TvdbID:          tvdbID,
+		LocalSeasons:    localSeasons,
+		TmdbSeasons:     seasons,
+	}, nil
+}
+
 // batchCreateInDB 分批写入数据库，每批 batchSize 条记录
 // 避免 SQLite "too many SQL variables" 错误
 func batchCreateInDB[T any](db *gorm.DB, records []T, batchSize int) error {
@@ -535,6 +639,7 @@ func (s *ScanService) AnalyzeEpisodeMappingFromCache(tmdbClient *tmdb.Client) (*
 
 	result := &ScanResult{}
 	var allAnomalies []model.EpisodeMappingAnomaly
+	pinnedGroups := s.loadPinnedEpisodeGroups()
 
 	for _, sc := range seriesCaches {
 		result.TotalScanned++
@@ -582,9 +687,15 @@ func (s *ScanService) AnalyzeEpisodeMappingFromCache(tmdbClient *tmdb.Client) (*
 			EmbyItemID:   sc.EmbyItemID,
 			Name:         sc.Name,
 			TmdbID:       tmdbID,
+			TmdbName:     tmdbDetails.LocalizedName(tmdbClient.Languages),
 			LocalSeasons: localSeasons,
 			TmdbSeasons:  tmdbDetails.Seasons,
 		}
+		if err := applyPinnedEpisodeGroup(context.Background(), tmdbClient, &seriesInfo, pinnedGroups[seriesInfo.EmbyItemID]); err != nil {
+			log.Printf("获取电视节目 %q 的剧集组失败: %v", seriesInfo.Name, err)
+			result.ErrorCount++
+			continue
+		}
 		anomalies := DetectEpisodeMappingAnomalies([]SeriesInfo{seriesInfo})
 		allAnomalies = append(allAnomalies, anomalies...)
 	}
@@ -627,6 +738,7 @@ func (s *ScanService) ScanEpisodeMapping(embyClient *emby.Client, tmdbClient *tm
 	}
 
 	var allAnomalies []model.EpisodeMappingAnomaly
+	pinnedGroups := s.loadPinnedEpisodeGroups()
 
 	for _, series := range allSeries {
 		result.TotalScanned++
@@ -674,9 +786,15 @@ func (s *ScanService) ScanEpisodeMapping(embyClient *emby.Client, tmdbClient *tm
 			EmbyItemID:   series.ID,
 			Name:         series.Name,
 			TmdbID:       tmdbID,
+			TmdbName:     tmdbDetails.LocalizedName(tmdbClient.Languages),
 			LocalSeasons: localSeasons,
 			TmdbSeasons:  tmdbDetails.Seasons,
 		}
+		if err := applyPinnedEpisodeGroup(context.Background(), tmdbClient, &seriesInfo, pinnedGroups[seriesInfo.EmbyItemID]); err != nil {
+			log.Printf("获取电视节目 %q 的剧集组失败: %v", seriesInfo.Name, err)
+			result.ErrorCount++
+			continue
+		}
 		anomalies := DetectEpisodeMappingAnomalies([]SeriesInfo{seriesInfo})
 		allAnomalies = append(allAnomalies, anomalies...)
 	}
@@ -732,6 +850,7 @@ func (s *ScanService) ScanEpisodeMappingWithContext(ctx context.Context, embyCli
 	}
 
 	result.TotalScanned = len(allSeries)
+	pinnedGroups := s.loadPinnedEpisodeGroups()
 
 	// 连续认证失败计数器（用于快速中止无效 API Key 的情况）
 	var consecutiveAuthErrors atomic.Int32
@@ -795,17 +914,20 @@ func (s *ScanService) ScanEpisodeMappingWithContext(ctx context.Context, embyCli
 			// 请求成功，重置连续 401 计数
 			consecutiveAuthErrors.Store(0)
 
-			return workerpool.Result[tmdbResult]{
-				Value: tmdbResult{
-					SeriesInfo: SeriesInfo{
-						EmbyItemID:   s.ID,
-						Name:         s.Name,
-						TmdbID:       tmdbID,
-						LocalSeasons: localSeasons,
-						TmdbSeasons:  tmdbDetails.Seasons,
-					},
-				},
+			info := SeriesInfo{
+				EmbyItemID:   s.ID,
+				Name:         s.Name,
+				TmdbID:       tmdbID,
+				TmdbName:     tmdbDetails.LocalizedName(tmdbClient.Languages),
+				LocalSeasons: localSeasons,
+				TmdbSeasons:  tmdbDetails.Seasons,
 			}
+			if err := applyPinnedEpisodeGroup(cancelCtx, tmdbClient, &info, pinnedGroups[s.ID]); err != nil {
+				log.Printf("获取电视节目 %q 的剧集组失败: %v", s.Name, err)
+				return workerpool.Result[tmdbResult]{Value: tmdbResult{Err: err}}
+			}
+
+			return workerpool.Result[tmdbResult]{Value: tmdbResult{SeriesInfo: info}}
 		})
 	}
 
@@ -840,10 +962,24 @@ func (s *ScanService) ScanEpisodeMappingWithContext(ctx context.Context, embyCli
 	return result, nil
 }
 
+// EpisodeMappingSources 异常映射分析的参考数据源
+type EpisodeMappingSources struct {
+	TMDB          *tmdb.Client
+	TVDB          *tvdb.Client // 未配置时为 nil，选择 TVDB 的剧集回退到 TMDB
+	DefaultSource string       // 全局参考数据源（tmdb/tvdb），为空时使用 TMDB
+}
+
 // AnalyzeEpisodeMappingFromCacheWithContext 并发分析异常映射（基于缓存）
 // 使用 Worker Pool 并发获取 TMDB 数据
 func (s *ScanService) AnalyzeEpisodeMappingFromCacheWithContext(ctx context.Context, tmdbClient *tmdb.Client) (*ScanResult, error) {
+	return s.AnalyzeEpisodeMappingFromCacheWithSources(ctx, EpisodeMappingSources{TMDB: tmdbClient})
+}
+
+// AnalyzeEpisodeMappingFromCacheWithSources 并发分析异常映射（基于缓存），按全局或剧集设置选择参考数据源
+// 选择 TVDB 的剧集使用 MediaCache.ProviderIDs 中的 Tvdb ID；没有 TVDB ID 或未配置 TVDB 时回退到 TMDB
+func (s *ScanService) AnalyzeEpisodeMappingFromCacheWithSources(ctx context.Context, sources EpisodeMappingSources) (*ScanResult, error) {
 	startedAt := time.Now()
+	tmdbClient := sources.TMDB
 
 	// 检查 context 是否已取消
 	select {
@@ -872,6 +1008,25 @@ func (s *ScanService) AnalyzeEpisodeMappingFromCacheWithContext(ctx context.Cont
 
 	log.Printf("📊 异常映射分析: 共 %d 个 Series，开始请求 TMDB...", len(seriesCaches))
 
+	// 固定了剧集组的剧集按剧集组的分组对比
+	pinnedGroups := s.loadPinnedEpisodeGroups()
+
+	// 参考数据源：剧集单独设置优先，其次全局设置
+	seriesSources := s.loadReferenceSources()
+	if sources.TVDB == nil && sources.DefaultSource == model.ReferenceSourceTVDB {
+		log.Printf("⚠️ 默认参考数据源为 TVDB 但未配置 TVDB API Key，回退到 TMDB")
+	}
+	referenceSourceFor := func(embyItemID string) string {
+		source := seriesSources[embyItemID]
+		if source == "" {
+			source = sources.DefaultSource
+		}
+		if source == model.ReferenceSourceTVDB && sources.TVDB != nil {
+			return model.ReferenceSourceTVDB
+		}
+		return model.ReferenceSourceTMDB
+	}
+
 	// 连续认证失败计数器（用于快速中止无效 API Key 的情况）
 	var consecutiveAuthErrors atomic.Int32
 	// 用 WithCancel 包装 context，以便在连续 401 时主动取消
@@ -892,8 +1047,34 @@ func (s *ScanService) AnalyzeEpisodeMappingFromCacheWithContext(ctx context.Cont
 	for _, sc := range seriesCaches {
 		cache := sc
 		pool.Submit(func() workerpool.Result[tmdbResult] {
-			// 获取 TMDB ID
 			item := cache.ToMediaItem()
+
+			// 以 TVDB 为参考数据源
+			if referenceSourceFor(cache.EmbyItemID) == model.ReferenceSourceTVDB {
+				if tvdbID, err := strconv.Atoi(item.ProviderIds["Tvdb"]); err == nil && tvdbID > 0 {
+					tmdbID, _ := strconv.Atoi(item.ProviderIds["Tmdb"])
+					localSeasons, err := s.loadLocalSeasons(cache.EmbyItemID)
+					var info SeriesInfo
+					if err == nil {
+						info, err = buildSeriesInfoFromTVDB(cancelCtx, sources.TVDB, cache, tmdbID, tvdbID, localSeasons)
+					}
+
+					progressMu.Lock()
+					progressCount++
+					current := progressCount
+					progressMu.Unlock()
+					if err != nil {
+						log.Printf("❌ [%d/%d] TVDB 请求失败: %q (TVDB ID=%d): %v", current, len(seriesCaches), cache.Name, tvdbID, err)
+						return workerpool.Result[tmdbResult]{Value: tmdbResult{Err: err}}
+					}
+					log.Printf("✅ [%d/%d] TVDB 请求成功: %q (TVDB ID=%d, %d 季)",
+						current, len(seriesCaches), cache.Name, tvdbID, len(info.TmdbSeasons))
+					return workerpool.Result[tmdbResult]{Value: tmdbResult{SeriesInfo: info}}
+				}
+				log.Printf("⚠️ %q 没有 TVDB ID，回退到 TMDB", cache.Name)
+			}
+
+			// 获取 TMDB ID
 			tmdbIDStr, ok := item.ProviderIds["Tmdb"]
 			if !ok || tmdbIDStr == "" {
 				progressMu.Lock()
@@ -936,13 +1117,18 @@ func (s *ScanService) AnalyzeEpisodeMappingFromCacheWithContext(ctx context.Cont
 			s.DB.Where("tmdb_id = ?", tmdbID).Find(&tmdbCaches)
 
 			var tmdbSeasons []tmdb.Season
+			var tmdbName string
 			if len(tmdbCaches) > 0 {
-				// 使用缓存数据
+				// 使用缓存数据，名称按当前语言偏好从多语言缓存中选取
 				for _, tc := range tmdbCaches {
+					name, seasonName := tc.LocalizedNames(tmdbClient.Languages)
+					if tmdbName == "" {
+						tmdbName = name
+					}
 					tmdbSeasons = append(tmdbSeasons, tmdb.Season{
 						SeasonNumber: tc.SeasonNumber,
 						EpisodeCount: tc.EpisodeCount,
-						Name:         tc.SeasonName,
+						Name:         seasonName,
 					})
 				}
 				progressMu.Lock()
@@ -982,20 +1168,12 @@ func (s *ScanService) AnalyzeEpisodeMappingFromCacheWithContext(ctx context.Cont
 				// 请求成功，重置连续 401 计数
 				consecutiveAuthErrors.Store(0)
 				tmdbSeasons = tmdbDetails.Seasons
+				tmdbName = tmdbDetails.LocalizedName(tmdbClient.Languages)
 
-				// 写入 TMDB 缓存
-				now := time.Now()
-				for _, season := range tmdbDetails.Seasons {
-					tc := model.TmdbCache{
-						TmdbID:       tmdbID,
-						Name:         tmdbDetails.Name,
-						SeasonNumber: season.SeasonNumber,
-						EpisodeCount: season.EpisodeCount,
-						SeasonName:   season.Name,
-						CachedAt:     now,
-						UpdatedAt:    now,
-					}
-					s.DB.Where("tmdb_id = ? AND season_number = ?", tmdbID, season.SeasonNumber).
+				// 写入 TMDB 缓存（含多语言名称）
+				tmdbDetails.ID = tmdbID
+				for _, tc := range model.NewTmdbCachesFromDetails(tmdbDetails, tmdbClient.Languages) {
+					s.DB.Where("tmdb_id = ? AND season_number = ?", tmdbID, tc.SeasonNumber).
 						Assign(tc).FirstOrCreate(&tc)
 				}
 
@@ -1007,17 +1185,20 @@ func (s *ScanService) AnalyzeEpisodeMappingFromCacheWithContext(ctx context.Cont
 					current, len(seriesCaches), cache.Name, tmdbID, len(tmdbSeasons))
 			}
 
-			return workerpool.Result[tmdbResult]{
-				Value: tmdbResult{
-					SeriesInfo: SeriesInfo{
-						EmbyItemID:   cache.EmbyItemID,
-						Name:         cache.Name,
-						TmdbID:       tmdbID,
-						LocalSeasons: localSeasons,
-						TmdbSeasons:  tmdbSeasons,
-					},
-				},
+			info := SeriesInfo{
+				EmbyItemID:   cache.EmbyItemID,
+				Name:         cache.Name,
+				TmdbID:       tmdbID,
+				TmdbName:     tmdbName,
+				LocalSeasons: localSeasons,
+				TmdbSeasons:  tmdbSeasons,
+			}
+			if err := applyPinnedEpisodeGroup(cancelCtx, tmdbClient, &info, pinnedGroups[cache.EmbyItemID]); err != nil {
+				log.Printf("❌ 获取剧集组失败: %q (剧集组=%s): %v", cache.Name, pinnedGroups[cache.EmbyItemID], err)
+				return workerpool.Result[tmdbResult]{Value: tmdbResult{Err: err}}
 			}
+
+			return workerpool.Result[tmdbResult]{Value: tmdbResult{SeriesInfo: info}}
 		})
 	}
 
@@ -7,8 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
+
+	"embyforge/internal/respcache"
 )
 
 // AuthError 表示 TMDB API 认证失败（401）
@@ -29,9 +33,11 @@ func IsAuthError(err error) bool {
 
 // TVShowDetails TMDB 电视节目详情
 type TVShowDetails struct {
-	ID       int      `json:"id"`
-	Name     string   `json:"name"`
-	Seasons  []Season `json:"seasons"`
+	ID           int              `json:"id"`
+	Name         string           `json:"name"`
+	OriginalName string           `json:"original_name"`
+	Seasons      []Season         `json:"seasons"`
+	Translations *TranslationList `json:"translations,omitempty"` // 配置了元数据语言时通过 append_to_response 附带
 }
 
 // Season TMDB 季信息
@@ -42,103 +48,199 @@ type Season struct {
 	Name         string `json:"name"`
 }
 
+// DefaultBaseURL TMDB 官方 API 地址
+const DefaultBaseURL = "https://api.themoviedb.org"
+
 // Client TMDB API 客户端
 type Client struct {
-	APIKey     string
-	BaseURL    string
-	HTTPClient *http.Client
+	APIKey      string // v3 API Key，通过 api_key 查询参数传递
+	AccessToken string // v4 读访问令牌，通过 Authorization: Bearer 头传递（优先于 APIKey）
+	BaseURL     string
+	HTTPClient  *http.Client
+	Cache       *respcache.Cache // 响应缓存，为 nil 时不缓存
+
+	Languages      []string // 元数据语言优先级（如 zh-CN, en-US），首个语言作为 language 参数
+	ImageLanguages []string // 图片语言偏好（如 zh, en），作为 include_image_language 参数
+}
+
+// Options TMDB 客户端配置
+type Options struct {
+	APIKey      string // v3 API Key
+	AccessToken string // v4 读访问令牌
+	BaseURL     string // API 基础地址（可指向反向代理），为空时使用官方地址
+	ProxyURL    string // HTTP/HTTPS/SOCKS5 代理地址，为空时沿用环境变量中的代理设置
+
+	Languages      []string // 元数据语言优先级，为空时使用 TMDB 默认语言（en-US）
+	ImageLanguages []string // 图片语言偏好
 }
 
 // NewClient 创建 TMDB API 客户端
+// 如果传入的 apiKey 实际上是 v4 读访问令牌（JWT 格式），自动改用 Bearer 认证
 func NewClient(apiKey string) *Client {
-	return &Client{
-		APIKey:  apiKey,
-		BaseURL: "https://api.themoviedb.org",
+	client := &Client{
+		BaseURL: DefaultBaseURL,
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		Cache: sharedCache,
 	}
+	if looksLikeAccessToken(apiKey) {
+		client.AccessToken = apiKey
+	} else {
+		client.APIKey = apiKey
+	}
+	return client
 }
 
-// maxRetries 速率限制重试最大次数
-const maxRetries = 3
+// NewClientWithOptions 根据配置创建 TMDB API 客户端
+// 支持 v4 读访问令牌、自定义基础地址（反向代理）以及 HTTP/SOCKS5 代理
+func NewClientWithOptions(opts Options) (*Client, error) {
+	apiKey := strings.TrimSpace(opts.APIKey)
+	accessToken := strings.TrimSpace(opts.AccessToken)
+	if accessToken == "" && looksLikeAccessToken(apiKey) {
+		accessToken = apiKey
+		apiKey = ""
+	}
+	if apiKey == "" && accessToken == "" {
+		return nil, errors.New("未配置 TMDB API Key 或读访问令牌")
+	}
 
-// doRequest 执行 HTTP 请求，处理 429 速率限制自动重试
-func (c *Client) doRequest(path string) ([]byte, error) {
-	url := fmt.Sprintf("%s%s?api_key=%s", c.BaseURL, path, c.APIKey)
+	baseURL, err := normalizeBaseURL(opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
 
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("创建请求失败: %w", err)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy := strings.TrimSpace(opts.ProxyURL); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("TMDB 代理地址格式无效: %s", proxy)
 		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("不支持的 TMDB 代理协议: %s（支持 http/https/socks5）", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
 
-		req.Header.Set("Accept", "application/json")
+	return &Client{
+		APIKey:      apiKey,
+		AccessToken: accessToken,
+		BaseURL:     baseURL,
+		HTTPClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		Cache:          sharedCache,
+		Languages:      normalizeLanguages(opts.Languages),
+		ImageLanguages: normalizeLanguages(opts.ImageLanguages),
+	}, nil
+}
 
-		resp, err := c.HTTPClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("请求失败: %w", err)
-		}
+// normalizeBaseURL 规范化 API 基础地址
+// 去掉末尾的 "/" 和 "/3"（请求路径中已包含版本号），为空时返回官方地址
+func normalizeBaseURL(raw string) (string, error) {
+	baseURL := strings.TrimSpace(raw)
+	if baseURL == "" {
+		return DefaultBaseURL, nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("TMDB 基础地址格式无效: %s", raw)
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/3")
+	return baseURL, nil
+}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("读取响应失败: %w", err)
-		}
+// looksLikeAccessToken 判断凭据是否为 v4 读访问令牌
+// v4 令牌是以 "eyJ" 开头、包含三段的 JWT，v3 API Key 是 32 位十六进制字符串
+func looksLikeAccessToken(credential string) bool {
+	return strings.HasPrefix(credential, "eyJ") && strings.Count(credential, ".") == 2
+}
 
-		// 处理速率限制（429 Too Many Requests）
-		if resp.StatusCode == http.StatusTooManyRequests {
-			if attempt >= maxRetries {
-				return nil, fmt.Errorf("TMDB API 速率限制，已重试 %d 次仍失败", maxRetries)
-			}
+// maxRetries 速率限制重试最大次数
+const maxRetries = 3
 
-			// 从 Retry-After 头获取等待时间，默认等待 2 秒
-			waitSeconds := 2
-			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
-				if seconds, err := strconv.Atoi(retryAfter); err == nil {
-					waitSeconds = seconds
-				}
-			}
-			time.Sleep(time.Duration(waitSeconds) * time.Second)
-			continue
-		}
+// newRequest 构建带认证信息的 GET 请求
+// 配置了读访问令牌时使用 Authorization 头，否则通过 api_key 查询参数认证
+func (c *Client) newRequest(ctx context.Context, path string, params url.Values) (*http.Request, error) {
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	if c.AccessToken == "" && c.APIKey != "" {
+		query.Set("api_key", c.APIKey)
+	}
 
-		if resp.StatusCode == http.StatusUnauthorized {
-			return nil, &AuthError{StatusCode: resp.StatusCode, Body: string(body)}
-		}
+	reqURL := c.BaseURL + path
+	if encoded := query.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("TMDB API 返回错误状态码 %d: %s", resp.StatusCode, string(body))
-		}
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
 
-		return body, nil
+	req.Header.Set("Accept", "application/json")
+	if c.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.AccessToken)
 	}
+	return req, nil
+}
 
-	return nil, fmt.Errorf("TMDB API 请求失败，超过最大重试次数")
+// doRequest 执行 HTTP 请求，处理 429 速率限制自动重试
+func (c *Client) doRequest(path string) ([]byte, error) {
+	return c.doRequestWithContext(context.Background(), path, nil)
 }
 
 // GetTVShowDetails 获取电视节目详情，包含季数信息
 // 调用 GET /3/tv/{series_id}
 func (c *Client) GetTVShowDetails(tmdbID int) (*TVShowDetails, error) {
-	path := fmt.Sprintf("/3/tv/%d", tmdbID)
+	return c.GetTVShowDetailsWithContext(context.Background(), tmdbID)
+}
 
-	body, err := c.doRequest(path)
-	if err != nil {
-		return nil, fmt.Errorf("获取电视节目详情失败 (TMDB ID=%d): %w", tmdbID, err)
+// withLanguageParams 在请求参数中补充语言偏好，调用方显式指定的参数优先
+func (c *Client) withLanguageParams(params url.Values) url.Values {
+	if len(c.Languages) == 0 && len(c.ImageLanguages) == 0 {
+		return params
 	}
-
-	var details TVShowDetails
-	if err := json.Unmarshal(body, &details); err != nil {
-		return nil, fmt.Errorf("解析电视节目详情失败 (TMDB ID=%d): %w", tmdbID, err)
+	merged := url.Values{}
+	for k, v := range params {
+		merged[k] = v
+	}
+	if len(c.Languages) > 0 && merged.Get("language") == "" {
+		merged.Set("language", c.Languages[0])
+	}
+	if len(c.ImageLanguages) > 0 && merged.Get("include_image_language") == "" {
+		// null 表示无文字的图片，始终作为兜底
+		merged.Set("include_image_language", strings.Join(c.ImageLanguages, ",")+",null")
 	}
+	return merged
+}
 
-	return &details, nil
+// tvDetailsParams 电视节目详情请求参数
+// 配置了多个元数据语言时附带 translations，一次请求即可获得各语言的节目名称
+func (c *Client) tvDetailsParams() url.Values {
+	if len(c.Languages) < 2 {
+		return nil
+	}
+	return url.Values{"append_to_response": {"translations"}}
 }
 
 // doRequestWithContext 使用 context 执行 HTTP 请求，处理 429 速率限制自动重试
-// 在重试等待期间检查 context 是否已取消
-func (c *Client) doRequestWithContext(ctx context.Context, path string) ([]byte, error) {
-	url := fmt.Sprintf("%s%s?api_key=%s", c.BaseURL, path, c.APIKey)
+// 在重试等待期间检查 context 是否已取消；成功响应写入响应缓存
+func (c *Client) doRequestWithContext(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	// 语言参数参与缓存键，切换语言偏好后不会命中旧语言的响应
+	params = c.withLanguageParams(params)
+	cacheKey := c.BaseURL + path + "?" + params.Encode()
+	if c.Cache != nil {
+		if body, ok := c.Cache.Get(cacheKey); ok {
+			return body, nil
+		}
+	}
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		// 检查 context 是否已取消
@@ -148,13 +250,11 @@ func (c *Client) doRequestWithContext(ctx context.Context, path string) ([]byte,
 		default:
 		}
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req, err := c.newRequest(ctx, path, params)
 		if err != nil {
-			return nil, fmt.Errorf("创建请求失败: %w", err)
+			return nil, err
 		}
 
-		req.Header.Set("Accept", "application/json")
-
 		resp, err := c.HTTPClient.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("请求失败: %w", err)
@@ -198,6 +298,9 @@ func (c *Client) doRequestWithContext(ctx context.Context, path string) ([]byte,
 			return nil, fmt.Errorf("TMDB API 返回错误状态码 %d: %s", resp.StatusCode, string(body))
 		}
 
+		if c.Cache != nil {
+			c.Cache.Set(cacheKey, body)
+		}
 		return body, nil
 	}
 
@@ -208,7 +311,7 @@ func (c *Client) doRequestWithContext(ctx context.Context, path string) ([]byte,
 func (c *Client) GetTVShowDetailsWithContext(ctx context.Context, tmdbID int) (*TVShowDetails, error) {
 	path := fmt.Sprintf("/3/tv/%d", tmdbID)
 
-	body, err := c.doRequestWithContext(ctx, path)
+	body, err := c.doRequestWithContext(ctx, path, c.tvDetailsParams())
 	if err != nil {
 		return nil, fmt.Errorf("获取电视节目详情失败 (TMDB ID=%d): %w", tmdbID, err)
 	}
@@ -0,0 +1,134 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// 剧集组类型（TMDB episode group type）
+const (
+	EpisodeGroupOriginalAirDate = 1 // 首播日期
+	EpisodeGroupAbsolute        = 2 // 绝对顺序
+	EpisodeGroupDVD             = 3 // DVD
+	EpisodeGroupDigital         = 4 // 数字发行
+	EpisodeGroupStoryArc        = 5 // 故事线
+	EpisodeGroupProduction      = 6 // 制作顺序
+	EpisodeGroupTV              = 7 // 电视播出
+)
+
+// EpisodeGroupSummary 电视节目的剧集组概要
+type EpisodeGroupSummary struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Type         int    `json:"type"`
+	EpisodeCount int    `json:"episode_count"`
+	GroupCount   int    `json:"group_count"`
+}
+
+// EpisodeGroupDetails 剧集组详情，包含每个分组下的剧集
+type EpisodeGroupDetails struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Type         int            `json:"type"`
+	EpisodeCount int            `json:"episode_count"`
+	GroupCount   int            `json:"group_count"`
+	Groups       []EpisodeGroup `json:"groups"`
+}
+
+// EpisodeGroup 剧集组中的一个分组（通常对应一季）
+type EpisodeGroup struct {
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Order    int                 `json:"order"`
+	Locked   bool                `json:"locked"`
+	Episodes []EpisodeGroupEntry `json:"episodes"`
+}
+
+// EpisodeGroupEntry 分组内的单集，SeasonNumber/EpisodeNumber 为默认季集编号
+type EpisodeGroupEntry struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+	Order         int    `json:"order"`
+}
+
+// GetTVEpisodeGroupsWithContext 获取电视节目的剧集组列表
+// 调用 GET /3/tv/{series_id}/episode_groups
+func (c *Client) GetTVEpisodeGroupsWithContext(ctx context.Context, tmdbID int) ([]EpisodeGroupSummary, error) {
+	path := fmt.Sprintf("/3/tv/%d/episode_groups", tmdbID)
+
+	body, err := c.doRequestWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取剧集组列表失败 (TMDB ID=%d): %w", tmdbID, err)
+	}
+
+	var resp struct {
+		Results []EpisodeGroupSummary `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析剧集组列表失败 (TMDB ID=%d): %w", tmdbID, err)
+	}
+
+	return resp.Results, nil
+}
+
+// GetEpisodeGroupWithContext 获取剧集组详情
+// 调用 GET /3/tv/episode_group/{tv_episode_group_id}
+func (c *Client) GetEpisodeGroupWithContext(ctx context.Context, groupID string) (*EpisodeGroupDetails, error) {
+	if strings.TrimSpace(groupID) == "" {
+		return nil, fmt.Errorf("剧集组 ID 不能为空")
+	}
+	path := "/3/tv/episode_group/" + url.PathEscape(groupID)
+
+	body, err := c.doRequestWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取剧集组详情失败 (ID=%s): %w", groupID, err)
+	}
+
+	var details EpisodeGroupDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, fmt.Errorf("解析剧集组详情失败 (ID=%s): %w", groupID, err)
+	}
+
+	return &details, nil
+}
+
+// Seasons 将剧集组转换为季信息，便于与默认季结构使用同一套对比逻辑
+// 分组按 order 排序；order=0 的分组为特别篇（名称含 Special/特别篇）时季号直接取 order，
+// 否则 order 从 0 开始对应第 1 季
+func (d *EpisodeGroupDetails) Seasons() []Season {
+	groups := make([]EpisodeGroup, len(d.Groups))
+	copy(groups, d.Groups)
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Order < groups[j].Order })
+
+	offset := 1
+	for _, g := range groups {
+		if g.Order == 0 && isSpecialsGroup(g.Name) {
+			offset = 0
+			break
+		}
+	}
+
+	seasons := make([]Season, 0, len(groups))
+	for _, g := range groups {
+		seasons = append(seasons, Season{
+			SeasonNumber: g.Order + offset,
+			EpisodeCount: len(g.Episodes),
+			Name:         g.Name,
+		})
+	}
+	return seasons
+}
+
+// isSpecialsGroup 判断分组名称是否表示特别篇
+func isSpecialsGroup(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "special") || strings.Contains(name, "特别") || strings.Contains(name, "特別")
+}
@@ -0,0 +1,75 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestEpisodeGroups 获取剧集组列表和详情
+func TestEpisodeGroups(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/3/tv/100/episode_groups", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":100,"results":[{"id":"5b11","name":"Absolute Order","type":2,"episode_count":24,"group_count":1}]}`))
+	})
+	mux.HandleFunc("/3/tv/episode_group/5b11", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(EpisodeGroupDetails{
+			ID:   "5b11",
+			Name: "Absolute Order",
+			Type: EpisodeGroupAbsolute,
+			Groups: []EpisodeGroup{
+				{Name: "Season 1", Order: 0, Episodes: make([]EpisodeGroupEntry, 24)},
+			},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client()}
+
+	groups, err := client.GetTVEpisodeGroupsWithContext(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("获取剧集组列表失败: %v", err)
+	}
+	if len(groups) != 1 || groups[0].ID != "5b11" || groups[0].Type != EpisodeGroupAbsolute {
+		t.Fatalf("剧集组列表解析不正确: %+v", groups)
+	}
+
+	group, err := client.GetEpisodeGroupWithContext(context.Background(), "5b11")
+	if err != nil {
+		t.Fatalf("获取剧集组详情失败: %v", err)
+	}
+	seasons := group.Seasons()
+	if len(seasons) != 1 || seasons[0].SeasonNumber != 1 || seasons[0].EpisodeCount != 24 {
+		t.Errorf("剧集组换算季结构不正确: %+v", seasons)
+	}
+
+	if _, err := client.GetEpisodeGroupWithContext(context.Background(), " "); err == nil {
+		t.Error("空剧集组 ID 应返回错误")
+	}
+}
+
+// TestEpisodeGroupSeasons 分组按 order 排序换算季号，特别篇分组占用第 0 季
+func TestEpisodeGroupSeasons(t *testing.T) {
+	withoutSpecials := &EpisodeGroupDetails{Groups: []EpisodeGroup{
+		{Name: "Part 2", Order: 1, Episodes: make([]EpisodeGroupEntry, 10)},
+		{Name: "Part 1", Order: 0, Episodes: make([]EpisodeGroupEntry, 12)},
+	}}
+	seasons := withoutSpecials.Seasons()
+	if len(seasons) != 2 ||
+		seasons[0] != (Season{SeasonNumber: 1, EpisodeCount: 12, Name: "Part 1"}) ||
+		seasons[1] != (Season{SeasonNumber: 2, EpisodeCount: 10, Name: "Part 2"}) {
+		t.Errorf("无特别篇时季号应从 1 开始: %+v", seasons)
+	}
+
+	withSpecials := &EpisodeGroupDetails{Groups: []EpisodeGroup{
+		{Name: "Specials", Order: 0, Episodes: make([]EpisodeGroupEntry, 3)},
+		{Name: "Season 1", Order: 1, Episodes: make([]EpisodeGroupEntry, 12)},
+	}}
+	seasons = withSpecials.Seasons()
+	if len(seasons) != 2 || seasons[0].SeasonNumber != 0 || seasons[1].SeasonNumber != 1 {
+		t.Errorf("特别篇分组应为第 0 季: %+v", seasons)
+	}
+}
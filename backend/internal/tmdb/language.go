@@ -0,0 +1,86 @@
+package tmdb
+
+import "strings"
+
+// TranslationList TMDB translations 附加响应
+type TranslationList struct {
+	Translations []Translation `json:"translations"`
+}
+
+// Translation TMDB 单个语言的翻译条目
+type Translation struct {
+	ISO3166 string          `json:"iso_3166_1"` // 地区代码，如 CN
+	ISO639  string          `json:"iso_639_1"`  // 语言代码，如 zh
+	Data    TranslationData `json:"data"`
+}
+
+// TranslationData 翻译内容（电视节目使用 name，电影使用 title）
+type TranslationData struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+// Tag 返回翻译条目的语言标签，如 zh-CN
+func (t Translation) Tag() string {
+	if t.ISO3166 == "" {
+		return t.ISO639
+	}
+	return t.ISO639 + "-" + t.ISO3166
+}
+
+// ParseLanguages 解析逗号或空白分隔的语言列表，去除空项和重复项
+// 例："zh-CN, en-US" -> [zh-CN en-US]
+func ParseLanguages(raw string) []string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+	})
+	return normalizeLanguages(fields)
+}
+
+// normalizeLanguages 去除空项和重复项（不区分大小写），保留原有顺序
+func normalizeLanguages(langs []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, lang := range langs {
+		lang = strings.TrimSpace(lang)
+		if lang == "" || seen[strings.ToLower(lang)] {
+			continue
+		}
+		seen[strings.ToLower(lang)] = true
+		result = append(result, lang)
+	}
+	return result
+}
+
+// TranslatedName 返回指定语言的节目名称，没有对应翻译时返回空字符串
+// lang 可以是完整标签（zh-CN）或仅语言代码（zh）；仅语言代码时取第一个匹配的地区
+func (d *TVShowDetails) TranslatedName(lang string) string {
+	if d.Translations == nil || lang == "" {
+		return ""
+	}
+	for _, t := range d.Translations.Translations {
+		if strings.EqualFold(t.Tag(), lang) && t.Data.Name != "" {
+			return t.Data.Name
+		}
+	}
+	if strings.Contains(lang, "-") {
+		return ""
+	}
+	for _, t := range d.Translations.Translations {
+		if strings.EqualFold(t.ISO639, lang) && t.Data.Name != "" {
+			return t.Data.Name
+		}
+	}
+	return ""
+}
+
+// LocalizedName 按语言优先级返回节目名称
+// 依次尝试各语言的翻译，均缺失时返回请求语言下的名称（TMDB 会自动回退到原始名称）
+func (d *TVShowDetails) LocalizedName(langs []string) string {
+	for _, lang := range langs {
+		if name := d.TranslatedName(lang); name != "" {
+			return name
+		}
+	}
+	return d.Name
+}
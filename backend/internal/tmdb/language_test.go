@@ -0,0 +1,110 @@
+package tmdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"embyforge/internal/respcache"
+)
+
+// TestParseLanguages 解析语言列表时去除空项和重复项，保留顺序
+func TestParseLanguages(t *testing.T) {
+	cases := map[string][]string{
+		"":                    nil,
+		"zh-CN":               {"zh-CN"},
+		"zh-CN, en-US":        {"zh-CN", "en-US"},
+		" zh-CN;en-US zh-cn ": {"zh-CN", "en-US"},
+		",,ja-JP,,":           {"ja-JP"},
+	}
+	for raw, want := range cases {
+		if got := ParseLanguages(raw); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseLanguages(%q) = %v, 期望 %v", raw, got, want)
+		}
+	}
+}
+
+// TestLanguageParams 配置语言偏好后请求附带 language / include_image_language / translations
+func TestLanguageParams(t *testing.T) {
+	server := newTVShowServer(t, func(r *http.Request) {
+		q := r.URL.Query()
+		if got := q.Get("language"); got != "zh-CN" {
+			t.Errorf("期望 language=zh-CN，实际 %q", got)
+		}
+		if got := q.Get("include_image_language"); got != "zh,en,null" {
+			t.Errorf("期望 include_image_language=zh,en,null，实际 %q", got)
+		}
+		if got := q.Get("append_to_response"); got != "translations" {
+			t.Errorf("期望 append_to_response=translations，实际 %q", got)
+		}
+	})
+
+	client, err := NewClientWithOptions(Options{
+		APIKey:         "v3-key",
+		BaseURL:        server.URL,
+		Languages:      []string{"zh-CN", "", "en-US"},
+		ImageLanguages: []string{"zh", "en"},
+	})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	if _, err := client.GetTVShowDetails(100); err != nil {
+		t.Fatalf("获取电视节目详情失败: %v", err)
+	}
+}
+
+// TestLanguageParams_SeparateCacheEntries 不同语言偏好的响应分别缓存
+func TestLanguageParams_SeparateCacheEntries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(TVShowDetails{ID: 100, Name: r.URL.Query().Get("language")})
+	}))
+	defer server.Close()
+
+	cache := respcache.New(time.Minute)
+	zh := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client(), Cache: cache, Languages: []string{"zh-CN"}}
+	en := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client(), Cache: cache, Languages: []string{"en-US"}}
+
+	for i := 0; i < 2; i++ {
+		if d, err := zh.GetTVShowDetails(100); err != nil || d.Name != "zh-CN" {
+			t.Fatalf("中文请求结果不正确: %+v, %v", d, err)
+		}
+		if d, err := en.GetTVShowDetails(100); err != nil || d.Name != "en-US" {
+			t.Fatalf("英文请求结果不正确: %+v, %v", d, err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("期望每种语言各请求 1 次，实际共 %d 次", requests)
+	}
+}
+
+// TestLocalizedName 按语言优先级回退选取节目名称
+func TestLocalizedName(t *testing.T) {
+	details := &TVShowDetails{
+		Name: "Shingeki no Kyojin",
+		Translations: &TranslationList{Translations: []Translation{
+			{ISO639: "zh", ISO3166: "TW", Data: TranslationData{Name: "進擊的巨人"}},
+			{ISO639: "zh", ISO3166: "CN", Data: TranslationData{Name: ""}},
+			{ISO639: "en", ISO3166: "US", Data: TranslationData{Name: "Attack on Titan"}},
+		}},
+	}
+
+	cases := []struct {
+		langs []string
+		want  string
+	}{
+		{[]string{"zh-CN", "en-US"}, "Attack on Titan"}, // zh-CN 翻译为空，回退到 en-US
+		{[]string{"zh", "en-US"}, "進擊的巨人"},              // 仅语言代码时匹配任意地区
+		{[]string{"ja-JP"}, "Shingeki no Kyojin"},       // 均无翻译时使用请求语言下的名称
+		{nil, "Shingeki no Kyojin"},
+	}
+	for _, tc := range cases {
+		if got := details.LocalizedName(tc.langs); got != tc.want {
+			t.Errorf("LocalizedName(%v) = %q, 期望 %q", tc.langs, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CollectionRef 电影所属合集的简要信息
+type CollectionRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// MovieDetails TMDB 电影详情
+type MovieDetails struct {
+	ID                  int            `json:"id"`
+	Title               string         `json:"title"`
+	OriginalTitle       string         `json:"original_title"`
+	ReleaseDate         string         `json:"release_date"` // 格式：2006-01-02
+	Runtime             int            `json:"runtime"`
+	ImdbID              string         `json:"imdb_id"`
+	BelongsToCollection *CollectionRef `json:"belongs_to_collection"` // 不属于任何合集时为 nil
+}
+
+// Year 返回上映年份，日期缺失时返回 0
+func (m *MovieDetails) Year() int {
+	return yearFromDate(m.ReleaseDate)
+}
+
+// CollectionDetails TMDB 合集详情
+type CollectionDetails struct {
+	ID    int            `json:"id"`
+	Name  string         `json:"name"`
+	Parts []SearchResult `json:"parts"` // 合集内的电影
+}
+
+// GetMovieDetailsWithContext 获取电影详情
+// 调用 GET /3/movie/{movie_id}
+func (c *Client) GetMovieDetailsWithContext(ctx context.Context, tmdbID int) (*MovieDetails, error) {
+	path := fmt.Sprintf("/3/movie/%d", tmdbID)
+
+	body, err := c.doRequestWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取电影详情失败 (TMDB ID=%d): %w", tmdbID, err)
+	}
+
+	var details MovieDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, fmt.Errorf("解析电影详情失败 (TMDB ID=%d): %w", tmdbID, err)
+	}
+
+	return &details, nil
+}
+
+// GetCollectionDetailsWithContext 获取合集详情，包含合集内所有电影
+// 调用 GET /3/collection/{collection_id}
+func (c *Client) GetCollectionDetailsWithContext(ctx context.Context, collectionID int) (*CollectionDetails, error) {
+	path := fmt.Sprintf("/3/collection/%d", collectionID)
+
+	body, err := c.doRequestWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取合集详情失败 (合集 ID=%d): %w", collectionID, err)
+	}
+
+	var details CollectionDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, fmt.Errorf("解析合集详情失败 (合集 ID=%d): %w", collectionID, err)
+	}
+
+	// 合集接口不返回 media_type，补全以便调用方统一处理
+	for i := range details.Parts {
+		if details.Parts[i].MediaType == "" {
+			details.Parts[i].MediaType = MediaTypeMovie
+		}
+	}
+
+	return &details, nil
+}
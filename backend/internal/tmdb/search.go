@@ -0,0 +1,212 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 媒体类型（与 TMDB media_type 字段取值一致）
+const (
+	MediaTypeMovie  = "movie"
+	MediaTypeTV     = "tv"
+	MediaTypePerson = "person"
+)
+
+// 外部 ID 来源（用于 /3/find 接口的 external_source 参数）
+const (
+	ExternalSourceIMDB = "imdb_id"
+	ExternalSourceTVDB = "tvdb_id"
+)
+
+// SearchResult 搜索结果条目（电影和电视节目共用）
+// 电影使用 Title/ReleaseDate，电视节目使用 Name/FirstAirDate
+type SearchResult struct {
+	ID            int     `json:"id"`
+	MediaType     string  `json:"media_type"`
+	Title         string  `json:"title"`
+	OriginalTitle string  `json:"original_title"`
+	ReleaseDate   string  `json:"release_date"`
+	Name          string  `json:"name"`
+	OriginalName  string  `json:"original_name"`
+	FirstAirDate  string  `json:"first_air_date"`
+	Popularity    float64 `json:"popularity"`
+}
+
+// DisplayName 返回条目名称（电影取 Title，电视节目取 Name）
+func (r *SearchResult) DisplayName() string {
+	if r.Title != "" {
+		return r.Title
+	}
+	return r.Name
+}
+
+// Year 返回上映/首播年份，日期缺失时返回 0
+func (r *SearchResult) Year() int {
+	if r.ReleaseDate != "" {
+		return yearFromDate(r.ReleaseDate)
+	}
+	return yearFromDate(r.FirstAirDate)
+}
+
+// SearchResponse 搜索接口分页响应
+type SearchResponse struct {
+	Page         int            `json:"page"`
+	TotalPages   int            `json:"total_pages"`
+	TotalResults int            `json:"total_results"`
+	Results      []SearchResult `json:"results"`
+}
+
+// FindResult 按外部 ID 查找的结果
+type FindResult struct {
+	MovieResults []SearchResult `json:"movie_results"`
+	TVResults    []SearchResult `json:"tv_results"`
+}
+
+// SearchMovieWithContext 按名称搜索电影，year > 0 时按上映年份过滤
+// 调用 GET /3/search/movie
+func (c *Client) SearchMovieWithContext(ctx context.Context, query string, year int) (*SearchResponse, error) {
+	params := url.Values{}
+	if year > 0 {
+		params.Set("year", strconv.Itoa(year))
+	}
+	resp, err := c.search(ctx, "/3/search/movie", query, params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.Results {
+		resp.Results[i].MediaType = MediaTypeMovie
+	}
+	return resp, nil
+}
+
+// SearchTVWithContext 按名称搜索电视节目，year > 0 时按首播年份过滤
+// 调用 GET /3/search/tv
+func (c *Client) SearchTVWithContext(ctx context.Context, query string, year int) (*SearchResponse, error) {
+	params := url.Values{}
+	if year > 0 {
+		params.Set("first_air_date_year", strconv.Itoa(year))
+	}
+	resp, err := c.search(ctx, "/3/search/tv", query, params)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.Results {
+		resp.Results[i].MediaType = MediaTypeTV
+	}
+	return resp, nil
+}
+
+// SearchMultiWithContext 同时搜索电影和电视节目（忽略人物结果）
+// TMDB 的 multi 搜索不支持年份参数，year > 0 时在本地按年份过滤（前后允许 1 年误差）
+// 调用 GET /3/search/multi
+func (c *Client) SearchMultiWithContext(ctx context.Context, query string, year int) (*SearchResponse, error) {
+	resp, err := c.search(ctx, "/3/search/multi", query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := resp.Results[:0]
+	for _, r := range resp.Results {
+		if r.MediaType != MediaTypeMovie && r.MediaType != MediaTypeTV {
+			continue
+		}
+		if year > 0 {
+			if y := r.Year(); y != 0 && (y < year-1 || y > year+1) {
+				continue
+			}
+		}
+		filtered = append(filtered, r)
+	}
+	resp.Results = filtered
+	return resp, nil
+}
+
+// search 执行搜索请求（只取第一页结果）
+func (c *Client) search(ctx context.Context, path, query string, params url.Values) (*SearchResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("搜索关键词不能为空")
+	}
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("query", query)
+
+	body, err := c.doRequestWithContext(ctx, path, params)
+	if err != nil {
+		return nil, fmt.Errorf("搜索失败 (%q): %w", query, err)
+	}
+
+	var resp SearchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析搜索结果失败 (%q): %w", query, err)
+	}
+	return &resp, nil
+}
+
+// FindByExternalIDWithContext 按外部 ID（IMDB / TVDB）查找 TMDB 条目
+// source 取值为 ExternalSourceIMDB 或 ExternalSourceTVDB
+// 调用 GET /3/find/{external_id}
+func (c *Client) FindByExternalIDWithContext(ctx context.Context, externalID, source string) (*FindResult, error) {
+	externalID = strings.TrimSpace(externalID)
+	if externalID == "" {
+		return nil, errors.New("外部 ID 不能为空")
+	}
+	if source != ExternalSourceIMDB && source != ExternalSourceTVDB {
+		return nil, fmt.Errorf("不支持的外部 ID 来源: %s", source)
+	}
+
+	params := url.Values{}
+	params.Set("external_source", source)
+	path := "/3/find/" + url.PathEscape(externalID)
+
+	body, err := c.doRequestWithContext(ctx, path, params)
+	if err != nil {
+		return nil, fmt.Errorf("按外部 ID 查找失败 (%s=%s): %w", source, externalID, err)
+	}
+
+	var result FindResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析查找结果失败 (%s=%s): %w", source, externalID, err)
+	}
+	for i := range result.MovieResults {
+		result.MovieResults[i].MediaType = MediaTypeMovie
+	}
+	for i := range result.TVResults {
+		result.TVResults[i].MediaType = MediaTypeTV
+	}
+	return &result, nil
+}
+
+// FindByIMDBIDWithContext 将 IMDB ID（如 tt0111161）转换为 TMDB 条目
+// 返回第一个匹配的电影或电视节目，没有匹配时返回 nil
+func (c *Client) FindByIMDBIDWithContext(ctx context.Context, imdbID string) (*SearchResult, error) {
+	result, err := c.FindByExternalIDWithContext(ctx, imdbID, ExternalSourceIMDB)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.MovieResults) > 0 {
+		return &result.MovieResults[0], nil
+	}
+	if len(result.TVResults) > 0 {
+		return &result.TVResults[0], nil
+	}
+	return nil, nil
+}
+
+// yearFromDate 从 "2006-01-02" 格式的日期中提取年份，格式不符时返回 0
+func yearFromDate(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
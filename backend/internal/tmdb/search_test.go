@@ -0,0 +1,187 @@
+package tmdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"embyforge/internal/respcache"
+)
+
+// newStandInServer 创建按路径返回固定 JSON 的 TMDB 替身服务器
+func newStandInServer(t *testing.T, routes map[string]string, check func(r *http.Request)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if check != nil {
+			check(r)
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status_code":34}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// TestSearchMovie_YearParam 电影搜索应传递 query 和 year 参数
+func TestSearchMovie_YearParam(t *testing.T) {
+	server, _ := newStandInServer(t, map[string]string{
+		"/3/search/movie": `{"page":1,"total_pages":1,"total_results":1,"results":[{"id":603,"title":"The Matrix","release_date":"1999-03-30"}]}`,
+	}, func(r *http.Request) {
+		if r.URL.Query().Get("query") != "The Matrix" || r.URL.Query().Get("year") != "1999" {
+			t.Errorf("搜索参数不正确: %s", r.URL.RawQuery)
+		}
+	})
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client()}
+	resp, err := client.SearchMovieWithContext(context.Background(), "The Matrix", 1999)
+	if err != nil {
+		t.Fatalf("搜索电影失败: %v", err)
+	}
+	if len(resp.Results) != 1 {
+		t.Fatalf("期望 1 条结果，实际 %d", len(resp.Results))
+	}
+	r := resp.Results[0]
+	if r.MediaType != MediaTypeMovie || r.DisplayName() != "The Matrix" || r.Year() != 1999 {
+		t.Errorf("结果解析不正确: %+v", r)
+	}
+}
+
+// TestSearchTV_YearParam 电视节目搜索使用 first_air_date_year 参数
+func TestSearchTV_YearParam(t *testing.T) {
+	server, _ := newStandInServer(t, map[string]string{
+		"/3/search/tv": `{"page":1,"results":[{"id":1399,"name":"Game of Thrones","first_air_date":"2011-04-17"}]}`,
+	}, func(r *http.Request) {
+		if r.URL.Query().Get("first_air_date_year") != "2011" {
+			t.Errorf("缺少 first_air_date_year 参数: %s", r.URL.RawQuery)
+		}
+	})
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client()}
+	resp, err := client.SearchTVWithContext(context.Background(), "Game of Thrones", 2011)
+	if err != nil {
+		t.Fatalf("搜索电视节目失败: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].MediaType != MediaTypeTV || resp.Results[0].Year() != 2011 {
+		t.Errorf("结果解析不正确: %+v", resp.Results)
+	}
+}
+
+// TestSearchMulti_FiltersPersonAndYear multi 搜索应过滤人物结果并在本地按年份过滤
+func TestSearchMulti_FiltersPersonAndYear(t *testing.T) {
+	server, _ := newStandInServer(t, map[string]string{
+		"/3/search/multi": `{"page":1,"results":[
+			{"id":1,"media_type":"movie","title":"A","release_date":"2010-01-01"},
+			{"id":2,"media_type":"tv","name":"B","first_air_date":"2011-05-01"},
+			{"id":3,"media_type":"person","name":"C"},
+			{"id":4,"media_type":"movie","title":"D","release_date":"2020-01-01"}
+		]}`,
+	}, nil)
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client()}
+	resp, err := client.SearchMultiWithContext(context.Background(), "A", 2010)
+	if err != nil {
+		t.Fatalf("multi 搜索失败: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].ID != 1 || resp.Results[1].ID != 2 {
+		t.Errorf("过滤结果不正确: %+v", resp.Results)
+	}
+}
+
+// TestFindByIMDBID IMDB ID 应转换为对应的 TMDB 条目
+func TestFindByIMDBID(t *testing.T) {
+	server, _ := newStandInServer(t, map[string]string{
+		"/3/find/tt0133093": `{"movie_results":[{"id":603,"title":"The Matrix"}],"tv_results":[]}`,
+		"/3/find/tt0000000": `{"movie_results":[],"tv_results":[]}`,
+	}, func(r *http.Request) {
+		if r.URL.Query().Get("external_source") != ExternalSourceIMDB {
+			t.Errorf("external_source 参数不正确: %s", r.URL.RawQuery)
+		}
+	})
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client()}
+	result, err := client.FindByIMDBIDWithContext(context.Background(), "tt0133093")
+	if err != nil {
+		t.Fatalf("查找失败: %v", err)
+	}
+	if result == nil || result.ID != 603 || result.MediaType != MediaTypeMovie {
+		t.Errorf("查找结果不正确: %+v", result)
+	}
+
+	result, err = client.FindByIMDBIDWithContext(context.Background(), "tt0000000")
+	if err != nil || result != nil {
+		t.Errorf("无匹配时应返回 nil, nil，实际 %+v, %v", result, err)
+	}
+
+	if _, err := client.FindByExternalIDWithContext(context.Background(), "x", "unknown"); err == nil {
+		t.Error("不支持的外部 ID 来源应返回错误")
+	}
+}
+
+// TestMovieAndCollectionDetails 电影详情和合集详情解析
+func TestMovieAndCollectionDetails(t *testing.T) {
+	server, _ := newStandInServer(t, map[string]string{
+		"/3/movie/603":       `{"id":603,"title":"The Matrix","release_date":"1999-03-30","imdb_id":"tt0133093","belongs_to_collection":{"id":2344,"name":"The Matrix Collection"}}`,
+		"/3/collection/2344": `{"id":2344,"name":"The Matrix Collection","parts":[{"id":603,"title":"The Matrix"},{"id":604,"title":"The Matrix Reloaded"}]}`,
+	}, nil)
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client()}
+	movie, err := client.GetMovieDetailsWithContext(context.Background(), 603)
+	if err != nil {
+		t.Fatalf("获取电影详情失败: %v", err)
+	}
+	if movie.Year() != 1999 || movie.BelongsToCollection == nil || movie.BelongsToCollection.ID != 2344 {
+		t.Fatalf("电影详情解析不正确: %+v", movie)
+	}
+
+	collection, err := client.GetCollectionDetailsWithContext(context.Background(), movie.BelongsToCollection.ID)
+	if err != nil {
+		t.Fatalf("获取合集详情失败: %v", err)
+	}
+	if len(collection.Parts) != 2 || collection.Parts[1].MediaType != MediaTypeMovie {
+		t.Errorf("合集详情解析不正确: %+v", collection)
+	}
+
+	if _, err := client.GetMovieDetailsWithContext(context.Background(), 1); err == nil {
+		t.Error("不存在的电影应返回错误")
+	}
+}
+
+// TestResponseCache_SharedAcrossLookups 相同请求命中缓存，不同请求各自请求一次
+func TestResponseCache_SharedAcrossLookups(t *testing.T) {
+	server, hits := newStandInServer(t, map[string]string{
+		"/3/tv/1":    `{"id":1,"name":"Show","seasons":[]}`,
+		"/3/movie/2": `{"id":2,"title":"Movie"}`,
+	}, nil)
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client(), Cache: respcache.New(time.Minute)}
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetTVShowDetailsWithContext(ctx, 1); err != nil {
+			t.Fatalf("获取电视节目详情失败: %v", err)
+		}
+		if _, err := client.GetMovieDetailsWithContext(ctx, 2); err != nil {
+			t.Fatalf("获取电影详情失败: %v", err)
+		}
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("期望请求 2 次（其余命中缓存），实际 %d 次", got)
+	}
+
+	// 错误响应不应被缓存
+	client.GetMovieDetailsWithContext(ctx, 404)
+	client.GetMovieDetailsWithContext(ctx, 404)
+	if got := hits.Load(); got != 4 {
+		t.Errorf("错误响应不应缓存，期望累计 4 次请求，实际 %d 次", got)
+	}
+}
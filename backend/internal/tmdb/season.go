@@ -0,0 +1,42 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SeasonEpisode 季详情中的单集
+type SeasonEpisode struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	EpisodeNumber int    `json:"episode_number"`
+	SeasonNumber  int    `json:"season_number"`
+	AirDate       string `json:"air_date"`
+}
+
+// SeasonDetails TMDB 季详情
+type SeasonDetails struct {
+	ID           int             `json:"id"`
+	Name         string          `json:"name"`
+	SeasonNumber int             `json:"season_number"`
+	Episodes     []SeasonEpisode `json:"episodes"`
+}
+
+// GetTVSeasonDetailsWithContext 获取电视节目某一季的详情，包含每集标题
+// 调用 GET /3/tv/{series_id}/season/{season_number}
+func (c *Client) GetTVSeasonDetailsWithContext(ctx context.Context, tmdbID, seasonNumber int) (*SeasonDetails, error) {
+	path := fmt.Sprintf("/3/tv/%d/season/%d", tmdbID, seasonNumber)
+
+	body, err := c.doRequestWithContext(ctx, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取季详情失败 (TMDB ID=%d, S%d): %w", tmdbID, seasonNumber, err)
+	}
+
+	var details SeasonDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, fmt.Errorf("解析季详情失败 (TMDB ID=%d, S%d): %w", tmdbID, seasonNumber, err)
+	}
+
+	return &details, nil
+}
@@ -0,0 +1,34 @@
+package tmdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestGetTVSeasonDetails 获取季详情并解析每集标题
+func TestGetTVSeasonDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/3/tv/100/season/2" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id":7,"name":"第 2 季","season_number":2,"episodes":[{"id":1,"name":"启程","episode_number":1,"season_number":2},{"id":2,"name":"重逢","episode_number":2,"season_number":2}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{APIKey: "key", BaseURL: server.URL, HTTPClient: server.Client()}
+
+	season, err := client.GetTVSeasonDetailsWithContext(context.Background(), 100, 2)
+	if err != nil {
+		t.Fatalf("获取季详情失败: %v", err)
+	}
+	if season.SeasonNumber != 2 || len(season.Episodes) != 2 || season.Episodes[1].Name != "重逢" {
+		t.Errorf("季详情解析不正确: %+v", season)
+	}
+
+	if _, err := client.GetTVSeasonDetailsWithContext(context.Background(), 100, 3); err == nil {
+		t.Error("季不存在时应返回错误")
+	}
+}
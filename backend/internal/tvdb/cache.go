@@ -0,0 +1,11 @@
+package tvdb
+
+import "embyforge/internal/respcache"
+
+// sharedCache 进程内共享的响应缓存，由 NewClientWithOptions 创建的客户端使用
+var sharedCache = respcache.New(respcache.DefaultTTL)
+
+// ClearSharedCache 清空共享响应缓存（清空 TMDB 缓存时一并调用，确保下次请求重新拉取 TVDB 数据）
+func ClearSharedCache() {
+	sharedCache.Clear()
+}
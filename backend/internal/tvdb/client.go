@@ -0,0 +1,246 @@
+package tvdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"embyforge/internal/respcache"
+)
+
+// DefaultBaseURL TVDB v4 官方 API 地址
+const DefaultBaseURL = "https://api4.thetvdb.com/v4"
+
+// maxRetries 速率限制重试最大次数
+const maxRetries = 3
+
+// AuthError 表示 TVDB API 认证失败（401）
+type AuthError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("TVDB API 认证失败 (状态码 %d): %s", e.StatusCode, e.Body)
+}
+
+// IsAuthError 判断错误是否为 TVDB 认证错误（401）
+func IsAuthError(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr)
+}
+
+// Client TVDB v4 API 客户端
+// 先通过 POST /login 用 API Key（及可选的订阅 PIN）换取令牌，之后以 Bearer 头访问其他接口
+type Client struct {
+	APIKey     string
+	PIN        string // 用户订阅 PIN，使用项目级 API Key 时可为空
+	BaseURL    string
+	HTTPClient *http.Client
+	Cache      *respcache.Cache // 响应缓存，为 nil 时不缓存
+
+	mu    sync.Mutex
+	token string
+}
+
+// Options TVDB 客户端配置
+type Options struct {
+	APIKey  string
+	PIN     string
+	BaseURL string // API 基础地址（可指向本地替身服务或反向代理），为空时使用官方地址
+}
+
+// NewClientWithOptions 根据配置创建 TVDB API 客户端
+func NewClientWithOptions(opts Options) (*Client, error) {
+	apiKey := strings.TrimSpace(opts.APIKey)
+	if apiKey == "" {
+		return nil, errors.New("未配置 TVDB API Key")
+	}
+
+	baseURL, err := normalizeBaseURL(opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		APIKey:  apiKey,
+		PIN:     strings.TrimSpace(opts.PIN),
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		Cache: sharedCache,
+	}, nil
+}
+
+// normalizeBaseURL 规范化 API 基础地址
+// 去掉末尾的 "/"，未带版本号时补上 "/v4"，为空时返回官方地址
+func normalizeBaseURL(raw string) (string, error) {
+	baseURL := strings.TrimSpace(raw)
+	if baseURL == "" {
+		return DefaultBaseURL, nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("TVDB 基础地址格式无效: %s", raw)
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(baseURL, "/v4") {
+		baseURL += "/v4"
+	}
+	return baseURL, nil
+}
+
+// login 用 API Key 换取访问令牌，令牌在客户端内复用
+func (c *Client) login(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	payload := map[string]string{"apikey": c.APIKey}
+	if c.PIN != "" {
+		payload["pin"] = c.PIN
+	}
+	data, _ := json.Marshal(payload)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/login", bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("创建登录请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("TVDB 登录请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取登录响应失败: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", &AuthError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("TVDB 登录返回错误状态码 %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Data.Token == "" {
+		return "", fmt.Errorf("解析 TVDB 登录响应失败: %s", string(body))
+	}
+
+	c.token = result.Data.Token
+	return c.token, nil
+}
+
+// resetToken 丢弃已失效的令牌，下次请求时重新登录
+func (c *Client) resetToken() {
+	c.mu.Lock()
+	c.token = ""
+	c.mu.Unlock()
+}
+
+// doRequestWithContext 执行 GET 请求
+// 处理 429 速率限制自动重试；令牌过期（401）时重新登录一次；成功响应写入响应缓存
+func (c *Client) doRequestWithContext(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	reqURL := c.BaseURL + path
+	if encoded := params.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
+	if c.Cache != nil {
+		if body, ok := c.Cache.Get(reqURL); ok {
+			return body, nil
+		}
+	}
+
+	relogged := false
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		token, err := c.login(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("请求失败: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取响应失败: %w", err)
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			if c.Cache != nil {
+				c.Cache.Set(reqURL, body)
+			}
+			return body, nil
+
+		case http.StatusUnauthorized:
+			// 令牌有效期约一个月，过期后重新登录一次
+			if relogged {
+				return nil, &AuthError{StatusCode: resp.StatusCode, Body: string(body)}
+			}
+			relogged = true
+			c.resetToken()
+			attempt--
+			continue
+
+		case http.StatusTooManyRequests:
+			if attempt >= maxRetries {
+				return nil, fmt.Errorf("TVDB API 速率限制，已重试 %d 次仍失败", maxRetries)
+			}
+			waitSeconds := 2
+			if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+				if seconds, err := strconv.Atoi(retryAfter); err == nil {
+					waitSeconds = seconds
+				}
+			}
+			timer := time.NewTimer(time.Duration(waitSeconds) * time.Second)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+			continue
+
+		default:
+			return nil, fmt.Errorf("TVDB API 返回错误状态码 %d: %s", resp.StatusCode, string(body))
+		}
+	}
+
+	return nil, fmt.Errorf("TVDB API 请求失败，超过最大重试次数")
+}
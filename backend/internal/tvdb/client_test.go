@@ -0,0 +1,107 @@
+package tvdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"embyforge/internal/respcache"
+)
+
+// newStandInServer 创建 TVDB 替身服务器：/login 颁发令牌，剧集单集列表分两页返回
+func newStandInServer(t *testing.T, logins, requests *int) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4/login", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload["apikey"] != "tvdb-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		*logins++
+		fmt.Fprintf(w, `{"status":"success","data":{"token":"token-%d"}}`, *logins)
+	})
+	mux.HandleFunc("/v4/series/300/episodes/default", func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		// 第一个令牌视为已过期，验证重新登录
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("page") == "1" {
+			w.Write([]byte(`{"data":{"series":{"id":300,"name":"Show"},"episodes":[
+				{"id":3,"seasonNumber":2,"number":1}]},"links":{"next":null}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"series":{"id":300,"name":"Show"},"episodes":[
+			{"id":1,"seasonNumber":1,"number":1},{"id":2,"seasonNumber":1,"number":2},{"id":9,"seasonNumber":0,"number":1}]},
+			"links":{"next":"/v4/series/300/episodes/default?page=1"}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestGetSeriesSeasons 登录换取令牌、令牌过期重新登录、自动翻页并按季汇总集数
+func TestGetSeriesSeasons(t *testing.T) {
+	logins, requests := 0, 0
+	server := newStandInServer(t, &logins, &requests)
+
+	// 基础地址未带 /v4 时自动补全
+	client, err := NewClientWithOptions(Options{APIKey: "tvdb-key", BaseURL: server.URL + "/"})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	client.Cache = respcache.New(respcache.DefaultTTL)
+
+	series, err := client.GetSeriesSeasonsWithContext(context.Background(), 300, "")
+	if err != nil {
+		t.Fatalf("获取季结构失败: %v", err)
+	}
+	want := []Season{{SeasonNumber: 0, EpisodeCount: 1}, {SeasonNumber: 1, EpisodeCount: 2}, {SeasonNumber: 2, EpisodeCount: 1}}
+	if series.Name != "Show" || fmt.Sprint(series.Seasons) != fmt.Sprint(want) {
+		t.Fatalf("季结构不正确: %+v", series)
+	}
+	if logins != 2 {
+		t.Errorf("令牌过期后应重新登录一次，实际登录 %d 次", logins)
+	}
+
+	// 再次查询命中缓存
+	before := requests
+	if _, err := client.GetSeriesSeasonsWithContext(context.Background(), 300, SeasonTypeDefault); err != nil {
+		t.Fatalf("获取季结构失败: %v", err)
+	}
+	if requests != before {
+		t.Errorf("重复查询应命中缓存，额外请求 %d 次", requests-before)
+	}
+}
+
+// TestLoginFailure API Key 无效时返回认证错误
+func TestLoginFailure(t *testing.T) {
+	logins, requests := 0, 0
+	server := newStandInServer(t, &logins, &requests)
+
+	client, err := NewClientWithOptions(Options{APIKey: "wrong-key", BaseURL: server.URL + "/v4"})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	client.Cache = nil
+
+	_, err = client.GetSeriesSeasonsWithContext(context.Background(), 300, "")
+	if !IsAuthError(err) {
+		t.Errorf("期望认证错误，实际 %v", err)
+	}
+}
+
+// TestNewClientWithOptions_InvalidConfig 无效配置应返回错误
+func TestNewClientWithOptions_InvalidConfig(t *testing.T) {
+	for _, opts := range []Options{{}, {APIKey: "key", BaseURL: "ftp://example.com"}} {
+		if _, err := NewClientWithOptions(opts); err == nil {
+			t.Errorf("配置 %+v 应返回错误", opts)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package tvdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// 季类型（TVDB season-type），决定剧集按哪种顺序分季
+const (
+	SeasonTypeDefault  = "default"  // 默认（播出顺序）
+	SeasonTypeOfficial = "official" // 官方顺序
+	SeasonTypeDVD      = "dvd"      // DVD 顺序
+	SeasonTypeAbsolute = "absolute" // 绝对顺序
+)
+
+// maxEpisodePages 剧集分页的最大页数（每页 500 集），防止异常响应导致无限翻页
+const maxEpisodePages = 20
+
+// SeriesBase TVDB 剧集基本信息
+type SeriesBase struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Episode TVDB 单集信息
+type Episode struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	SeasonNumber   int    `json:"seasonNumber"`
+	Number         int    `json:"number"`
+	AbsoluteNumber int    `json:"absoluteNumber"`
+	Aired          string `json:"aired"`
+}
+
+// Season 按季汇总的集数
+type Season struct {
+	SeasonNumber int `json:"season_number"`
+	EpisodeCount int `json:"episode_count"`
+}
+
+// SeriesSeasons 剧集的季结构
+type SeriesSeasons struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Seasons []Season `json:"seasons"`
+}
+
+// episodesResponse GET /series/{id}/episodes/{season-type} 的响应
+type episodesResponse struct {
+	Data struct {
+		Series   SeriesBase `json:"series"`
+		Episodes []Episode  `json:"episodes"`
+	} `json:"data"`
+	Links struct {
+		Next *string `json:"next"`
+	} `json:"links"`
+}
+
+// GetSeriesEpisodesWithContext 获取剧集的全部单集（自动翻页）
+// 调用 GET /v4/series/{id}/episodes/{season-type}?page=N
+func (c *Client) GetSeriesEpisodesWithContext(ctx context.Context, tvdbID int, seasonType string) (*SeriesBase, []Episode, error) {
+	if seasonType == "" {
+		seasonType = SeasonTypeDefault
+	}
+	path := fmt.Sprintf("/series/%d/episodes/%s", tvdbID, url.PathEscape(seasonType))
+
+	var series SeriesBase
+	var episodes []Episode
+	for page := 0; page < maxEpisodePages; page++ {
+		body, err := c.doRequestWithContext(ctx, path, url.Values{"page": {strconv.Itoa(page)}})
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取剧集单集列表失败 (TVDB ID=%d): %w", tvdbID, err)
+		}
+
+		var resp episodesResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, nil, fmt.Errorf("解析剧集单集列表失败 (TVDB ID=%d): %w", tvdbID, err)
+		}
+		if page == 0 {
+			series = resp.Data.Series
+		}
+		episodes = append(episodes, resp.Data.Episodes...)
+
+		if resp.Links.Next == nil || *resp.Links.Next == "" || len(resp.Data.Episodes) == 0 {
+			break
+		}
+	}
+
+	if series.ID == 0 {
+		series.ID = tvdbID
+	}
+	return &series, episodes, nil
+}
+
+// GetSeriesSeasonsWithContext 获取剧集按季汇总的集数
+func (c *Client) GetSeriesSeasonsWithContext(ctx context.Context, tvdbID int, seasonType string) (*SeriesSeasons, error) {
+	series, episodes, err := c.GetSeriesEpisodesWithContext(ctx, tvdbID, seasonType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SeriesSeasons{
+		ID:      series.ID,
+		Name:    series.Name,
+		Seasons: CountSeasons(episodes),
+	}, nil
+}
+
+// CountSeasons 按季号统计集数，结果按季号升序排列
+func CountSeasons(episodes []Episode) []Season {
+	counts := make(map[int]int)
+	for _, ep := range episodes {
+		counts[ep.SeasonNumber]++
+	}
+
+	seasons := make([]Season, 0, len(counts))
+	for number, count := range counts {
+		seasons = append(seasons, Season{SeasonNumber: number, EpisodeCount: count})
+	}
+	sort.Slice(seasons, func(i, j int) bool { return seasons[i].SeasonNumber < seasons[j].SeasonNumber })
+	return seasons
+}
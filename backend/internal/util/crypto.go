@@ -4,109 +4,245 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
 )
 
-// getEncryptionKey 从环境变量获取加密密钥，如果不存在则使用默认密钥
-// 注意：生产环境应该使用环境变量设置 ENCRYPTION_KEY
-func getEncryptionKey() []byte {
-	key := os.Getenv("ENCRYPTION_KEY")
-	if key == "" {
-		// 默认密钥（32字节用于AES-256）
-		// 生产环境必须通过环境变量设置
-		key = "default-encryption-key-32bytes"
-	}
-	
-	// 确保密钥长度为32字节（AES-256）
-	keyBytes := []byte(key)
-	if len(keyBytes) < 32 {
-		// 填充到32字节
-		padding := make([]byte, 32-len(keyBytes))
-		keyBytes = append(keyBytes, padding...)
-	} else if len(keyBytes) > 32 {
-		// 截断到32字节
-		keyBytes = keyBytes[:32]
-	}
-	
+const (
+	// defaultEncryptionKey 内置默认密钥，未设置 ENCRYPTION_KEY 时使用（版本 0），不能保护数据，仅用于兼容
+	defaultEncryptionKey = "default-encryption-key-32bytes"
+	// minEncryptionKeyLength 建议的最短密钥长度
+	minEncryptionKeyLength = 16
+	// minCiphertextSize 密文解码后的最短长度：12 字节 nonce 和 16 字节认证标签
+	minCiphertextSize = 28
+)
+
+// encryptionKey 一个版本的加密密钥
+type encryptionKey struct {
+	version  int
+	material string
+}
+
+// aesKey 带版本前缀的密文使用密钥材料的 SHA-256 作为 AES-256 密钥
+func (k encryptionKey) aesKey() []byte {
+	sum := sha256.Sum256([]byte(k.material))
+	return sum[:]
+}
+
+// legacyAESKey 无版本前缀的旧密文使用的密钥：密钥材料补零或截断到 32 字节
+func legacyAESKey(material string) []byte {
+	keyBytes := make([]byte, 32)
+	copy(keyBytes, material)
 	return keyBytes
 }
 
-// Encrypt 使用AES-256-GCM加密数据
-func Encrypt(plaintext string) (string, error) {
-	if plaintext == "" {
-		return "", nil
+// encryptionKeyring 从环境变量读取当前密钥和轮换前的旧密钥
+//   - ENCRYPTION_KEY：当前密钥，未设置时使用内置默认密钥（版本 0）
+//   - ENCRYPTION_KEY_VERSION：当前密钥的版本号（正整数，默认 1），每次轮换时递增
+//   - ENCRYPTION_PREVIOUS_KEYS：轮换前的旧密钥，格式为 "版本:密钥"，多个用逗号分隔，
+//     仅用于解密，启动时会把旧密钥加密的数据重新加密到当前版本
+//
+// 默认密钥始终作为版本 0 参与解密，设置 ENCRYPTION_KEY 后无需额外配置即可迁移默认密钥加密的数据
+func encryptionKeyring() (encryptionKey, map[int]encryptionKey, error) {
+	keys := map[int]encryptionKey{0: {version: 0, material: defaultEncryptionKey}}
+
+	current := keys[0]
+	if material := os.Getenv("ENCRYPTION_KEY"); material != "" {
+		version := 1
+		if v := strings.TrimSpace(os.Getenv("ENCRYPTION_KEY_VERSION")); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return encryptionKey{}, nil, fmt.Errorf("ENCRYPTION_KEY_VERSION 必须为正整数: %q", v)
+			}
+			version = n
+		}
+		current = encryptionKey{version: version, material: material}
 	}
-	
-	key := getEncryptionKey()
-	
-	// 创建AES cipher
+
+	if previous := strings.TrimSpace(os.Getenv("ENCRYPTION_PREVIOUS_KEYS")); previous != "" {
+		for _, item := range strings.Split(previous, ",") {
+			v, material, ok := strings.Cut(strings.TrimSpace(item), ":")
+			version, err := strconv.Atoi(v)
+			if !ok || err != nil || version < 1 || material == "" {
+				return encryptionKey{}, nil, fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS 格式错误，应为 \"版本:密钥\"（版本为正整数）: %q", item)
+			}
+			if version == current.version {
+				return encryptionKey{}, nil, fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS 中的版本 %d 与当前密钥版本相同", version)
+			}
+			keys[version] = encryptionKey{version: version, material: material}
+		}
+	}
+	keys[current.version] = current
+	return current, keys, nil
+}
+
+// CheckEncryptionKeys 校验加密密钥配置，配置错误时返回错误（应拒绝启动）
+func CheckEncryptionKeys() error {
+	_, _, err := encryptionKeyring()
+	return err
+}
+
+// UsingDefaultEncryptionKey 是否在使用内置默认密钥（未设置 ENCRYPTION_KEY）
+func UsingDefaultEncryptionKey() bool {
+	return os.Getenv("ENCRYPTION_KEY") == ""
+}
+
+// WeakEncryptionKey 设置的 ENCRYPTION_KEY 是否过短
+func WeakEncryptionKey() bool {
+	key := os.Getenv("ENCRYPTION_KEY")
+	return key != "" && len(key) < minEncryptionKeyLength
+}
+
+// EncryptionKeyVersion 返回当前密钥版本
+func EncryptionKeyVersion() int {
+	current, _, err := encryptionKeyring()
+	if err != nil {
+		return -1
+	}
+	return current.version
+}
+
+// CiphertextVersion 解析带版本前缀（"v版本:"）的密文，返回密钥版本；旧格式或非密文返回 false
+func CiphertextVersion(ciphertext string) (int, bool) {
+	prefix, encoded, ok := strings.Cut(ciphertext, ":")
+	if !ok || len(prefix) < 2 || prefix[0] != 'v' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(prefix[1:])
+	if err != nil || version < 0 {
+		return 0, false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < minCiphertextSize {
+		return 0, false
+	}
+	return version, true
+}
+
+// LooksLikeLegacyCiphertext 判断值是否符合无版本前缀的旧格式密文（base64 解码后至少包含 nonce 和认证标签）
+func LooksLikeLegacyCiphertext(value string) bool {
+	data, err := base64.StdEncoding.DecodeString(value)
+	return err == nil && len(data) >= minCiphertextSize
+}
+
+// IsCurrentCiphertext 判断密文是否已使用当前版本的密钥加密
+func IsCurrentCiphertext(ciphertext string) bool {
+	version, ok := CiphertextVersion(ciphertext)
+	return ok && version == EncryptionKeyVersion()
+}
+
+// sealGCM 使用 AES-256-GCM 加密，nonce 附加在密文前面
+func sealGCM(key []byte, plaintext string) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	
-	// 创建GCM模式
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	
-	// 生成随机nonce
 	nonce := make([]byte, gcm.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+		return nil, err
 	}
-	
-	// 加密数据（nonce会被附加到密文前面）
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	
-	// 返回base64编码的密文
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return gcm.Seal(nonce, nonce, []byte(plaintext), nil), nil
 }
 
-// Decrypt 使用AES-256-GCM解密数据
-func Decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-	
-	key := getEncryptionKey()
-	
-	// 解码base64
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-	
-	// 创建AES cipher
+// openGCM 解密 sealGCM 生成的数据
+func openGCM(key, data []byte) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
-	
-	// 创建GCM模式
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
 		return "", err
 	}
-	
-	// 检查数据长度
 	nonceSize := gcm.NonceSize()
 	if len(data) < nonceSize {
 		return "", errors.New("密文数据太短")
 	}
-	
-	// 提取nonce和密文
 	nonce, ciphertextBytes := data[:nonceSize], data[nonceSize:]
-	
-	// 解密数据
 	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
 	if err != nil {
 		return "", err
 	}
-	
 	return string(plaintext), nil
 }
+
+// Encrypt 使用当前版本的密钥以 AES-256-GCM 加密数据，密文格式为 "v版本:base64"
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	current, _, err := encryptionKeyring()
+	if err != nil {
+		return "", err
+	}
+	data, err := sealGCM(current.aesKey(), plaintext)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("v%d:%s", current.version, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// Decrypt 解密数据：带版本前缀的密文使用对应版本的密钥，
+// 无前缀的旧密文依次尝试当前密钥、旧密钥和默认密钥（旧版补零方式）
+func Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	current, keys, err := encryptionKeyring()
+	if err != nil {
+		return "", err
+	}
+
+	if version, ok := CiphertextVersion(ciphertext); ok {
+		key, known := keys[version]
+		if !known {
+			return "", fmt.Errorf("未配置密钥版本 %d", version)
+		}
+		_, encoded, _ := strings.Cut(ciphertext, ":")
+		data, _ := base64.StdEncoding.DecodeString(encoded)
+		return openGCM(key.aesKey(), data)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	candidates := []encryptionKey{current}
+	for version, key := range keys {
+		if version != current.version {
+			candidates = append(candidates, key)
+		}
+	}
+	for _, key := range candidates {
+		if plaintext, err := openGCM(legacyAESKey(key.material), data); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", errors.New("无法使用已配置的密钥解密")
+}
+
+// HashToken 计算令牌的 SHA-256 哈希（十六进制），用于存储刷新令牌和 API 令牌
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RandomToken 生成 n 字节的随机令牌（十六进制）
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -14,14 +14,57 @@ api.interceptors.request.use(config => {
   return config
 })
 
-// 响应拦截器：处理 401 未认证响应
+// 保存登录/刷新接口返回的令牌
+export function saveTokens(data) {
+  localStorage.setItem('token', data.token)
+  localStorage.setItem('refreshToken', data.refresh_token)
+}
+
+// 清除本地令牌
+export function clearTokens() {
+  localStorage.removeItem('token')
+  localStorage.removeItem('refreshToken')
+}
+
+// 使用刷新令牌换取新的访问令牌，并发请求共用同一次刷新
+let refreshing = null
+function refreshTokens() {
+  if (!refreshing) {
+    const refreshToken = localStorage.getItem('refreshToken')
+
+    refreshing = (refreshToken
+      ? axios.post(`${api.defaults.baseURL}/auth/refresh`, { refresh_token: refreshToken })
+        .then(({ data }) => saveTokens(data))
+      : Promise.reject(new Error('no refresh token')))
+      .finally(() => { refreshing = null })
+  }
+
+  return refreshing
+}
+
+// 响应拦截器：访问令牌过期时自动刷新并重试一次，刷新失败跳转登录页
 api.interceptors.response.use(
   response => response,
-  error => {
+  async error => {
+    const config = error.config
+    if (error.response?.status === 401 && config && !config._retried && !config.url?.startsWith('/auth/')) {
+      config._retried = true
+      try {
+        await refreshTokens()
+
+        return api(config)
+      }
+      catch {
+        // 刷新失败，继续走登录流程
+      }
+    }
     if (error.response?.status === 401) {
-      localStorage.removeItem('token')
+      clearTokens()
       window.location.href = '/login'
     }
+    // 仍在使用初始密码，跳转到个人设置页修改密码
+    if (error.response?.status === 403 && error.response.data?.password_change_required && window.location.pathname !== '/profile')
+      window.location.href = '/profile'
 
     return Promise.reject(error)
   },